@@ -0,0 +1,52 @@
+package app
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/ijalalfrz/go-serverless/internal/app/config"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/db"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/logger"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/metrics"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/pagination"
+	lambdatransport "github.com/ijalalfrz/go-serverless/internal/pkg/transport/lambda"
+	"github.com/spf13/cobra"
+)
+
+var authorizerCmd = &cobra.Command{
+	Use:   "authorizer",
+	Short: "Authorize API Gateway HTTP API requests as a Lambda authorizer",
+	Run: func(_ *cobra.Command, _ []string) {
+		slog.Debug("command line flags", slog.String("config_path", cfgFilePath))
+		cfg := config.MustInitConfig(cfgFilePath)
+
+		logger.InitStructuredLogger(cfg.LogLevel)
+
+		recorder := makeMetrics(cfg, os.Stdout)
+
+		lambda.Start(metrics.WrapInvoke(recorder, makeAuthorizer(cfg, recorder).Invoke))
+	},
+}
+
+// makeAuthorizer builds the Lambda authorizer, it verifies tokens and API keys like the
+// http command does when AUTH_ENABLED is set.
+func makeAuthorizer(cfg config.Config, recorder *metrics.Metrics) *lambdatransport.Authorizer {
+	apiKeySvc := makeAPIKeyService(
+		cfg,
+		db.InitDynamoDB(cfg, dynamoDBOptions(recorder, makeTracer(cfg))...),
+		pagination.NewCursorCodec(cfg.Pagination.CursorSecret),
+	)
+
+	authorizer, err := lambdatransport.NewAuthorizer(
+		newTokenVerifier(cfg),
+		apiKeySvc,
+		lambdatransport.AuthorizerResponse(cfg.Auth.AuthorizerResponse),
+	)
+	if err != nil {
+		slog.Error("failed to create lambda authorizer", slog.String("error", err.Error()))
+		panic(err)
+	}
+
+	return authorizer
+}
@@ -2,25 +2,52 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http/pprof"
+	"os"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	chiv5adapter "github.com/awslabs/aws-lambda-go-api-proxy/chi"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/go-chi/chi/v5"
 	"github.com/ijalalfrz/go-serverless/internal/app/config"
 	"github.com/ijalalfrz/go-serverless/internal/app/endpoint"
 	"github.com/ijalalfrz/go-serverless/internal/app/repository"
 	"github.com/ijalalfrz/go-serverless/internal/app/router"
 	"github.com/ijalalfrz/go-serverless/internal/app/service"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
 	"github.com/ijalalfrz/go-serverless/internal/pkg/db"
 	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
 	"github.com/ijalalfrz/go-serverless/internal/pkg/logger"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/metrics"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/pagination"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tracing"
+	httptransport "github.com/ijalalfrz/go-serverless/internal/pkg/transport/http"
+	lambdatransport "github.com/ijalalfrz/go-serverless/internal/pkg/transport/lambda"
 	"github.com/spf13/cobra"
 )
 
-type handler func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)
+// Trace exporters.
+const (
+	tracingExporterLog  = "log"
+	tracingExporterFile = "file"
+)
+
+// Panic snapshot sinks.
+const (
+	snapshotSinkLog  = "log"
+	snapshotSinkFile = "file"
+)
+
+// defaultServiceName names the traced service when TRACING_SERVICE_NAME is not set.
+const defaultServiceName = "go-serverless"
+
+var (
+	errUnknownTracingExporter = errors.New("unknown tracing exporter")
+	errUnknownSnapshotSink    = errors.New("unknown panic snapshot sink")
+)
 
 var httpServerCmd = &cobra.Command{
 	Use:   "http",
@@ -31,62 +58,277 @@ var httpServerCmd = &cobra.Command{
 
 		logger.InitStructuredLogger(cfg.LogLevel)
 
-		lambda.Start(getLambdaHandler(cfg))
+		recorder := makeMetrics(cfg, os.Stdout)
+
+		lambda.Start(metrics.WrapInvoke(recorder, getLambdaHandler(cfg, recorder, makeTracer(cfg)).Invoke))
 	},
 }
 
-// create lambda handler.
-func getLambdaHandler(cfg config.Config) handler {
+// create lambda handler serving the events of the configured event source.
+func getLambdaHandler(cfg config.Config, recorder *metrics.Metrics, tracer *tracing.Tracer) *lambdatransport.Handler {
+	router := makeHTTPRouter(cfg, recorder, tracer)
+
+	// Add pprof routes if enabled
+	if cfg.HTTP.PprofEnabled {
+		router.Mount("/", makePprofRouter())
+	}
+
+	handler, err := lambdatransport.NewHandler(router, lambdatransport.EventSource(cfg.Lambda.EventSource))
+	if err != nil {
+		slog.Error("failed to create lambda handler", slog.String("error", err.Error()))
+		panic(err)
+	}
+
+	return handler
+}
+
+// makeHTTPRouter sets up localization and builds the API router shared by the lambda handler
+// and the standalone server, requests are recorded by recorder and traced by tracer unless
+// they are nil.
+func makeHTTPRouter(cfg config.Config, recorder *metrics.Metrics, tracer *tracing.Tracer) *chi.Mux {
 	lang.SetSupportedLanguages(cfg.Locales.SupportedLanguages)
 	lang.SetBasePath(cfg.Locales.BasePath)
+	httptransport.SetProblemDetails(cfg.HTTP.ProblemDetails)
+	httptransport.SetDecoderOptions(httptransport.DecoderOptions{
+		MaxBodySize: cfg.HTTP.MaxBodySize,
+		Strict:      cfg.HTTP.StrictDecoding,
+	})
+
+	dbConn := makeDynamoDB(cfg, recorder, tracer)
+	endpts, apiKeySvc := makeEndpoints(cfg, dbConn)
 
-	endpts := makeEndpoints(cfg)
+	var requestMetrics httptransport.RequestMetrics
+	if recorder != nil {
+		requestMetrics = recorder
+	}
 
-	router := router.MakeHTTPRouter(
+	return router.MakeHTTPRouter(
 		endpts,
+		makeTokenVerifier(cfg),
+		apiKeySvc,
+		makeIdempotencyStore(cfg, dbConn),
+		requestMetrics,
+		tracer,
+		makeSnapshotSink(cfg),
 		cfg,
 	)
+}
 
-	// Add pprof routes if enabled
-	if cfg.HTTP.PprofEnabled {
-		pprofRouter := chi.NewRouter()
-		pprofRouter.HandleFunc("/debug/pprof/*", pprof.Index)
-		pprofRouter.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
-		pprofRouter.HandleFunc("/debug/pprof/profile", pprof.Profile)
-		pprofRouter.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
-		pprofRouter.HandleFunc("/debug/pprof/trace", pprof.Trace)
-		pprofRouter.Handle("/debug/pprof/goroutine", pprof.Handler("goroutine"))
-		pprofRouter.Handle("/debug/pprof/heap", pprof.Handler("heap"))
-		pprofRouter.Handle("/debug/pprof/threadcreate", pprof.Handler("threadcreate"))
-		pprofRouter.Handle("/debug/pprof/block", pprof.Handler("block"))
-		pprofRouter.Handle("/debug/pprof/mutex", pprof.Handler("mutex"))
+// makeMetrics creates the metrics written as EMF documents to emf, nil only serves them to
+// Prometheus. It returns nil when metrics are disabled.
+func makeMetrics(cfg config.Config, emf io.Writer) *metrics.Metrics {
+	if !cfg.Metrics.Enabled {
+		return nil
+	}
+
+	return metrics.New(cfg.Metrics.Namespace, emf)
+}
+
+// makeTracer creates the tracer exporting the spans with the configured exporter, it returns nil
+// when tracing is disabled and panics when the trace file can not be opened.
+func makeTracer(cfg config.Config) *tracing.Tracer {
+	if !cfg.Tracing.Enabled {
+		return nil
+	}
 
-		router.Mount("/", pprofRouter)
+	serviceName := cfg.Tracing.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
 	}
 
-	chiLambda := chiv5adapter.NewV2(router)
+	switch cfg.Tracing.Exporter {
+	case "", tracingExporterLog:
+		return tracing.NewTracer(serviceName, tracing.NewLogExporter(nil))
+	case tracingExporterFile:
+		exporter, err := tracing.NewFileExporter(cfg.Tracing.FilePath, serviceName)
+		if err != nil {
+			slog.Error("failed to create trace exporter", slog.String("error", err.Error()))
+			panic(err)
+		}
+
+		return tracing.NewTracer(serviceName, exporter)
+	default:
+		err := fmt.Errorf("%w: %q", errUnknownTracingExporter, cfg.Tracing.Exporter)
+		slog.Error("failed to create tracer", slog.String("error", err.Error()))
+		panic(err)
+	}
+}
+
+// makeSnapshotSink creates the sink of the panic snapshots, it returns nil when snapshots are
+// disabled and panics when the snapshot file can not be opened.
+func makeSnapshotSink(cfg config.Config) httptransport.SnapshotSink {
+	switch cfg.PanicSnapshot.Sink {
+	case "":
+		return nil
+	case snapshotSinkLog:
+		return httptransport.NewLogSnapshotSink(slog.Default())
+	case snapshotSinkFile:
+		sink, err := httptransport.NewFileSnapshotSink(cfg.PanicSnapshot.FilePath)
+		if err != nil {
+			slog.Error("failed to create panic snapshot sink", slog.String("error", err.Error()))
+			panic(err)
+		}
 
-	return func(ctx context.Context,
-		req events.APIGatewayV2HTTPRequest,
-	) (events.APIGatewayV2HTTPResponse, error) {
-		return chiLambda.ProxyWithContextV2(ctx, req)
+		return sink
+	default:
+		err := fmt.Errorf("%w: %q", errUnknownSnapshotSink, cfg.PanicSnapshot.Sink)
+		slog.Error("failed to create panic snapshot sink", slog.String("error", err.Error()))
+		panic(err)
 	}
 }
 
-func makeEndpoints(cfg config.Config) endpoint.Endpoint {
-	dbConn := db.InitDynamoDB(cfg)
+// makeTokenVerifier loads the JWT verification keys, it returns nil when authentication is disabled.
+func makeTokenVerifier(cfg config.Config) *auth.Verifier {
+	if !cfg.Auth.Enabled {
+		slog.Warn("authentication is disabled, the API is open to anonymous requests")
+
+		return nil
+	}
+
+	return newTokenVerifier(cfg)
+}
+
+// newTokenVerifier loads the JWT verification keys, it panics when a key file can not be loaded.
+func newTokenVerifier(cfg config.Config) *auth.Verifier {
+	var keys []auth.Key
+
+	if cfg.Auth.JWKSFile != "" {
+		jwks, err := auth.LoadJWKSFile(cfg.Auth.JWKSFile)
+		if err != nil {
+			slog.Error("failed to load jwks", slog.String("error", err.Error()))
+			panic(err)
+		}
+
+		keys = append(keys, jwks...)
+	}
+
+	if cfg.Auth.PublicKeyFile != "" {
+		key, err := auth.LoadPublicKeyFile(cfg.Auth.PublicKeyFile)
+		if err != nil {
+			slog.Error("failed to load jwt public key", slog.String("error", err.Error()))
+			panic(err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if cfg.Auth.HMACSecret != "" {
+		keys = append(keys, auth.NewHMACKey([]byte(cfg.Auth.HMACSecret)))
+	}
+
+	if len(keys) == 0 {
+		slog.Warn("no jwt verification key is configured, only API Gateway authorizer claims are accepted")
+	}
+
+	return auth.NewVerifier(keys, auth.VerifierConfig{
+		Issuer:    cfg.Auth.Issuer,
+		Audience:  cfg.Auth.Audience,
+		ClockSkew: cfg.Auth.ClockSkew,
+	})
+}
+
+func makePprofRouter() *chi.Mux {
+	pprofRouter := chi.NewRouter()
+	pprofRouter.HandleFunc("/debug/pprof/*", pprof.Index)
+	pprofRouter.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	pprofRouter.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	pprofRouter.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	pprofRouter.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	pprofRouter.Handle("/debug/pprof/goroutine", pprof.Handler("goroutine"))
+	pprofRouter.Handle("/debug/pprof/heap", pprof.Handler("heap"))
+	pprofRouter.Handle("/debug/pprof/threadcreate", pprof.Handler("threadcreate"))
+	pprofRouter.Handle("/debug/pprof/block", pprof.Handler("block"))
+	pprofRouter.Handle("/debug/pprof/mutex", pprof.Handler("mutex"))
+
+	return pprofRouter
+}
+
+// makeDynamoDB connects to DynamoDB and bootstraps the table when configured, the operations
+// are recorded by recorder and traced by tracer unless they are nil.
+func makeDynamoDB(cfg config.Config, recorder *metrics.Metrics, tracer *tracing.Tracer) *dynamodb.Client {
+	dbConn := db.InitDynamoDB(cfg, dynamoDBOptions(recorder, tracer)...)
+
+	if cfg.DynamoDB.BootstrapTable {
+		if err := db.EnsureTable(context.Background(), dbConn, cfg.DynamoDB.TableName); err != nil {
+			slog.Error("failed to bootstrap table", slog.String("error", err.Error()))
+			panic(err)
+		}
+	}
+
+	return dbConn
+}
+
+// dynamoDBOptions instruments the DynamoDB client.
+func dynamoDBOptions(recorder *metrics.Metrics, tracer *tracing.Tracer) []func(*dynamodb.Options) {
+	var optFns []func(*dynamodb.Options)
+
+	if recorder != nil {
+		optFns = append(optFns, db.WithMetrics(recorder))
+	}
+
+	if tracer != nil {
+		optFns = append(optFns, db.WithTracing(tracer))
+	}
+
+	return optFns
+}
+
+// makeIdempotencyStore builds the idempotency key store, it returns nil when idempotency is disabled.
+func makeIdempotencyStore(cfg config.Config, dbConn *dynamodb.Client) httptransport.IdempotencyStore {
+	if cfg.Idempotency.TTL <= 0 {
+		return nil
+	}
+
+	return repository.NewIdempotencyRepository(
+		dbConn,
+		cfg.DynamoDB.TableName,
+		cfg.Idempotency.TTL,
+		cfg.Idempotency.LockTimeout,
+	)
+}
+
+// makeEndpoints builds the service endpoints, the API key service is returned as well since it
+// verifies the X-API-Key header.
+func makeEndpoints(cfg config.Config, dbConn *dynamodb.Client) (endpoint.Endpoint, *service.APIKeyService) {
+	cursorCodec := pagination.NewCursorCodec(cfg.Pagination.CursorSecret)
+	if cfg.Pagination.CursorSecret == "" {
+		slog.Warn("pagination cursor secret is not set, cursors are only valid within this instance")
+	}
 
 	// init all repo
-	deviceRepository := repository.NewDeviceRepository(dbConn, cfg.DynamoDB.TableName)
+	deviceRepository := repository.NewDeviceRepository(dbConn, cfg.DynamoDB.TableName, cursorCodec)
+	deviceModelRepository := repository.NewDeviceModelRepository(dbConn, cfg.DynamoDB.TableName, cursorCodec)
+
+	apiKeySvc := makeAPIKeyService(cfg, dbConn, cursorCodec)
 
 	return endpoint.Endpoint{
-		Device: makeDeviceEndpoints(deviceRepository),
-	}
+		Device:      makeDeviceEndpoints(cfg, deviceRepository),
+		DeviceModel: makeDeviceModelEndpoints(deviceModelRepository),
+		APIKey:      endpoint.NewAPIKeyEndpoint(apiKeySvc),
+	}, apiKeySvc
 }
 
-func makeDeviceEndpoints(deviceRepository *repository.DeviceRepository) endpoint.Device {
+// makeAPIKeyService builds the API key service, which also verifies the X-API-Key header.
+func makeAPIKeyService(
+	cfg config.Config,
+	dbConn *dynamodb.Client,
+	cursorCodec *pagination.CursorCodec,
+) *service.APIKeyService {
+	apiKeyRepository := repository.NewAPIKeyRepository(dbConn, cfg.DynamoDB.TableName, cursorCodec)
+
+	return service.NewAPIKeyService(apiKeyRepository, cfg.Auth.APIKeyCacheTTL)
+}
+
+func makeDeviceEndpoints(cfg config.Config, deviceRepository *repository.DeviceRepository) endpoint.Device {
 	// init device service
-	deviceSvc := service.NewDeviceService(deviceRepository)
+	deviceSvc := service.NewDeviceService(deviceRepository, cfg.Device.SoftDeleteRetention)
 
 	return endpoint.NewDeviceEndpoint(deviceSvc)
 }
+
+func makeDeviceModelEndpoints(deviceModelRepository *repository.DeviceModelRepository) endpoint.DeviceModel {
+	// init device model service
+	deviceModelSvc := service.NewDeviceModelService(deviceModelRepository)
+
+	return endpoint.NewDeviceModelEndpoint(deviceModelSvc)
+}
@@ -18,6 +18,8 @@ func init() { //nolint:gochecknoinits
 	rootCmd.PersistentFlags().StringVarP(&cfgFilePath, "config", "c", "", "")
 	rootCmd.AddCommand(
 		httpServerCmd,
+		serveCmd,
+		authorizerCmd,
 	)
 }
 
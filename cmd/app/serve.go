@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/config"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/logger"
+	httptransport "github.com/ijalalfrz/go-serverless/internal/pkg/transport/http"
+	"github.com/spf13/cobra"
+)
+
+// defaultShutdownTimeout bounds the graceful shutdown when HTTP_TIMEOUT is not set.
+const defaultShutdownTimeout = 15 * time.Second
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the REST HTTP/JSON API on a standalone HTTP server",
+	Run: func(_ *cobra.Command, _ []string) {
+		slog.Debug("command line flags", slog.String("config_path", cfgFilePath))
+		cfg := config.MustInitConfig(cfgFilePath)
+
+		logger.InitStructuredLogger(cfg.LogLevel)
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		if err := serve(ctx, cfg); err != nil {
+			slog.Error("server stopped with error", slog.String("error", err.Error()))
+			panic(err)
+		}
+	},
+}
+
+// serve runs the API server, and the pprof server when enabled, until ctx is done and
+// then shuts them down gracefully, letting in-flight requests finish within the timeout.
+func serve(ctx context.Context, cfg config.Config) error {
+	recorder := makeMetrics(cfg, nil)
+	router := makeHTTPRouter(cfg, recorder, makeTracer(cfg))
+
+	if recorder != nil {
+		router.Handle("/metrics", recorder.Handler())
+	}
+
+	servers := []*http.Server{
+		newHTTPServer(cfg, cfg.HTTP.Port, router),
+	}
+
+	if cfg.HTTP.PprofEnabled {
+		pprofServer := newHTTPServer(cfg, cfg.HTTP.PprofPort, makePprofRouter())
+		// CPU profiles and traces stream for the requested number of seconds
+		pprofServer.WriteTimeout = 0
+
+		servers = append(servers, pprofServer)
+	}
+
+	errCh := make(chan error, len(servers))
+
+	for _, server := range servers {
+		go func() {
+			slog.Info("starting http server", slog.String("addr", server.Addr))
+
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- fmt.Errorf("listen on %s: %w", server.Addr, err)
+			}
+		}()
+	}
+
+	var serveErr error
+
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down http server")
+	case serveErr = <-errCh:
+	}
+
+	timeout := cfg.HTTP.Timeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			serveErr = errors.Join(serveErr, fmt.Errorf("shutdown %s: %w", server.Addr, err))
+		}
+	}
+
+	return serveErr
+}
+
+func newHTTPServer(cfg config.Config, port int, handler http.Handler) *http.Server {
+	// leave the timed out requests the time to write their 504 response
+	writeTimeout := cfg.HTTP.Timeout
+	if writeTimeout > 0 {
+		writeTimeout += httptransport.DefaultDeadlineMargin
+	}
+
+	return &http.Server{
+		Addr:              net.JoinHostPort("", strconv.Itoa(port)),
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.HTTP.Timeout,
+		ReadTimeout:       cfg.HTTP.Timeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       cfg.HTTP.Timeout,
+	}
+}
@@ -12,6 +12,16 @@ import (
 // @BasePath  /
 // @license.name Ijal Alfarizi
 // @license.url https://techwithrizal.com
+
+// @securityDefinitions.apikey BearerAuth
+// @in                         header
+// @name                       Authorization
+// @description                JWT bearer token, e.g. "Bearer eyJhbGciOi..."
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in                         header
+// @name                       X-API-Key
+// @description                API key minted by /api/apikeys
 // main.
 func main() {
 	app.Execute()
@@ -18,27 +18,127 @@ func (l LogLeveler) Level() slog.Level {
 
 // Config holds the server configuration.
 type Config struct {
-	LogLevel         LogLeveler `mapstructure:"LOG_LEVEL"`
-	ProfilingEnabled bool       `mapstructure:"PROFILING_ENABLED"`
-	DynamoDB         DynamoDB   `mapstructure:",squash"`
-	HTTP             HTTP       `mapstructure:",squash"`
-	Locales          Locales    `mapstructure:",squash"`
+	LogLevel         LogLeveler    `mapstructure:"LOG_LEVEL"`
+	ProfilingEnabled bool          `mapstructure:"PROFILING_ENABLED"`
+	Auth             Auth          `mapstructure:",squash"`
+	DynamoDB         DynamoDB      `mapstructure:",squash"`
+	HTTP             HTTP          `mapstructure:",squash"`
+	Lambda           Lambda        `mapstructure:",squash"`
+	Locales          Locales       `mapstructure:",squash"`
+	Pagination       Pagination    `mapstructure:",squash"`
+	Device           Device        `mapstructure:",squash"`
+	Idempotency      Idempotency   `mapstructure:",squash"`
+	Metrics          Metrics       `mapstructure:",squash"`
+	Tracing          Tracing       `mapstructure:",squash"`
+	Logging          Logging       `mapstructure:",squash"`
+	PanicSnapshot    PanicSnapshot `mapstructure:",squash"`
+}
+
+// Auth configures the JWT authentication of the API routes, tokens are verified with the keys
+// of the JWKS file, the PEM public key file and the HMAC secret that are set. API keys are
+// accepted as well, verified keys are cached for APIKeyCacheTTL.
+type Auth struct {
+	Enabled       bool          `mapstructure:"AUTH_ENABLED"`
+	JWKSFile      string        `mapstructure:"AUTH_JWKS_FILE"`
+	PublicKeyFile string        `mapstructure:"AUTH_JWT_PUBLIC_KEY_FILE"`
+	HMACSecret    string        `mapstructure:"AUTH_JWT_HMAC_SECRET"`
+	Issuer        string        `mapstructure:"AUTH_JWT_ISSUER"`
+	Audience      string        `mapstructure:"AUTH_JWT_AUDIENCE"`
+	ClockSkew     time.Duration `mapstructure:"AUTH_JWT_CLOCK_SKEW"`
+
+	APIKeyCacheTTL time.Duration `mapstructure:"AUTH_API_KEY_CACHE_TTL"`
+
+	// AuthorizerResponse is the response format of the authorizer command, simple or iam.
+	AuthorizerResponse string `mapstructure:"AUTH_AUTHORIZER_RESPONSE"`
 }
 
 type DynamoDB struct {
 	Endpoint  string `mapstructure:"DYNAMODB_ENDPOINT"`
 	Region    string `mapstructure:"DYNAMODB_REGION"`
 	TableName string `mapstructure:"DYNAMODB_TABLE_NAME"`
+	// BootstrapTable creates the table and its secondary indexes on startup when they are missing,
+	// it is meant for DynamoDB Local, deployed tables are managed by terraform.
+	BootstrapTable bool `mapstructure:"DYNAMODB_BOOTSTRAP_TABLE"`
 }
 
 type HTTP struct {
+	// Port is the listening port of the standalone server started by the serve command.
+	Port          int           `mapstructure:"HTTP_PORT"`
 	Timeout       time.Duration `mapstructure:"HTTP_TIMEOUT"`
 	PprofEnabled  bool          `mapstructure:"PPROF_ENABLED"`
 	PprofPort     int           `mapstructure:"PPROF_PORT"`
 	AllowedOrigin []string      `mapstructure:"ALLOWED_ORIGIN"`
+	// ProblemDetails returns RFC 7807 application/problem+json errors to every client instead of
+	// only to the clients sending Accept: application/problem+json.
+	ProblemDetails bool `mapstructure:"HTTP_PROBLEM_DETAILS"`
+	// MaxBodySize is the size limit of the request bodies in bytes, zero uses 1 MiB.
+	MaxBodySize int64 `mapstructure:"HTTP_MAX_BODY_SIZE"`
+	// StrictDecoding refuses the request bodies with unknown fields or trailing data.
+	StrictDecoding bool `mapstructure:"HTTP_STRICT_DECODING"`
+}
+
+type Lambda struct {
+	// EventSource is the service invoking the function: apigateway_v1, apigateway_v2, alb,
+	// function_url, or auto (the default) to detect it from every event.
+	EventSource string `mapstructure:"LAMBDA_EVENT_SOURCE"`
+	// DeadlineMargin is the time kept before the invocation deadline to answer the requests
+	// which did not complete, HTTP_TIMEOUT applies when it ends earlier. Zero uses 500ms.
+	DeadlineMargin time.Duration `mapstructure:"LAMBDA_DEADLINE_MARGIN"`
 }
 
 type Locales struct {
 	BasePath           string `mapstructure:"LOCALES_BASE_PATH"`
 	SupportedLanguages string `mapstructure:"LOCALES_SUPPORTED_LANGUAGES"`
 }
+
+type Pagination struct {
+	CursorSecret string `mapstructure:"PAGINATION_CURSOR_SECRET"`
+}
+
+type Device struct {
+	// SoftDeleteRetention is how long soft-deleted devices are kept before DynamoDB TTL purges them,
+	// zero keeps them forever.
+	SoftDeleteRetention time.Duration `mapstructure:"DEVICE_SOFT_DELETE_RETENTION"`
+}
+
+// Idempotency configures the deduplication of the mutating requests sent with an X-Transaction-Id
+// header. Responses are replayed for TTL, zero disables it. A request holds its key for at most
+// LockTimeout, afterwards the key can be retried, zero uses 30s.
+type Idempotency struct {
+	TTL         time.Duration `mapstructure:"IDEMPOTENCY_TTL"`
+	LockTimeout time.Duration `mapstructure:"IDEMPOTENCY_LOCK_TIMEOUT"`
+}
+
+// Metrics configures the application metrics, written as CloudWatch Embedded Metric Format logs
+// by the Lambda commands and served at /metrics by the serve command.
+type Metrics struct {
+	Enabled   bool   `mapstructure:"METRICS_ENABLED"`
+	Namespace string `mapstructure:"METRICS_NAMESPACE"`
+}
+
+// Tracing configures the spans started for the HTTP requests and the DynamoDB operations. They
+// are exported as log lines, or as OTLP/JSON lines appended to FilePath with the file exporter.
+type Tracing struct {
+	Enabled     bool   `mapstructure:"TRACING_ENABLED"`
+	Exporter    string `mapstructure:"TRACING_EXPORTER"`
+	FilePath    string `mapstructure:"TRACING_FILE_PATH"`
+	ServiceName string `mapstructure:"TRACING_SERVICE_NAME"`
+}
+
+// Logging configures the request logs. Body fields and query parameters matching the RedactFields
+// JSON paths and the RedactHeaders headers are redacted, bodies are truncated after MaxBodySize bytes and only a
+// SuccessSampleRate fraction of the successful requests is logged, zero logging none of them.
+// Every successful request is logged when SuccessSampleRate is not set.
+type Logging struct {
+	RedactFields      []string `mapstructure:"LOG_REDACT_FIELDS"`
+	RedactHeaders     []string `mapstructure:"LOG_REDACT_HEADERS"`
+	MaxBodySize       int      `mapstructure:"LOG_MAX_BODY_SIZE"`
+	SuccessSampleRate *float64 `mapstructure:"LOG_SUCCESS_SAMPLE_RATE"`
+}
+
+// PanicSnapshot configures where a sanitized snapshot of the requests whose handler panicked is
+// stored: nowhere when Sink is empty, in the logs with log, or appended to FilePath with file.
+type PanicSnapshot struct {
+	Sink     string `mapstructure:"PANIC_SNAPSHOT_SINK"`
+	FilePath string `mapstructure:"PANIC_SNAPSHOT_FILE_PATH"`
+}
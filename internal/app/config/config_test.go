@@ -4,6 +4,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -16,5 +17,33 @@ func TestLoadConfig(t *testing.T) {
 		assert.Equal(t, "http://dynamodb:8000", config.DynamoDB.Endpoint)
 		assert.Equal(t, "ap-southeast-1", config.DynamoDB.Region)
 		assert.Equal(t, "devices_rizal_alfarizi_local", config.DynamoDB.TableName)
+		assert.True(t, config.DynamoDB.BootstrapTable)
+		assert.Equal(t, 3000, config.HTTP.Port)
+		assert.False(t, config.HTTP.ProblemDetails)
+		assert.Equal(t, 15*time.Second, config.HTTP.Timeout)
+		assert.Equal(t, int64(1048576), config.HTTP.MaxBodySize)
+		assert.True(t, config.HTTP.StrictDecoding)
+		assert.Equal(t, 500*time.Millisecond, config.Lambda.DeadlineMargin)
+		assert.False(t, config.Auth.Enabled)
+		assert.Equal(t, "local-jwt-secret", config.Auth.HMACSecret)
+		assert.Equal(t, 30*time.Second, config.Auth.ClockSkew)
+		assert.Equal(t, time.Minute, config.Auth.APIKeyCacheTTL)
+		assert.Equal(t, "simple", config.Auth.AuthorizerResponse)
+		assert.Equal(t, 24*time.Hour, config.Idempotency.TTL)
+		assert.Equal(t, 30*time.Second, config.Idempotency.LockTimeout)
+		assert.True(t, config.Metrics.Enabled)
+		assert.Equal(t, "GoServerless", config.Metrics.Namespace)
+		assert.True(t, config.Tracing.Enabled)
+		assert.Equal(t, "log", config.Tracing.Exporter)
+		assert.Equal(t, "traces.jsonl", config.Tracing.FilePath)
+		assert.Equal(t, "go-serverless", config.Tracing.ServiceName)
+		assert.Equal(t, []string{"serial"}, config.Logging.RedactFields)
+		assert.Equal(t, []string{"Authorization", "X-API-Key"}, config.Logging.RedactHeaders)
+		assert.Equal(t, 4096, config.Logging.MaxBodySize)
+		if assert.NotNil(t, config.Logging.SuccessSampleRate) {
+			assert.Equal(t, 1.0, *config.Logging.SuccessSampleRate)
+		}
+		assert.Equal(t, "file", config.PanicSnapshot.Sink)
+		assert.Equal(t, "panics.jsonl", config.PanicSnapshot.FilePath)
 	})
 }
@@ -0,0 +1,115 @@
+package dto
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
+)
+
+// CreateAPIKeyRequest is the request body for the CreateAPIKey endpoint. The owner is the
+// authenticated caller, who can only grant the scopes it holds.
+type CreateAPIKeyRequest struct {
+	Name      string       `json:"name"      validate:"required,max=100"`
+	Scopes    []string     `json:"scopes"    validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time   `json:"expiresAt"` //nolint:tagliatelle
+	Owner     string       `json:"-"`
+	Caller    *auth.Claims `json:"-"`
+}
+
+func (r *CreateAPIKeyRequest) Bind(req *http.Request) error {
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestAPIKey)
+	}
+
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return NewInvalidRequestError(errors.New("expiresAt must be in the future"), InvalidRequestAPIKey)
+	}
+
+	r.Owner = AnonymousSubject
+	if reqContext, ok := RequestFromContext(req.Context()); ok {
+		if reqContext.Subject != "" {
+			r.Owner = reqContext.Subject
+		}
+
+		r.Caller = reqContext.Claims
+	}
+
+	return nil
+}
+
+// ListAPIKeysRequest is the query param for the ListAPIKeys endpoint.
+type ListAPIKeysRequest struct {
+	Limit  int32  `json:"limit"  validate:"min=1,max=100"`
+	Cursor string `json:"cursor"`
+}
+
+func (r *ListAPIKeysRequest) Bind(req *http.Request) error {
+	if err := bindPage(req, &r.Limit, &r.Cursor); err != nil {
+		return err
+	}
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestPagination)
+	}
+
+	return nil
+}
+
+// RotateAPIKeyRequest is the url param for the RotateAPIKey endpoint.
+type RotateAPIKeyRequest struct {
+	ID string `json:"-" validate:"required"`
+}
+
+func (r *RotateAPIKeyRequest) Bind(req *http.Request) error {
+	r.ID = chi.URLParam(req, "id")
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, RequiredAPIKeyID)
+	}
+
+	return nil
+}
+
+// RevokeAPIKeyRequest is the url param for the RevokeAPIKey endpoint.
+type RevokeAPIKeyRequest struct {
+	ID string `json:"-" validate:"required"`
+}
+
+func (r *RevokeAPIKeyRequest) Bind(req *http.Request) error {
+	r.ID = chi.URLParam(req, "id")
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, RequiredAPIKeyID)
+	}
+
+	return nil
+}
+
+// APIKeyResponse is the response body of an API key, the secret is never returned after
+// the key is created or rotated.
+type APIKeyResponse struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Owner     string     `json:"owner"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"createdAt"`           //nolint:tagliatelle
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"` //nolint:tagliatelle
+	RevokedAt *time.Time `json:"revokedAt,omitempty"` //nolint:tagliatelle
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` //nolint:tagliatelle
+}
+
+// APIKeySecretResponse is the response body for the CreateAPIKey and RotateAPIKey endpoints,
+// Key is the value of the X-API-Key header and is only shown once.
+type APIKeySecretResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// ListAPIKeysResponse is the response body for the ListAPIKeys endpoint.
+type ListAPIKeysResponse struct {
+	APIKeys    []APIKeyResponse `json:"apiKeys"`              //nolint:tagliatelle
+	NextCursor string           `json:"nextCursor,omitempty"` //nolint:tagliatelle
+}
@@ -1,13 +1,120 @@
 package dto
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/requestid"
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+// newValidator returns a validator reporting fields by their JSON name, fields hidden from
+// JSON are path params and reported by their lower case name.
+func newValidator() *validator.Validate {
+	validate := validator.New()
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return strings.ToLower(field.Name)
+		}
+
+		return name
+	})
+
+	return validate
+}
 
 // ErrorResponse response payload.
 type ErrorResponse struct {
-	Error  string `json:"error"`
-	UICode string `json:"uiCode"` //nolint:tagliatelle
+	Error   string        `json:"error"`
+	UICode  string        `json:"uiCode"` //nolint:tagliatelle
+	Details []ErrorDetail `json:"details,omitempty"`
+	// RequestID identifies the failed request in the logs.
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// ErrorDetail describes a request field failing validation.
+type ErrorDetail struct {
+	// Field is the JSON path of the field, e.g. devices[0].serial.
+	Field string `json:"field"`
+	// Rule is the failed validation rule, e.g. required or max.
+	Rule string `json:"rule"`
+	// Param is the parameter of the rule, e.g. 100 for max=100.
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewErrorResponse maps err to its HTTP status code and response payload, application errors
+// are localized to the language of the request context.
+func NewErrorResponse(ctx context.Context, err error) (int, ErrorResponse) {
+	var appErr exception.ApplicationError
+
+	ids, _ := requestid.FromContext(ctx)
+
+	if !errors.As(err, &appErr) {
+		return http.StatusInternalServerError, ErrorResponse{
+			Error:     err.Error(),
+			UICode:    exception.InternalServerError,
+			RequestID: ids.RequestID,
+		}
+	}
+
+	// in case of failure to get request context, default language will be used
+	reqContext, _ := RequestFromContext(ctx)
+
+	resp := ErrorResponse{
+		Error:     appErr.Localize(reqContext.Language),
+		UICode:    appErr.UICode,
+		RequestID: ids.RequestID,
+	}
+
+	for _, violation := range appErr.Details {
+		resp.Details = append(resp.Details, ErrorDetail{
+			Field:   violation.Field,
+			Rule:    violation.Rule,
+			Param:   violation.Param,
+			Message: violation.Localize(reqContext.Language),
+		})
+	}
+
+	return appErr.StatusCode, resp
+}
+
+// ProblemDetails is the RFC 7807 error response payload, uiCode and the validation errors are
+// extension members.
+type ProblemDetails struct {
+	Type     string        `json:"type"`
+	Title    string        `json:"title"`
+	Status   int           `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Instance string        `json:"instance,omitempty"`
+	UICode   string        `json:"uiCode,omitempty"` //nolint:tagliatelle
+	Errors   []ErrorDetail `json:"errors,omitempty"`
+	// RequestID identifies the failed request in the logs.
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// NewProblemDetails maps err to its HTTP status code and problem details document. The problem
+// type is about:blank, so the title is the status text and the localized message the detail.
+func NewProblemDetails(ctx context.Context, err error) (int, ProblemDetails) {
+	statusCode, resp := NewErrorResponse(ctx, err)
+	reqContext, _ := RequestFromContext(ctx)
+
+	return statusCode, ProblemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(statusCode),
+		Status:    statusCode,
+		Detail:    resp.Error,
+		Instance:  reqContext.Path,
+		UICode:    resp.UICode,
+		Errors:    resp.Details,
+		RequestID: resp.RequestID,
+	}
 }
@@ -0,0 +1,143 @@
+//go:build unit
+
+package dto
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewErrorResponse(t *testing.T) {
+	lang.SetBasePath("../../../resources/locales")
+	lang.SetSupportedLanguages("en,es")
+
+	t.Cleanup(func() { lang.SetSupportedLanguages("en") })
+
+	t.Run("application_error", func(t *testing.T) {
+		err := exception.ErrConflict
+		err.UICode = exception.DeviceAlreadyExist
+		err.MessageVars = map[string]interface{}{"name": "Device"}
+
+		status, resp := NewErrorResponse(context.Background(), err)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, ErrorResponse{Error: "Device record already exist", UICode: exception.DeviceAlreadyExist}, resp)
+	})
+
+	t.Run("other_error", func(t *testing.T) {
+		status, resp := NewErrorResponse(context.Background(), errors.New("boom"))
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.Equal(t, ErrorResponse{Error: "boom", UICode: exception.InternalServerError}, resp)
+	})
+
+	t.Run("internal_error", func(t *testing.T) {
+		err := exception.ErrInternal
+		err.UICode = exception.InternalServerError
+		err.Cause = errors.New("panic: boom")
+
+		ctx := WithRequestContext(context.Background(), RequestContext{Language: "es"})
+
+		status, resp := NewErrorResponse(ctx, err)
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.Equal(t, ErrorResponse{
+			Error:  "Ocurrió un error inesperado, reintente más tarde o informe el ID de la solicitud",
+			UICode: exception.InternalServerError,
+		}, resp)
+	})
+
+	t.Run("request_id", func(t *testing.T) {
+		ctx := requestid.NewContext(context.Background(), requestid.IDs{RequestID: "req-1"})
+
+		_, resp := NewErrorResponse(ctx, exception.ErrConflict)
+		assert.Equal(t, "req-1", resp.RequestID)
+
+		_, resp = NewErrorResponse(ctx, errors.New("boom"))
+		assert.Equal(t, "req-1", resp.RequestID)
+
+		_, problem := NewProblemDetails(ctx, exception.ErrConflict)
+		assert.Equal(t, "req-1", problem.RequestID)
+	})
+}
+
+func TestNewErrorResponseValidationDetails(t *testing.T) {
+	t.Run("create_device", func(t *testing.T) {
+		req := CreateDeviceRequest{ID: "/devices/d1", DeviceModel: "/devicemodels/m1", Note: "note"}
+
+		status, resp := NewErrorResponse(context.Background(), req.Validate())
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, InvalidRequestDevice, resp.UICode)
+		assert.Equal(t, []ErrorDetail{
+			{Field: "name", Rule: "required", Message: "name is required"},
+			{Field: "serial", Rule: "required", Message: "serial is required"},
+		}, resp.Details)
+	})
+
+	t.Run("rule_params_per_kind", func(t *testing.T) {
+		name := ""
+		devices := make([]CreateDeviceRequest, MaxBatchSize+1)
+
+		patchReq := httptest.NewRequest(http.MethodPatch, "/devices/d1", nil)
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("id", "/devices/d1")
+		patchReq = patchReq.WithContext(context.WithValue(patchReq.Context(), chi.RouteCtxKey, routeCtx))
+
+		testCases := []struct {
+			name     string
+			err      error
+			uiCode   string
+			expected ErrorDetail
+		}{
+			{
+				name:   "number",
+				err:    (&ListDeviceModelsRequest{}).Bind(httptest.NewRequest(http.MethodGet, "/?limit=101", nil)),
+				uiCode: InvalidRequestPagination,
+				expected: ErrorDetail{
+					Field: "limit", Rule: "max", Param: "100", Message: "limit must be at most 100",
+				},
+			},
+			{
+				name:   "string",
+				err:    (&PatchDeviceRequest{Name: &name}).Bind(patchReq),
+				uiCode: InvalidRequestDeviceUpdate,
+				expected: ErrorDetail{
+					Field: "name", Rule: "min", Param: "1", Message: "name must be at least 1 characters long",
+				},
+			},
+			{
+				name:   "items",
+				err:    (&BatchCreateDevicesRequest{Devices: devices}).Bind(nil),
+				uiCode: InvalidRequestBatch,
+				expected: ErrorDetail{
+					Field: "devices", Rule: "max", Param: "500", Message: "devices must contain at most 500 items",
+				},
+			},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.name, func(t *testing.T) {
+				_, resp := NewErrorResponse(context.Background(), testCase.err)
+				assert.Equal(t, testCase.uiCode, resp.UICode)
+				assert.Equal(t, []ErrorDetail{testCase.expected}, resp.Details)
+			})
+		}
+	})
+
+	t.Run("localized", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), requestContextKey, RequestContext{Language: "es"})
+		req := BatchGetDevicesRequest{}
+
+		_, resp := NewErrorResponse(ctx, req.Bind(nil))
+		assert.Equal(t, "Solicitud inválida", resp.Error)
+		assert.Equal(t, []ErrorDetail{
+			{Field: "ids", Rule: "required", Message: "ids es obligatorio"},
+		}, resp.Details)
+	})
+}
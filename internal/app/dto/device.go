@@ -1,8 +1,12 @@
 package dto
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
@@ -18,8 +22,13 @@ type CreateDeviceRequest struct {
 }
 
 func (r *CreateDeviceRequest) Bind(_ *http.Request) error {
+	return r.Validate()
+}
+
+// Validate checks the device fields, it is shared by the single and batch create endpoints.
+func (r *CreateDeviceRequest) Validate() error {
 	if err := validate.Struct(r); err != nil {
-		return NewInvalidRequestError(err, InvalidRequestDevicePrefix)
+		return NewInvalidRequestError(err, InvalidRequestDevice)
 	}
 
 	if !validatePrefixDeviceID(r.ID) {
@@ -43,6 +52,36 @@ func validatePrefixDeviceModel(deviceModel string) bool {
 	return strings.HasPrefix(deviceModel, "/devicemodels/")
 }
 
+// MaxBatchSize is the maximum number of entries of a batch request.
+const MaxBatchSize = 500
+
+// BatchCreateDevicesRequest is the request body for the BatchCreateDevices endpoint.
+// Entries are validated one by one, an invalid entry fails on its own.
+type BatchCreateDevicesRequest struct {
+	Devices []CreateDeviceRequest `json:"devices" validate:"required,min=1,max=500"`
+}
+
+func (r *BatchCreateDevicesRequest) Bind(_ *http.Request) error {
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestBatch)
+	}
+
+	return nil
+}
+
+// BatchGetDevicesRequest is the request body for the BatchGetDevices endpoint.
+type BatchGetDevicesRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=500"`
+}
+
+func (r *BatchGetDevicesRequest) Bind(_ *http.Request) error {
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestBatch)
+	}
+
+	return nil
+}
+
 // GetDeviceByIDRequest is the url param for the GetDeviceByID endpoint.
 type GetDeviceByIDRequest struct {
 	ID string `json:"id" validate:"required"`
@@ -59,6 +98,239 @@ func (r *GetDeviceByIDRequest) Bind(req *http.Request) error {
 	return nil
 }
 
+// DefaultListLimit is the page size used when the client does not send a limit.
+const DefaultListLimit = 20
+
+// ListDevicesRequest is the query param for the ListDevices endpoint.
+// A serial narrows the result down to the device owning that serial,
+// a device model to the devices of that model.
+type ListDevicesRequest struct {
+	Limit       int32  `json:"limit"       validate:"min=1,max=100"`
+	Cursor      string `json:"cursor"`
+	Serial      string `json:"serial"`
+	DeviceModel string `json:"deviceModel"` //nolint:tagliatelle
+}
+
+func (r *ListDevicesRequest) Bind(req *http.Request) error {
+	if err := bindPage(req, &r.Limit, &r.Cursor); err != nil {
+		return err
+	}
+
+	r.Serial = req.URL.Query().Get("serial")
+	r.DeviceModel = req.URL.Query().Get("deviceModel")
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestPagination)
+	}
+
+	if r.DeviceModel != "" && !validatePrefixDeviceModel(r.DeviceModel) {
+		return NewInvalidRequestError(errors.New("device model must start with /devicemodels/"),
+			InvalidRequestDeviceModelPrefix)
+	}
+
+	return nil
+}
+
+// ListDevicesByModelRequest is the url and query param for the ListDevicesByModel endpoint.
+type ListDevicesByModelRequest struct {
+	ID     string `json:"id"     validate:"required"`
+	Limit  int32  `json:"limit"  validate:"min=1,max=100"`
+	Cursor string `json:"cursor"`
+}
+
+func (r *ListDevicesByModelRequest) Bind(req *http.Request) error {
+	r.ID = chi.URLParam(req, "id")
+
+	if err := bindPage(req, &r.Limit, &r.Cursor); err != nil {
+		return err
+	}
+
+	if r.ID == "" {
+		return NewInvalidRequestError(errors.New("device model id is required"), RequiredDeviceModelID)
+	}
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestPagination)
+	}
+
+	return nil
+}
+
+// bindPage reads the limit and cursor query params of a list endpoint.
+func bindPage(req *http.Request, limit *int32, cursor *string) error {
+	query := req.URL.Query()
+
+	*limit = DefaultListLimit
+	*cursor = query.Get("cursor")
+
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return NewInvalidRequestError(errors.New("limit must be a number"), InvalidRequestPagination)
+		}
+
+		*limit = int32(parsed)
+	}
+
+	return nil
+}
+
+// UpdateDeviceRequest is the request body for the UpdateDevice endpoint.
+type UpdateDeviceRequest struct {
+	ID          string `json:"-"           validate:"required"`
+	IfMatch     string `json:"-"`
+	DeviceModel string `json:"deviceModel" validate:"required"` //nolint:tagliatelle
+	Name        string `json:"name"        validate:"required"`
+	Note        string `json:"note"        validate:"required"`
+	Serial      string `json:"serial"      validate:"required"`
+}
+
+func (r *UpdateDeviceRequest) Bind(req *http.Request) error {
+	r.ID = chi.URLParam(req, "id")
+	r.IfMatch = req.Header.Get("If-Match")
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestDeviceUpdate)
+	}
+
+	if !validatePrefixDeviceModel(r.DeviceModel) {
+		return NewInvalidRequestError(errors.New("device model must start with /devicemodels/"),
+			InvalidRequestDeviceModelPrefix)
+	}
+
+	return nil
+}
+
+// PatchDeviceRequest is the JSON Merge Patch (RFC 7396) body for the PatchDevice endpoint.
+// Absent members are left untouched, present members replace the stored value.
+type PatchDeviceRequest struct {
+	ID          string  `json:"-"           validate:"required"`
+	IfMatch     string  `json:"-"`
+	DeviceModel *string `json:"deviceModel" validate:"omitnil,min=1"` //nolint:tagliatelle
+	Name        *string `json:"name"        validate:"omitnil,min=1"`
+	Note        *string `json:"note"        validate:"omitnil,min=1"`
+	Serial      *string `json:"serial"      validate:"omitnil,min=1"`
+
+	// nullMembers holds members explicitly set to null, which would remove a required attribute.
+	nullMembers []string
+}
+
+func (r *PatchDeviceRequest) UnmarshalJSON(data []byte) error {
+	return r.unmarshalJSON(data, false)
+}
+
+// UnmarshalStrictJSON is UnmarshalJSON refusing the unknown members.
+func (r *PatchDeviceRequest) UnmarshalStrictJSON(data []byte) error {
+	return r.unmarshalJSON(data, true)
+}
+
+func (r *PatchDeviceRequest) unmarshalJSON(data []byte, strict bool) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return fmt.Errorf("unmarshal merge patch: %w", err)
+	}
+
+	fields := map[string]**string{
+		"deviceModel": &r.DeviceModel,
+		"name":        &r.Name,
+		"note":        &r.Note,
+		"serial":      &r.Serial,
+	}
+
+	for member, raw := range members {
+		field, ok := fields[member]
+		if !ok && strict {
+			return fmt.Errorf("json: unknown field %q", member) //nolint:err113
+		}
+
+		if !ok {
+			continue
+		}
+
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			r.nullMembers = append(r.nullMembers, member)
+
+			continue
+		}
+
+		if err := json.Unmarshal(raw, field); err != nil {
+			return fmt.Errorf("unmarshal merge patch member %s: %w", member, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *PatchDeviceRequest) Bind(req *http.Request) error {
+	r.ID = chi.URLParam(req, "id")
+	r.IfMatch = req.Header.Get("If-Match")
+
+	if len(r.nullMembers) > 0 {
+		return NewInvalidRequestError(fmt.Errorf("%s cannot be removed", strings.Join(r.nullMembers, ", ")),
+			InvalidRequestDeviceUpdate)
+	}
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestDeviceUpdate)
+	}
+
+	if r.DeviceModel != nil && !validatePrefixDeviceModel(*r.DeviceModel) {
+		return NewInvalidRequestError(errors.New("device model must start with /devicemodels/"),
+			InvalidRequestDeviceModelPrefix)
+	}
+
+	return nil
+}
+
+// AnonymousSubject is recorded as actor when the request is not authenticated.
+const AnonymousSubject = "anonymous"
+
+// DeleteDeviceRequest is the url param for the DeleteDevice endpoint.
+type DeleteDeviceRequest struct {
+	ID        string `json:"-" validate:"required"`
+	Hard      bool   `json:"-"`
+	DeletedBy string `json:"-"`
+}
+
+func (r *DeleteDeviceRequest) Bind(req *http.Request) error {
+	r.ID = chi.URLParam(req, "id")
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, RequiredDeviceID)
+	}
+
+	if hard := req.URL.Query().Get("hard"); hard != "" {
+		parsed, err := strconv.ParseBool(hard)
+		if err != nil {
+			return NewInvalidRequestError(errors.New("hard must be a boolean"), InvalidRequestDeviceDelete)
+		}
+
+		r.Hard = parsed
+	}
+
+	r.DeletedBy = AnonymousSubject
+	if reqContext, ok := RequestFromContext(req.Context()); ok && reqContext.Subject != "" {
+		r.DeletedBy = reqContext.Subject
+	}
+
+	return nil
+}
+
+// RestoreDeviceRequest is the url param for the RestoreDevice endpoint.
+type RestoreDeviceRequest struct {
+	ID string `json:"-" validate:"required"`
+}
+
+func (r *RestoreDeviceRequest) Bind(req *http.Request) error {
+	r.ID = chi.URLParam(req, "id")
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, RequiredDeviceID)
+	}
+
+	return nil
+}
+
 // DeviceResponse is the response body for the GetDeviceByID endpoint.
 type DeviceResponse struct {
 	ID          string `json:"id"`
@@ -66,4 +338,31 @@ type DeviceResponse struct {
 	Name        string `json:"name"`
 	Note        string `json:"note"`
 	Serial      string `json:"serial"`
+	Version     int64  `json:"version"`
+}
+
+// ETag returns the entity tag sent in the ETag response header.
+func (r DeviceResponse) ETag() string {
+	return ETag(r.Version)
+}
+
+// ListDevicesResponse is the response body for the ListDevices endpoint.
+type ListDevicesResponse struct {
+	Devices    []DeviceResponse `json:"devices"`
+	NextCursor string           `json:"nextCursor,omitempty"` //nolint:tagliatelle
+}
+
+// BatchDeviceResult is the outcome of one entry of a batch request, Status is the HTTP status
+// the entry would have had as a single request. Device is set on success, Error on failure.
+type BatchDeviceResult struct {
+	Index  int             `json:"index"`
+	ID     string          `json:"id"`
+	Status int             `json:"status"`
+	Device *DeviceResponse `json:"device,omitempty"`
+	Error  *ErrorResponse  `json:"error,omitempty"`
+}
+
+// BatchDevicesResponse is the response body for the batch endpoints, results are in request order.
+type BatchDevicesResponse struct {
+	Results []BatchDeviceResult `json:"results"`
 }
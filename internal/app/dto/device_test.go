@@ -6,9 +6,11 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -79,6 +81,31 @@ func TestCreateDeviceRequest_Bind(t *testing.T) {
 	))
 }
 
+func TestBatchCreateDevicesRequest_Bind(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/api/devices:batchCreate", nil)
+
+	empty := BatchCreateDevicesRequest{}
+	assert.Error(t, empty.Bind(request))
+
+	tooLarge := BatchCreateDevicesRequest{Devices: make([]CreateDeviceRequest, MaxBatchSize+1)}
+	assert.Error(t, tooLarge.Bind(request))
+
+	// entries are validated one by one by the service
+	invalidEntry := BatchCreateDevicesRequest{Devices: []CreateDeviceRequest{{ID: "123"}}}
+	assert.NoError(t, invalidEntry.Bind(request))
+	assert.Error(t, invalidEntry.Devices[0].Validate())
+}
+
+func TestBatchGetDevicesRequest_Bind(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/api/devices:batchGet", nil)
+
+	empty := BatchGetDevicesRequest{}
+	assert.Error(t, empty.Bind(request))
+
+	valid := BatchGetDevicesRequest{IDs: []string{"id1", "/devices/id2"}}
+	assert.NoError(t, valid.Bind(request))
+}
+
 func TestGetDeviceByIDRequest_Bind(t *testing.T) {
 	bindGetDeviceByIDRequest := func(name string, urlParam string, wantID string, wantErr bool) func(t *testing.T) {
 		return func(t *testing.T) {
@@ -116,6 +143,185 @@ func TestGetDeviceByIDRequest_Bind(t *testing.T) {
 	))
 }
 
+func TestListDevicesRequest_Bind(t *testing.T) {
+	bindListDevicesRequest := func(name string, query string, want ListDevicesRequest, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/devices"+query, nil)
+
+			var listReq ListDevicesRequest
+			err := listReq.Bind(req)
+
+			if wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, want, listReq)
+		}
+	}
+
+	t.Run("default_limit", bindListDevicesRequest(
+		"default_limit",
+		"",
+		ListDevicesRequest{Limit: DefaultListLimit},
+		false,
+	))
+
+	t.Run("limit_and_cursor", bindListDevicesRequest(
+		"limit_and_cursor",
+		"?limit=5&cursor=abc.def",
+		ListDevicesRequest{Limit: 5, Cursor: "abc.def"},
+		false,
+	))
+
+	t.Run("limit_not_a_number", bindListDevicesRequest(
+		"limit_not_a_number",
+		"?limit=five",
+		ListDevicesRequest{},
+		true,
+	))
+
+	t.Run("limit_too_large", bindListDevicesRequest(
+		"limit_too_large",
+		"?limit=101",
+		ListDevicesRequest{},
+		true,
+	))
+
+	t.Run("limit_zero", bindListDevicesRequest(
+		"limit_zero",
+		"?limit=0",
+		ListDevicesRequest{},
+		true,
+	))
+
+	t.Run("device_model", bindListDevicesRequest(
+		"device_model",
+		"?deviceModel=/devicemodels/m1",
+		ListDevicesRequest{Limit: DefaultListLimit, DeviceModel: "/devicemodels/m1"},
+		false,
+	))
+
+	t.Run("device_model_without_prefix", bindListDevicesRequest(
+		"device_model_without_prefix",
+		"?deviceModel=m1",
+		ListDevicesRequest{},
+		true,
+	))
+}
+
+func TestPatchDeviceRequest_Bind(t *testing.T) {
+	bindPatchDeviceRequest := func(name string, body string, wantErr bool, check func(t *testing.T, req PatchDeviceRequest)) func(t *testing.T) {
+		return func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "device-1")
+
+			request := httptest.NewRequest(http.MethodPatch, "/api/devices/device-1", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("If-Match", `"2"`)
+			request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+
+			var patchReq PatchDeviceRequest
+			err := render.Bind(request, &patchReq)
+
+			if wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			check(t, patchReq)
+		}
+	}
+
+	t.Run("partial_members", bindPatchDeviceRequest(
+		"partial_members",
+		`{"name":"New Name","unknown":1}`,
+		false,
+		func(t *testing.T, req PatchDeviceRequest) {
+			assert.Equal(t, "device-1", req.ID)
+			assert.Equal(t, `"2"`, req.IfMatch)
+			assert.Equal(t, "New Name", *req.Name)
+			assert.Nil(t, req.Note)
+			assert.Nil(t, req.Serial)
+			assert.Nil(t, req.DeviceModel)
+		},
+	))
+
+	t.Run("null_member", bindPatchDeviceRequest(
+		"null_member",
+		`{"note":null}`,
+		true,
+		nil,
+	))
+
+	t.Run("empty_member", bindPatchDeviceRequest(
+		"empty_member",
+		`{"serial":""}`,
+		true,
+		nil,
+	))
+
+	t.Run("invalid_device_model_prefix", bindPatchDeviceRequest(
+		"invalid_device_model_prefix",
+		`{"deviceModel":"model-x"}`,
+		true,
+		nil,
+	))
+}
+
+func TestDeleteDeviceRequest_Bind(t *testing.T) {
+	bindDeleteDeviceRequest := func(name string, query string, reqContext *RequestContext, want DeleteDeviceRequest, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "device-1")
+
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+			if reqContext != nil {
+				ctx = context.WithValue(ctx, requestContextKey, *reqContext)
+			}
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/devices/device-1"+query, nil).WithContext(ctx)
+
+			var deleteReq DeleteDeviceRequest
+			err := deleteReq.Bind(req)
+
+			if wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, want, deleteReq)
+		}
+	}
+
+	t.Run("soft_delete_anonymous", bindDeleteDeviceRequest(
+		"soft_delete_anonymous",
+		"",
+		nil,
+		DeleteDeviceRequest{ID: "device-1", DeletedBy: AnonymousSubject},
+		false,
+	))
+
+	t.Run("hard_delete_with_subject", bindDeleteDeviceRequest(
+		"hard_delete_with_subject",
+		"?hard=true",
+		&RequestContext{Subject: "user-1"},
+		DeleteDeviceRequest{ID: "device-1", Hard: true, DeletedBy: "user-1"},
+		false,
+	))
+
+	t.Run("invalid_hard", bindDeleteDeviceRequest(
+		"invalid_hard",
+		"?hard=maybe",
+		nil,
+		DeleteDeviceRequest{},
+		true,
+	))
+}
+
 func TestValidatePrefixDeviceID(t *testing.T) {
 	validateDeviceID := func(name string, deviceID string, want bool) func(t *testing.T) {
 		return func(t *testing.T) {
@@ -0,0 +1,121 @@
+package dto
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateDeviceModelRequest is the request body for the CreateDeviceModel endpoint.
+type CreateDeviceModelRequest struct {
+	ID           string            `json:"id"           validate:"required"`
+	Manufacturer string            `json:"manufacturer" validate:"required"`
+	DisplayName  string            `json:"displayName"  validate:"required"` //nolint:tagliatelle
+	Category     string            `json:"category"     validate:"required"`
+	Specs        map[string]string `json:"specs"        validate:"omitempty,max=50,dive,keys,min=1,endkeys"`
+}
+
+func (r *CreateDeviceModelRequest) Bind(_ *http.Request) error {
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestDeviceModel)
+	}
+
+	if !validatePrefixDeviceModel(r.ID) {
+		return NewInvalidRequestError(errors.New("device model id must start with /devicemodels/"),
+			InvalidRequestDeviceModelPrefix)
+	}
+
+	return nil
+}
+
+// GetDeviceModelByIDRequest is the url param for the GetDeviceModelByID endpoint.
+type GetDeviceModelByIDRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+func (r *GetDeviceModelByIDRequest) Bind(req *http.Request) error {
+	r.ID = chi.URLParam(req, "id")
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, RequiredDeviceModelID)
+	}
+
+	return nil
+}
+
+// ListDeviceModelsRequest is the query param for the ListDeviceModels endpoint.
+type ListDeviceModelsRequest struct {
+	Limit  int32  `json:"limit"  validate:"min=1,max=100"`
+	Cursor string `json:"cursor"`
+}
+
+func (r *ListDeviceModelsRequest) Bind(req *http.Request) error {
+	if err := bindPage(req, &r.Limit, &r.Cursor); err != nil {
+		return err
+	}
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestPagination)
+	}
+
+	return nil
+}
+
+// UpdateDeviceModelRequest is the request body for the UpdateDeviceModel endpoint.
+type UpdateDeviceModelRequest struct {
+	ID           string            `json:"-"            validate:"required"`
+	IfMatch      string            `json:"-"`
+	Manufacturer string            `json:"manufacturer" validate:"required"`
+	DisplayName  string            `json:"displayName"  validate:"required"` //nolint:tagliatelle
+	Category     string            `json:"category"     validate:"required"`
+	Specs        map[string]string `json:"specs"        validate:"omitempty,max=50,dive,keys,min=1,endkeys"`
+}
+
+func (r *UpdateDeviceModelRequest) Bind(req *http.Request) error {
+	r.ID = chi.URLParam(req, "id")
+	r.IfMatch = req.Header.Get("If-Match")
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, InvalidRequestDeviceModel)
+	}
+
+	return nil
+}
+
+// DeleteDeviceModelRequest is the url param for the DeleteDeviceModel endpoint.
+type DeleteDeviceModelRequest struct {
+	ID string `json:"-" validate:"required"`
+}
+
+func (r *DeleteDeviceModelRequest) Bind(req *http.Request) error {
+	r.ID = chi.URLParam(req, "id")
+
+	if err := validate.Struct(r); err != nil {
+		return NewInvalidRequestError(err, RequiredDeviceModelID)
+	}
+
+	return nil
+}
+
+// DeviceModelResponse is the response body for the GetDeviceModelByID endpoint.
+type DeviceModelResponse struct {
+	ID           string            `json:"id"`
+	Manufacturer string            `json:"manufacturer"`
+	DisplayName  string            `json:"displayName"` //nolint:tagliatelle
+	Category     string            `json:"category"`
+	Specs        map[string]string `json:"specs,omitempty"`
+	DeviceCount  int64             `json:"deviceCount"` //nolint:tagliatelle
+	Version      int64             `json:"version"`
+}
+
+// ETag returns the entity tag sent in the ETag response header.
+func (r DeviceModelResponse) ETag() string {
+	return ETag(r.Version)
+}
+
+// ListDeviceModelsResponse is the response body for the ListDeviceModels endpoint.
+type ListDeviceModelsResponse struct {
+	DeviceModels []DeviceModelResponse `json:"deviceModels"`         //nolint:tagliatelle
+	NextCursor   string                `json:"nextCursor,omitempty"` //nolint:tagliatelle
+}
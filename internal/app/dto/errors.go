@@ -1,19 +1,65 @@
 package dto
 
 import (
+	"errors"
 	"net/http"
+	"reflect"
+	"strings"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
 	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
 )
 
 const (
+	InvalidRequestDevice            = "INVALID_REQUEST_DEVICE"
 	InvalidRequestDevicePrefix      = "INVALID_REQUEST_DEVICE_PREFIX"
 	InvalidRequestDeviceModelPrefix = "INVALID_REQUEST_DEVICE_MODEL_PREFIX"
 	RequiredDeviceID                = "REQUIRED_DEVICE_ID_PARAM"
+	InvalidRequestPagination        = "INVALID_REQUEST_PAGINATION"
+	InvalidRequestDeviceUpdate      = "INVALID_REQUEST_DEVICE_UPDATE"
+	InvalidRequestDeviceDelete      = "INVALID_REQUEST_DEVICE_DELETE"
+	InvalidRequestDeviceModel       = "INVALID_REQUEST_DEVICE_MODEL"
+	RequiredDeviceModelID           = "REQUIRED_DEVICE_MODEL_ID_PARAM"
+	InvalidRequestBatch             = "INVALID_REQUEST_BATCH"
+	InvalidRequestAPIKey            = "INVALID_REQUEST_API_KEY"
+	RequiredAPIKeyID                = "REQUIRED_API_KEY_ID_PARAM"
 )
 
+// validationMessages holds the default message of the translated validation rules, min, max
+// and len are translated per kind of field, the other rules fall back to validation_invalid.
+var validationMessages = map[string]string{
+	"validation_required":   "{{.field}} is required",
+	"validation_min":        "{{.field}} must be at least {{.param}}",
+	"validation_min_length": "{{.field}} must be at least {{.param}} characters long",
+	"validation_min_items":  "{{.field}} must contain at least {{.param}} items",
+	"validation_max":        "{{.field}} must be at most {{.param}}",
+	"validation_max_length": "{{.field}} must be at most {{.param}} characters long",
+	"validation_max_items":  "{{.field}} must contain at most {{.param}} items",
+	"validation_len":        "{{.field}} must be {{.param}}",
+	"validation_len_length": "{{.field}} must be {{.param}} characters long",
+	"validation_len_items":  "{{.field}} must contain {{.param}} items",
+	"validation_oneof":      "{{.field}} must be one of [{{.param}}]",
+	"validation_invalid":    "{{.field}} is invalid, failed on the {{.rule}} rule",
+}
+
+// NewInvalidRequestError returns a bad request error, validation errors list the failing
+// fields in the error details.
 func NewInvalidRequestError(err error, uiCode string) exception.ApplicationError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Localizable: lang.Localizable{
+				MessageID: "errors.request_validation",
+				Message:   "request validation failed",
+			},
+			UICode:  uiCode,
+			Cause:   err,
+			Details: newFieldViolations(validationErrs),
+		}
+	}
+
 	return exception.ApplicationError{
 		StatusCode: http.StatusBadRequest,
 		Localizable: lang.Localizable{
@@ -26,3 +72,50 @@ func NewInvalidRequestError(err error, uiCode string) exception.ApplicationError
 		Cause:  err,
 	}
 }
+
+func newFieldViolations(validationErrs validator.ValidationErrors) []exception.FieldViolation {
+	violations := make([]exception.FieldViolation, 0, len(validationErrs))
+
+	for _, fieldErr := range validationErrs {
+		// the namespace starts with the struct name, e.g. CreateDeviceRequest.name
+		_, field, _ := strings.Cut(fieldErr.Namespace(), ".")
+		messageID := validationMessageID(fieldErr)
+
+		violations = append(violations, exception.FieldViolation{
+			Localizable: lang.Localizable{
+				MessageID: "errors." + messageID,
+				Message:   validationMessages[messageID],
+				MessageVars: map[string]interface{}{
+					"field": field,
+					"rule":  fieldErr.Tag(),
+					"param": fieldErr.Param(),
+				},
+			},
+			Field: field,
+			Rule:  fieldErr.Tag(),
+			Param: fieldErr.Param(),
+		})
+	}
+
+	return violations
+}
+
+func validationMessageID(fieldErr validator.FieldError) string {
+	messageID := "validation_" + fieldErr.Tag()
+
+	switch fieldErr.Tag() {
+	case "min", "max", "len":
+		switch fieldErr.Kind() { //nolint:exhaustive
+		case reflect.String:
+			messageID += "_length"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			messageID += "_items"
+		}
+	}
+
+	if _, ok := validationMessages[messageID]; !ok {
+		return "validation_invalid"
+	}
+
+	return messageID
+}
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag builds a strong entity tag from a record version.
+func ETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// IfMatch reports whether the If-Match header value allows writing the given version.
+// An empty header or "*" matches any existing version. Tags are compared with the strong
+// comparison of RFC 7232, so a weak tag never matches.
+func IfMatch(header string, version int64) bool {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return true
+	}
+
+	current := ETag(version)
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == current {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,35 @@
+//go:build unit
+
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatch(t *testing.T) {
+	testCases := []struct {
+		name    string
+		header  string
+		version int64
+		want    bool
+	}{
+		{name: "no header", header: "", version: 3, want: true},
+		{name: "wildcard", header: "*", version: 3, want: true},
+		{name: "strong tag", header: `"3"`, version: 3, want: true},
+		{name: "weak tag", header: `W/"3"`, version: 3, want: false},
+		{name: "tag list with weak tag", header: `W/"3", "3"`, version: 3, want: true},
+		{name: "tag list", header: `"1", "3"`, version: 3, want: true},
+		{name: "stale tag", header: `"2"`, version: 3, want: false},
+		{name: "unquoted tag", header: "3", version: 3, want: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.want, IfMatch(testCase.header, testCase.version))
+		})
+	}
+
+	assert.Equal(t, `"7"`, DeviceResponse{Version: 7}.ETag())
+}
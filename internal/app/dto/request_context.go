@@ -2,13 +2,36 @@ package dto
 
 import (
 	"context"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/requestid"
 )
 
 type RequestContext struct {
+	// Language is the supported language negotiated from the Accept-Language header.
 	Language string `mapstructure:"language"`
+	// Subject identifies the caller, it is empty for anonymous requests.
+	Subject string `mapstructure:"subject"`
+	// Claims are the verified token claims of the caller, nil for anonymous requests.
+	Claims *auth.Claims `mapstructure:"claims"`
+	// Tenant owns the data the request reads and writes, it is resolved by the tenant middleware.
+	Tenant string `mapstructure:"tenant"`
+	// RequestID identifies the request in the logs, the response headers and the error bodies.
+	RequestID string `mapstructure:"request_id"`
+	// Path is the request URI, it identifies the occurrence of an error in problem details.
+	Path string `mapstructure:"path"`
+	// AcceptProblem is set when the client accepts RFC 7807 problem details error responses.
+	AcceptProblem bool `mapstructure:"accept_problem"`
 }
 
+// ProblemContentType is the media type of RFC 7807 problem details documents.
+const ProblemContentType = "application/problem+json"
+
 type contextKey string
 
 // requestContextKey is the context.Context key to store the request context.
@@ -18,10 +41,19 @@ func RequestWithContext(req *http.Request) (*http.Request, error) {
 	var reqContext RequestContext
 
 	reqContext.Language = getLanguage(req)
+	reqContext.Path = req.URL.RequestURI()
 
-	ctx := context.WithValue(req.Context(), requestContextKey, reqContext)
+	if ids, ok := requestid.FromContext(req.Context()); ok {
+		reqContext.RequestID = ids.RequestID
+	}
+	reqContext.AcceptProblem = acceptsProblem(req)
+
+	return req.WithContext(WithRequestContext(req.Context(), reqContext)), nil
+}
 
-	return req.WithContext(ctx), nil
+// WithRequestContext returns a copy of ctx storing the request context.
+func WithRequestContext(ctx context.Context, reqContext RequestContext) context.Context {
+	return context.WithValue(ctx, requestContextKey, reqContext)
 }
 
 func RequestFromContext(ctx context.Context) (RequestContext, bool) {
@@ -31,5 +63,25 @@ func RequestFromContext(ctx context.Context) (RequestContext, bool) {
 }
 
 func getLanguage(req *http.Request) string {
-	return req.Header.Get("Accept-Language")
+	return lang.Match(req.Header.Get("Accept-Language"))
+}
+
+// acceptsProblem reports whether the Accept header lists the problem details media type
+// with a non zero quality.
+func acceptsProblem(req *http.Request) bool {
+	for _, accept := range req.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(mediaRange)
+			if err != nil || mediaType != ProblemContentType {
+				continue
+			}
+
+			quality, err := strconv.ParseFloat(params["q"], 32)
+			if err != nil || quality > 0 {
+				return true
+			}
+		}
+	}
+
+	return false
 }
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,3 +29,35 @@ func TestRequestContext(t *testing.T) {
 
 	assert.Equal(t, language, reqContext.Language)
 }
+
+func TestRequestContextNegotiatesLanguage(t *testing.T) {
+	lang.SetSupportedLanguages("en,es,id")
+
+	t.Cleanup(func() { lang.SetSupportedLanguages("en") })
+
+	testCases := []struct {
+		acceptLanguage string
+		expected       string
+	}{
+		{acceptLanguage: "es-MX,es;q=0.9,en;q=0.8", expected: "es"},
+		{acceptLanguage: "id-ID", expected: "id"},
+		{acceptLanguage: "de-DE", expected: "en"},
+		{acceptLanguage: "", expected: "en"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.acceptLanguage, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), "GET", "/foo", nil)
+			assert.NoError(t, err)
+
+			req.Header.Set("Accept-Language", testCase.acceptLanguage)
+
+			out, err := RequestWithContext(req)
+			assert.NoError(t, err)
+
+			reqContext, ok := RequestFromContext(out.Context())
+			assert.True(t, ok)
+			assert.Equal(t, testCase.expected, reqContext.Language)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req dto.CreateAPIKeyRequest) (dto.APIKeySecretResponse, error)
+	ListAPIKeys(ctx context.Context, req dto.ListAPIKeysRequest) (dto.ListAPIKeysResponse, error)
+	RotateAPIKey(ctx context.Context, req dto.RotateAPIKeyRequest) (dto.APIKeySecretResponse, error)
+	RevokeAPIKey(ctx context.Context, req dto.RevokeAPIKeyRequest) error
+}
+
+func NewAPIKeyEndpoint(apiKeyService APIKeyService) APIKey {
+	return APIKey{
+		CreateAPIKey: makeCreateAPIKeyEndpoint(apiKeyService),
+		ListAPIKeys:  makeListAPIKeysEndpoint(apiKeyService),
+		RotateAPIKey: makeRotateAPIKeyEndpoint(apiKeyService),
+		RevokeAPIKey: makeRevokeAPIKeyEndpoint(apiKeyService),
+	}
+}
+
+func makeCreateAPIKeyEndpoint(apiKeyService APIKeyService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.CreateAPIKeyRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		apiKey, err := apiKeyService.CreateAPIKey(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("api key service: %w", err)
+		}
+
+		return apiKey, nil
+	}
+}
+
+func makeListAPIKeysEndpoint(apiKeyService APIKeyService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.ListAPIKeysRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		apiKeys, err := apiKeyService.ListAPIKeys(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("api key service: %w", err)
+		}
+
+		return apiKeys, nil
+	}
+}
+
+func makeRotateAPIKeyEndpoint(apiKeyService APIKeyService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.RotateAPIKeyRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		apiKey, err := apiKeyService.RotateAPIKey(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("api key service: %w", err)
+		}
+
+		return apiKey, nil
+	}
+}
+
+func makeRevokeAPIKeyEndpoint(apiKeyService APIKeyService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.RevokeAPIKeyRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		err := apiKeyService.RevokeAPIKey(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("api key service: %w", err)
+		}
+
+		return nil, nil
+	}
+}
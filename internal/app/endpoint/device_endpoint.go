@@ -11,12 +11,28 @@ import (
 type DeviceService interface {
 	CreateDevice(ctx context.Context, req dto.CreateDeviceRequest) error
 	GetDeviceByID(ctx context.Context, req dto.GetDeviceByIDRequest) (dto.DeviceResponse, error)
+	ListDevices(ctx context.Context, req dto.ListDevicesRequest) (dto.ListDevicesResponse, error)
+	ListDevicesByModel(ctx context.Context, req dto.ListDevicesByModelRequest) (dto.ListDevicesResponse, error)
+	UpdateDevice(ctx context.Context, req dto.UpdateDeviceRequest) (dto.DeviceResponse, error)
+	PatchDevice(ctx context.Context, req dto.PatchDeviceRequest) (dto.DeviceResponse, error)
+	DeleteDevice(ctx context.Context, req dto.DeleteDeviceRequest) error
+	RestoreDevice(ctx context.Context, req dto.RestoreDeviceRequest) (dto.DeviceResponse, error)
+	BatchCreateDevices(ctx context.Context, req dto.BatchCreateDevicesRequest) (dto.BatchDevicesResponse, error)
+	BatchGetDevices(ctx context.Context, req dto.BatchGetDevicesRequest) (dto.BatchDevicesResponse, error)
 }
 
 func NewDeviceEndpoint(deviceService DeviceService) Device {
 	return Device{
-		CreateDevice:  makeCreateDeviceEndpoint(deviceService),
-		GetDeviceByID: makeGetDeviceByIDEndpoint(deviceService),
+		CreateDevice:       makeCreateDeviceEndpoint(deviceService),
+		GetDeviceByID:      makeGetDeviceByIDEndpoint(deviceService),
+		ListDevices:        makeListDevicesEndpoint(deviceService),
+		ListDevicesByModel: makeListDevicesByModelEndpoint(deviceService),
+		UpdateDevice:       makeUpdateDeviceEndpoint(deviceService),
+		PatchDevice:        makePatchDeviceEndpoint(deviceService),
+		DeleteDevice:       makeDeleteDeviceEndpoint(deviceService),
+		RestoreDevice:      makeRestoreDeviceEndpoint(deviceService),
+		BatchCreateDevices: makeBatchCreateDevicesEndpoint(deviceService),
+		BatchGetDevices:    makeBatchGetDevicesEndpoint(deviceService),
 	}
 }
 
@@ -51,3 +67,131 @@ func makeGetDeviceByIDEndpoint(deviceService DeviceService) endpoint.Endpoint {
 		return device, nil
 	}
 }
+
+func makeListDevicesEndpoint(deviceService DeviceService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.ListDevicesRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		devices, err := deviceService.ListDevices(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device service: %w", err)
+		}
+
+		return devices, nil
+	}
+}
+
+func makeListDevicesByModelEndpoint(deviceService DeviceService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.ListDevicesByModelRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		devices, err := deviceService.ListDevicesByModel(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device service: %w", err)
+		}
+
+		return devices, nil
+	}
+}
+
+func makeUpdateDeviceEndpoint(deviceService DeviceService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.UpdateDeviceRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		device, err := deviceService.UpdateDevice(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device service: %w", err)
+		}
+
+		return device, nil
+	}
+}
+
+func makePatchDeviceEndpoint(deviceService DeviceService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.PatchDeviceRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		device, err := deviceService.PatchDevice(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device service: %w", err)
+		}
+
+		return device, nil
+	}
+}
+
+func makeDeleteDeviceEndpoint(deviceService DeviceService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.DeleteDeviceRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		err := deviceService.DeleteDevice(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device service: %w", err)
+		}
+
+		return nil, nil
+	}
+}
+
+func makeRestoreDeviceEndpoint(deviceService DeviceService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.RestoreDeviceRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		device, err := deviceService.RestoreDevice(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device service: %w", err)
+		}
+
+		return device, nil
+	}
+}
+
+func makeBatchCreateDevicesEndpoint(deviceService DeviceService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.BatchCreateDevicesRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		results, err := deviceService.BatchCreateDevices(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device service: %w", err)
+		}
+
+		return results, nil
+	}
+}
+
+func makeBatchGetDevicesEndpoint(deviceService DeviceService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.BatchGetDevicesRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		results, err := deviceService.BatchGetDevices(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device service: %w", err)
+		}
+
+		return results, nil
+	}
+}
@@ -0,0 +1,107 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+)
+
+type DeviceModelService interface {
+	CreateDeviceModel(ctx context.Context, req dto.CreateDeviceModelRequest) error
+	GetDeviceModelByID(ctx context.Context, req dto.GetDeviceModelByIDRequest) (dto.DeviceModelResponse, error)
+	ListDeviceModels(ctx context.Context, req dto.ListDeviceModelsRequest) (dto.ListDeviceModelsResponse, error)
+	UpdateDeviceModel(ctx context.Context, req dto.UpdateDeviceModelRequest) (dto.DeviceModelResponse, error)
+	DeleteDeviceModel(ctx context.Context, req dto.DeleteDeviceModelRequest) error
+}
+
+func NewDeviceModelEndpoint(deviceModelService DeviceModelService) DeviceModel {
+	return DeviceModel{
+		CreateDeviceModel:  makeCreateDeviceModelEndpoint(deviceModelService),
+		GetDeviceModelByID: makeGetDeviceModelByIDEndpoint(deviceModelService),
+		ListDeviceModels:   makeListDeviceModelsEndpoint(deviceModelService),
+		UpdateDeviceModel:  makeUpdateDeviceModelEndpoint(deviceModelService),
+		DeleteDeviceModel:  makeDeleteDeviceModelEndpoint(deviceModelService),
+	}
+}
+
+func makeCreateDeviceModelEndpoint(deviceModelService DeviceModelService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.CreateDeviceModelRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		err := deviceModelService.CreateDeviceModel(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device model service: %w", err)
+		}
+
+		return nil, nil
+	}
+}
+
+func makeGetDeviceModelByIDEndpoint(deviceModelService DeviceModelService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.GetDeviceModelByIDRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		deviceModel, err := deviceModelService.GetDeviceModelByID(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device model service: %w", err)
+		}
+
+		return deviceModel, nil
+	}
+}
+
+func makeListDeviceModelsEndpoint(deviceModelService DeviceModelService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.ListDeviceModelsRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		deviceModels, err := deviceModelService.ListDeviceModels(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device model service: %w", err)
+		}
+
+		return deviceModels, nil
+	}
+}
+
+func makeUpdateDeviceModelEndpoint(deviceModelService DeviceModelService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.UpdateDeviceModelRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		deviceModel, err := deviceModelService.UpdateDeviceModel(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device model service: %w", err)
+		}
+
+		return deviceModel, nil
+	}
+}
+
+func makeDeleteDeviceModelEndpoint(deviceModelService DeviceModelService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(*dto.DeleteDeviceModelRequest)
+		if !ok {
+			return nil, fmt.Errorf("invalid request type: %w", ErrInvalidType)
+		}
+
+		err := deviceModelService.DeleteDeviceModel(ctx, *req)
+		if err != nil {
+			return nil, fmt.Errorf("device model service: %w", err)
+		}
+
+		return nil, nil
+	}
+}
@@ -15,10 +15,35 @@ var ErrInvalidType = exception.ApplicationError{
 }
 
 type Device struct {
-	CreateDevice  endpoint.Endpoint
-	GetDeviceByID endpoint.Endpoint
+	CreateDevice       endpoint.Endpoint
+	GetDeviceByID      endpoint.Endpoint
+	ListDevices        endpoint.Endpoint
+	ListDevicesByModel endpoint.Endpoint
+	UpdateDevice       endpoint.Endpoint
+	PatchDevice        endpoint.Endpoint
+	DeleteDevice       endpoint.Endpoint
+	RestoreDevice      endpoint.Endpoint
+	BatchCreateDevices endpoint.Endpoint
+	BatchGetDevices    endpoint.Endpoint
+}
+
+type DeviceModel struct {
+	CreateDeviceModel  endpoint.Endpoint
+	GetDeviceModelByID endpoint.Endpoint
+	ListDeviceModels   endpoint.Endpoint
+	UpdateDeviceModel  endpoint.Endpoint
+	DeleteDeviceModel  endpoint.Endpoint
+}
+
+type APIKey struct {
+	CreateAPIKey endpoint.Endpoint
+	ListAPIKeys  endpoint.Endpoint
+	RotateAPIKey endpoint.Endpoint
+	RevokeAPIKey endpoint.Endpoint
 }
 
 type Endpoint struct {
 	Device
+	DeviceModel
+	APIKey
 }
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// APIKey is an API key of a machine client, only the salted hash of its secret is stored.
+type APIKey struct {
+	PK        string     `dynamodbav:"PK"`
+	ID        string     `dynamodbav:"id"`
+	Name      string     `dynamodbav:"name"`
+	Owner     string     `dynamodbav:"owner"`
+	Tenant    string     `dynamodbav:"tenant"`
+	Scopes    []string   `dynamodbav:"scopes"`
+	Salt      []byte     `dynamodbav:"salt"`
+	Hash      []byte     `dynamodbav:"hash"`
+	CreatedAt time.Time  `dynamodbav:"createdAt"`
+	RotatedAt *time.Time `dynamodbav:"rotatedAt,omitempty"`
+	RevokedAt *time.Time `dynamodbav:"revokedAt,omitempty"`
+
+	// ExpiresAt is the table TTL attribute in unix seconds, keys without expiry are kept.
+	ExpiresAt int64 `dynamodbav:"expiresAt,omitempty"`
+}
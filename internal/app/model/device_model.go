@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Device struct {
 	PK          string `dynamodbav:"PK"`
 	ID          string `dynamodbav:"id"`
@@ -7,4 +9,10 @@ type Device struct {
 	Name        string `dynamodbav:"name"`
 	Note        string `dynamodbav:"note"`
 	Serial      string `dynamodbav:"serial"`
+	Version     int64  `dynamodbav:"version"`
+
+	// soft delete markers, ExpiresAt is the table TTL attribute in unix seconds
+	DeletedAt *time.Time `dynamodbav:"deletedAt,omitempty"`
+	DeletedBy string     `dynamodbav:"deletedBy,omitempty"`
+	ExpiresAt int64      `dynamodbav:"expiresAt,omitempty"`
 }
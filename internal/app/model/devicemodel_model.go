@@ -0,0 +1,15 @@
+package model
+
+type DeviceModel struct {
+	PK           string            `dynamodbav:"PK"`
+	ID           string            `dynamodbav:"id"`
+	Manufacturer string            `dynamodbav:"manufacturer"`
+	DisplayName  string            `dynamodbav:"displayName"`
+	Category     string            `dynamodbav:"category"`
+	Specs        map[string]string `dynamodbav:"specs,omitempty"`
+	Version      int64             `dynamodbav:"version"`
+
+	// DeviceCount is maintained transactionally by device writes, a model can
+	// only be deleted when no device references it.
+	DeviceCount int64 `dynamodbav:"deviceCount"`
+}
@@ -0,0 +1,23 @@
+package model
+
+// IdempotencyRecord tracks a request sent with an idempotency key. The request holding
+// LockID is running until LockedUntil (unix seconds), afterwards the record holds its response.
+type IdempotencyRecord struct {
+	PK          string `dynamodbav:"PK"`
+	Key         string `dynamodbav:"key"`
+	Fingerprint string `dynamodbav:"fingerprint"`
+	LockID      string `dynamodbav:"lockId,omitempty"`
+	LockedUntil int64  `dynamodbav:"lockedUntil,omitempty"`
+
+	StatusCode int                 `dynamodbav:"statusCode,omitempty"`
+	Header     map[string][]string `dynamodbav:"header,omitempty"`
+	Body       []byte              `dynamodbav:"body,omitempty"`
+
+	// ExpiresAt is the table TTL attribute in unix seconds.
+	ExpiresAt int64 `dynamodbav:"expiresAt"`
+}
+
+// Locked reports whether the request of the record is still running.
+func (r IdempotencyRecord) Locked() bool {
+	return r.LockID != ""
+}
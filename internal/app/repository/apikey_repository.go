@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ijalalfrz/go-serverless/internal/app/model"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/pagination"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
+)
+
+// apiKeyPrefix prefixes the keys of API key items. Keys are looked up by ID before the tenant
+// of the caller is known, so they are not part of a tenant keyspace and record their tenant
+// in the tenant attribute instead.
+const apiKeyPrefix = "APIKEY#"
+
+type APIKeyRepository struct {
+	db          DynamoDBAPI
+	tableName   string
+	cursorCodec *pagination.CursorCodec
+}
+
+func NewAPIKeyRepository(
+	db DynamoDBAPI,
+	tableName string,
+	cursorCodec *pagination.CursorCodec,
+) *APIKeyRepository {
+	return &APIKeyRepository{
+		db:          db,
+		tableName:   tableName,
+		cursorCodec: cursorCodec,
+	}
+}
+
+// Create stores an API key of the tenant of ctx.
+func (r *APIKeyRepository) Create(ctx context.Context, apiKey model.APIKey) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	apiKey.PK = apiKeyPrefix + apiKey.ID
+	apiKey.Tenant = tenantID
+
+	data, err := attributevalue.MarshalMap(apiKey)
+	if err != nil {
+		return fmt.Errorf("failed to marshal api key: %w", err)
+	}
+
+	_, err = r.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &r.tableName,
+		Item:                data,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			err := exception.ErrConflict
+			err.MessageVars = map[string]interface{}{"name": "api key"}
+
+			return err
+		}
+
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns an API key of any tenant, revoked keys included. It is the lookup of the
+// key verification and must not be used to serve the keys of a tenant.
+func (r *APIKeyRepository) GetByID(ctx context.Context, id string) (model.APIKey, error) {
+	out, err := r.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      &r.tableName,
+		Key:            keyOf(apiKeyPrefix + id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if out.Item == nil {
+		return model.APIKey{}, apiKeyNotFoundError()
+	}
+
+	apiKey := model.APIKey{}
+
+	err = attributevalue.UnmarshalMap(out.Item, &apiKey)
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("failed to unmarshal api key: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+// List scans the API keys of the tenant of ctx.
+func (r *APIKeyRepository) List(ctx context.Context, limit int32, cursor string) ([]model.APIKey, string, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, "", err //nolint:wrapcheck
+	}
+
+	items, nextCursor, err := scanPage(ctx, r.db, r.cursorCodec, &dynamodb.ScanInput{
+		TableName:                &r.tableName,
+		FilterExpression:         aws.String("begins_with(PK, :prefix) AND #tenant = :tenant"),
+		ExpressionAttributeNames: map[string]string{"#tenant": "tenant"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":prefix": &types.AttributeValueMemberS{Value: apiKeyPrefix},
+			":tenant": &types.AttributeValueMemberS{Value: tenantID},
+		},
+	}, limit, cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	apiKeys := []model.APIKey{}
+
+	err = attributevalue.UnmarshalListOfMaps(items, &apiKeys)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal api keys: %w", err)
+	}
+
+	return apiKeys, nextCursor, nil
+}
+
+// Rotate replaces the secret hash of an API key of the tenant of ctx which is not revoked,
+// the previous secret stops working immediately.
+func (r *APIKeyRepository) Rotate(
+	ctx context.Context,
+	id string,
+	salt []byte,
+	hash []byte,
+	rotatedAt time.Time,
+) (model.APIKey, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return model.APIKey{}, err //nolint:wrapcheck
+	}
+
+	rotated, err := attributevalue.Marshal(rotatedAt)
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("failed to marshal rotation time: %w", err)
+	}
+
+	out, err := r.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        &r.tableName,
+		Key:              keyOf(apiKeyPrefix + id),
+		UpdateExpression: aws.String("SET salt = :salt, #hash = :hash, rotatedAt = :rotatedAt"),
+		ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(revokedAt) " +
+			"AND #tenant = :tenant"),
+		ExpressionAttributeNames: map[string]string{
+			"#hash":   "hash",
+			"#tenant": "tenant",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":salt":      &types.AttributeValueMemberB{Value: salt},
+			":hash":      &types.AttributeValueMemberB{Value: hash},
+			":rotatedAt": rotated,
+			":tenant":    &types.AttributeValueMemberS{Value: tenantID},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return model.APIKey{}, apiKeyNotFoundError()
+		}
+
+		return model.APIKey{}, fmt.Errorf("failed to rotate api key: %w", err)
+	}
+
+	apiKey := model.APIKey{}
+
+	err = attributevalue.UnmarshalMap(out.Attributes, &apiKey)
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("failed to unmarshal api key: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+// Revoke marks an API key of the tenant of ctx as revoked, the item is kept for auditing.
+// Revoking a revoked key reports it as not found.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	revoked, err := attributevalue.Marshal(revokedAt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revocation time: %w", err)
+	}
+
+	_, err = r.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        &r.tableName,
+		Key:              keyOf(apiKeyPrefix + id),
+		UpdateExpression: aws.String("SET revokedAt = :revokedAt"),
+		ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(revokedAt) " +
+			"AND #tenant = :tenant"),
+		ExpressionAttributeNames: map[string]string{
+			"#tenant": "tenant",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":revokedAt": revoked,
+			":tenant":    &types.AttributeValueMemberS{Value: tenantID},
+		},
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return apiKeyNotFoundError()
+		}
+
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return nil
+}
+
+func apiKeyNotFoundError() exception.ApplicationError {
+	err := exception.ErrRecordNotFound
+	err.MessageVars = map[string]interface{}{
+		"name": "api key",
+	}
+	err.UICode = exception.APIKeyNotFound
+
+	return err
+}
@@ -0,0 +1,119 @@
+//go:build unit
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/model"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/pagination"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAPIKeyRepository() (*APIKeyRepository, *FakeDynamoDB) {
+	store := NewFakeDynamoDB()
+
+	return NewAPIKeyRepository(store, "devices", pagination.NewCursorCodec("secret")), store
+}
+
+func testAPIKey(id string) model.APIKey {
+	return model.APIKey{
+		ID:        id,
+		Name:      "ingest " + id,
+		Owner:     "user-1",
+		Scopes:    []string{"devices:write"},
+		Salt:      []byte("salt"),
+		Hash:      []byte("hash"),
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestAPIKeyRepository_Create(t *testing.T) {
+	repo, _ := newTestAPIKeyRepository()
+
+	assert.NoError(t, repo.Create(testContext(), testAPIKey("k1")))
+
+	stored, err := repo.GetByID(testContext(), "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "APIKEY#k1", stored.PK)
+	assert.Equal(t, []string{"devices:write"}, stored.Scopes)
+	assert.Equal(t, []byte("hash"), stored.Hash)
+
+	err = repo.Create(testContext(), testAPIKey("k1"))
+	assert.ErrorIs(t, err, exception.ErrConflict)
+
+	_, err = repo.GetByID(testContext(), "k2")
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+}
+
+func TestAPIKeyRepository_List(t *testing.T) {
+	repo, store := newTestAPIKeyRepository()
+	deviceModels := NewDeviceModelRepository(store, "devices", pagination.NewCursorCodec("secret"))
+
+	assert.NoError(t, repo.Create(testContext(), testAPIKey("k1")))
+	assert.NoError(t, repo.Create(testContext(), testAPIKey("k2")))
+	assert.NoError(t, deviceModels.Create(testContext(), testDeviceModel("m1")))
+
+	apiKeys, nextCursor, err := repo.List(testContext(), 10, "")
+	assert.NoError(t, err)
+	assert.Empty(t, nextCursor)
+	assert.Len(t, apiKeys, 2)
+}
+
+func TestAPIKeyRepository_RotateAndRevoke(t *testing.T) {
+	repo, _ := newTestAPIKeyRepository()
+	assert.NoError(t, repo.Create(testContext(), testAPIKey("k1")))
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	rotated, err := repo.Rotate(testContext(), "k1", []byte("salt2"), []byte("hash2"), now)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hash2"), rotated.Hash)
+	assert.Equal(t, []byte("salt2"), rotated.Salt)
+	assert.Equal(t, now, *rotated.RotatedAt)
+	assert.Equal(t, "ingest k1", rotated.Name)
+
+	assert.NoError(t, repo.Revoke(testContext(), "k1", now))
+
+	stored, err := repo.GetByID(testContext(), "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, now, *stored.RevokedAt)
+
+	// revoked keys can neither be rotated nor revoked again
+	_, err = repo.Rotate(testContext(), "k1", []byte("salt3"), []byte("hash3"), now)
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+	err = repo.Revoke(testContext(), "k1", now)
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+	err = repo.Revoke(testContext(), "k2", now)
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+}
+
+func TestAPIKeyRepository_TenantIsolation(t *testing.T) {
+	repo, _ := newTestAPIKeyRepository()
+	assert.NoError(t, repo.Create(testContext(), testAPIKey("k1")))
+
+	stored, err := repo.GetByID(testContext(), "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, testTenant, stored.Tenant)
+
+	other := tenant.NewContext(context.Background(), "globex")
+
+	apiKeys, _, err := repo.List(other, 10, "")
+	assert.NoError(t, err)
+	assert.Empty(t, apiKeys)
+
+	_, err = repo.Rotate(other, "k1", []byte("salt2"), []byte("hash2"), time.Now())
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+	err = repo.Revoke(other, "k1", time.Now())
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+	err = repo.Create(context.Background(), testAPIKey("k2"))
+	assert.ErrorIs(t, err, tenant.ErrTenantRequired)
+}
@@ -2,41 +2,86 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/ijalalfrz/go-serverless/internal/app/model"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/db"
 	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/pagination"
+)
+
+const (
+	devicePrefix = "DEVICE#"
+	serialPrefix = "SERIAL#"
 )
 
 type DeviceRepository struct {
-	db        *dynamodb.Client
-	tableName string
+	db          DynamoDBAPI
+	tableName   string
+	cursorCodec *pagination.CursorCodec
 }
 
-func NewDeviceRepository(db *dynamodb.Client, tableName string) *DeviceRepository {
+func NewDeviceRepository(
+	db DynamoDBAPI,
+	tableName string,
+	cursorCodec *pagination.CursorCodec,
+) *DeviceRepository {
 	return &DeviceRepository{
-		db:        db,
-		tableName: tableName,
+		db:          db,
+		tableName:   tableName,
+		cursorCodec: cursorCodec,
 	}
 }
 
+// txWrite is an item of a device transaction together with the error returned when its
+// condition fails. Items without error are best effort, they release references to device
+// models or serials which may be missing (e.g. for devices written before those were
+// tracked) and must not block the write, so they are dropped and the transaction is retried.
+type txWrite struct {
+	item   types.TransactWriteItem
+	failed func(reason types.CancellationReason) error
+}
+
 func (r *DeviceRepository) Create(ctx context.Context, device model.Device) error {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return err
+	}
+
 	id := normalizeID(device.ID)
 
-	device.PK = "DEVICE#" + id
+	device.PK = keys.device(id)
 
 	data, err := attributevalue.MarshalMap(device)
 	if err != nil {
 		return fmt.Errorf("failed to marshal device: %w", err)
 	}
 
-	_, err = r.db.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: &r.tableName,
-		Item:      data,
+	// the device put, the model counter increment and the serial guard form a single
+	// transaction, so concurrent creates of the same device cannot overwrite each other,
+	// a device cannot reference a model that does not exist and serials stay unique
+	err = r.transactWrite(ctx, []txWrite{
+		{
+			item: types.TransactWriteItem{
+				Put: &types.Put{
+					TableName:           &r.tableName,
+					Item:                data,
+					ConditionExpression: aws.String("attribute_not_exists(PK)"),
+				},
+			},
+			failed: func(types.CancellationReason) error { return deviceAlreadyExistError() },
+		},
+		r.acquireModel(keys, device.DeviceModel),
+		r.acquireSerial(keys, device.Serial, id),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create device: %w", err)
@@ -45,29 +90,683 @@ func (r *DeviceRepository) Create(ctx context.Context, device model.Device) erro
 	return nil
 }
 
+// BatchCreate creates the devices with BatchWriteItem, the returned errors are aligned with
+// devices and nil for created ones. Batch writes cannot be conditional, so the devices, their
+// serials and device models are checked with a consistent BatchGetItem and the device model
+// counters are incremented before the devices are written. A single create of the same device
+// or serial running between the check and the write can still be overwritten, Create is the
+// safe choice when that matters.
+func (r *DeviceRepository) BatchCreate(ctx context.Context, devices []model.Device) []error {
+	errs := make([]error, len(devices))
+
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+
+		return errs
+	}
+
+	devices = append([]model.Device(nil), devices...)
+	ids := make(map[string]bool, len(devices))
+	serials := make(map[string]bool, len(devices))
+	pks := make([]string, 0, 3*len(devices))
+
+	for i := range devices {
+		id := normalizeID(devices[i].ID)
+		devices[i].PK = keys.device(id)
+
+		switch {
+		case ids[id]:
+			errs[i] = deviceAlreadyExistError()
+		case serials[devices[i].Serial]:
+			errs[i] = deviceSerialAlreadyExistError()
+		}
+
+		ids[id] = true
+		serials[devices[i].Serial] = true
+		pks = append(pks, devices[i].PK, keys.serial(devices[i].Serial), keys.deviceModel(devices[i].DeviceModel))
+	}
+
+	items, readErrs := batchGet(ctx, r.db, r.tableName, pks)
+
+	for i, device := range devices {
+		if errs[i] == nil {
+			errs[i] = checkBatchCreate(keys, device, items, readErrs)
+		}
+	}
+
+	r.acquireModels(ctx, keys, devices, errs)
+
+	requests := make([]types.WriteRequest, 0, 2*len(devices))
+	owners := make([]int, 0, 2*len(devices))
+
+	for i, device := range devices {
+		if errs[i] != nil {
+			continue
+		}
+
+		data, err := attributevalue.MarshalMap(device)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to marshal device: %w", err)
+			r.releaseModels(ctx, keys, map[string]int64{device.DeviceModel: 1})
+
+			continue
+		}
+
+		requests = append(requests,
+			types.WriteRequest{PutRequest: &types.PutRequest{Item: data}},
+			types.WriteRequest{PutRequest: &types.PutRequest{Item: map[string]types.AttributeValue{
+				"PK":       &types.AttributeValueMemberS{Value: keys.serial(device.Serial)},
+				"deviceId": &types.AttributeValueMemberS{Value: normalizeID(device.ID)},
+			}}},
+		)
+		owners = append(owners, i, i)
+	}
+
+	writeErrs := batchWrite(ctx, r.db, r.tableName, requests)
+
+	for j, err := range writeErrs {
+		if err != nil && errs[owners[j]] == nil {
+			errs[owners[j]] = fmt.Errorf("failed to create device: %w", err)
+		}
+	}
+
+	r.rollbackBatchCreate(ctx, keys, devices, errs, requests, owners, writeErrs)
+
+	return errs
+}
+
+// BatchGet reads the devices with BatchGetItem, devices and errors are aligned with ids.
+// Soft-deleted devices are reported as not found.
+func (r *DeviceRepository) BatchGet(ctx context.Context, ids []string) ([]model.Device, []error) {
+	devices := make([]model.Device, len(ids))
+	errs := make([]error, len(ids))
+
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+
+		return devices, errs
+	}
+
+	pks := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		pks = append(pks, keys.device(id))
+	}
+
+	items, readErrs := batchGet(ctx, r.db, r.tableName, pks)
+
+	for i, key := range pks {
+		if err := readErrs[key]; err != nil {
+			errs[i] = fmt.Errorf("failed to get device: %w", err)
+
+			continue
+		}
+
+		item, ok := items[key]
+		if !ok {
+			errs[i] = deviceNotFoundError()
+
+			continue
+		}
+
+		if err := attributevalue.UnmarshalMap(item, &devices[i]); err != nil {
+			errs[i] = fmt.Errorf("failed to unmarshal device: %w", err)
+
+			continue
+		}
+
+		if devices[i].DeletedAt != nil {
+			devices[i] = model.Device{}
+			errs[i] = deviceNotFoundError()
+		}
+	}
+
+	return devices, errs
+}
+
+// checkBatchCreate validates a device of a batch create against the items read before the write.
+func checkBatchCreate(
+	keys keyspace,
+	device model.Device,
+	items map[string]map[string]types.AttributeValue,
+	readErrs map[string]error,
+) error {
+	serialKey := keys.serial(device.Serial)
+	modelKey := keys.deviceModel(device.DeviceModel)
+
+	for _, key := range []string{device.PK, serialKey, modelKey} {
+		if err := readErrs[key]; err != nil {
+			return fmt.Errorf("failed to check device: %w", err)
+		}
+	}
+
+	if _, ok := items[device.PK]; ok {
+		return deviceAlreadyExistError()
+	}
+
+	if guard, ok := items[serialKey]; ok && attributeString(guard, "deviceId") != normalizeID(device.ID) {
+		return deviceSerialAlreadyExistError()
+	}
+
+	if _, ok := items[modelKey]; !ok {
+		return unknownDeviceModelError()
+	}
+
+	return nil
+}
+
+// acquireModels adds the devices without error to the counters of their device models,
+// devices of a device model deleted since the check fail as unknown device model.
+func (r *DeviceRepository) acquireModels(ctx context.Context, keys keyspace, devices []model.Device, errs []error) {
+	counts := map[string]int64{}
+
+	for i, device := range devices {
+		if errs[i] == nil {
+			counts[device.DeviceModel]++
+		}
+	}
+
+	for deviceModel, count := range counts {
+		err := r.updateModelCounter(ctx, keys, deviceModel, count)
+		if err == nil {
+			continue
+		}
+
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			err = unknownDeviceModelError()
+		}
+
+		for i, device := range devices {
+			if errs[i] == nil && device.DeviceModel == deviceModel {
+				errs[i] = err
+			}
+		}
+	}
+}
+
+// releaseModels subtracts the given counts from the device model counters, failures are
+// only logged as the devices are already reported as failed.
+func (r *DeviceRepository) releaseModels(ctx context.Context, keys keyspace, counts map[string]int64) {
+	for deviceModel, count := range counts {
+		if err := r.updateModelCounter(ctx, keys, deviceModel, -count); err != nil {
+			slog.WarnContext(ctx, "failed to release device model",
+				slog.String("device_model", deviceModel), slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (r *DeviceRepository) updateModelCounter(
+	ctx context.Context,
+	keys keyspace,
+	deviceModel string,
+	delta int64,
+) error {
+	update := r.modelCounter(keys, deviceModel, delta).Update
+
+	_, err := r.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 update.TableName,
+		Key:                       update.Key,
+		UpdateExpression:          update.UpdateExpression,
+		ConditionExpression:       update.ConditionExpression,
+		ExpressionAttributeValues: update.ExpressionAttributeValues,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update device model counter: %w", err)
+	}
+
+	return nil
+}
+
+// rollbackBatchCreate removes the items written for devices whose other item could not be
+// written and releases the device model references of all devices failing the write.
+func (r *DeviceRepository) rollbackBatchCreate(
+	ctx context.Context,
+	keys keyspace,
+	devices []model.Device,
+	errs []error,
+	requests []types.WriteRequest,
+	owners []int,
+	writeErrs []error,
+) {
+	counts := map[string]int64{}
+	deletes := []types.WriteRequest{}
+
+	for j, request := range requests {
+		i := owners[j]
+		if errs[i] == nil {
+			continue
+		}
+
+		// both items of a device are adjacent, count the device once
+		if j%2 == 0 {
+			counts[devices[i].DeviceModel]++
+		}
+
+		if writeErrs[j] == nil {
+			deletes = append(deletes, types.WriteRequest{DeleteRequest: &types.DeleteRequest{
+				Key: keyOf(writeRequestKey(request)),
+			}})
+		}
+	}
+
+	for j, err := range batchWrite(ctx, r.db, r.tableName, deletes) {
+		if err != nil {
+			slog.WarnContext(ctx, "failed to roll back device item",
+				slog.String("key", writeRequestKey(deletes[j])), slog.String("error", err.Error()))
+		}
+	}
+
+	r.releaseModels(ctx, keys, counts)
+}
+
 func (r *DeviceRepository) GetByID(ctx context.Context, id string) (model.Device, error) {
-	nid := normalizeID(id)
+	device, err := r.get(ctx, id)
+	if err != nil {
+		return model.Device{}, err
+	}
+
+	if device.DeletedAt != nil {
+		return model.Device{}, deviceNotFoundError()
+	}
+
+	return device, nil
+}
+
+// GetBySerial resolves a device through the guard item owning its serial.
+func (r *DeviceRepository) GetBySerial(ctx context.Context, serial string) (model.Device, error) {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return model.Device{}, err
+	}
 
 	out, err := r.db.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &r.tableName,
-		Key: map[string]types.AttributeValue{
-			"PK": &types.AttributeValueMemberS{Value: "DEVICE#" + nid},
+		Key:       keyOf(keys.serial(serial)),
+	})
+	if err != nil {
+		return model.Device{}, fmt.Errorf("failed to get serial: %w", err)
+	}
+
+	deviceID, ok := out.Item["deviceId"].(*types.AttributeValueMemberS)
+	if !ok {
+		return model.Device{}, deviceNotFoundError()
+	}
+
+	return r.GetByID(ctx, deviceID.Value)
+}
+
+// Update replaces the mutable attributes of a device and increments its version.
+// The write only succeeds when the stored version still equals the version of previous,
+// a version of 0 matches devices written before versioning was introduced.
+// Soft-deleted devices are treated as not found. When the device model changes the
+// device is moved from the counter of the previous model to the one of the new model,
+// when the serial changes the new serial is claimed and the previous one released.
+func (r *DeviceRepository) Update(ctx context.Context, device model.Device, previous model.Device) (model.Device, error) {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return model.Device{}, err
+	}
+
+	nid := normalizeID(device.ID)
+
+	values := map[string]types.AttributeValue{
+		":deviceModel": &types.AttributeValueMemberS{Value: device.DeviceModel},
+		":name":        &types.AttributeValueMemberS{Value: device.Name},
+		":note":        &types.AttributeValueMemberS{Value: device.Note},
+		":serial":      &types.AttributeValueMemberS{Value: device.Serial},
+		":one":         &types.AttributeValueMemberN{Value: "1"},
+	}
+
+	condition := "attribute_exists(PK) AND attribute_not_exists(deletedAt) AND attribute_not_exists(#version)"
+	if previous.Version > 0 {
+		condition = "attribute_exists(PK) AND attribute_not_exists(deletedAt) AND #version = :expectedVersion"
+		values[":expectedVersion"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(previous.Version, 10)}
+	}
+
+	writes := []txWrite{
+		{
+			item: types.TransactWriteItem{
+				Update: &types.Update{
+					TableName: &r.tableName,
+					Key:       keyOf(keys.device(nid)),
+					UpdateExpression: aws.String("SET #deviceModel = :deviceModel, #name = :name, #note = :note, " +
+						"#serial = :serial ADD #version :one"),
+					ConditionExpression: aws.String(condition),
+					ExpressionAttributeNames: map[string]string{
+						"#deviceModel": "deviceModel",
+						"#name":        "name",
+						"#note":        "note",
+						"#serial":      "serial",
+						"#version":     "version",
+					},
+					ExpressionAttributeValues:           values,
+					ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+				},
+			},
+			failed: func(reason types.CancellationReason) error {
+				if reason.Item == nil || reason.Item["deletedAt"] != nil {
+					return deviceNotFoundError()
+				}
+
+				return exception.DeviceVersionStaleError()
+			},
 		},
+	}
+
+	if device.DeviceModel != previous.DeviceModel {
+		writes = append(writes, r.acquireModel(keys, device.DeviceModel), r.releaseModel(keys, previous.DeviceModel))
+	}
+
+	if device.Serial != previous.Serial {
+		writes = append(writes, r.acquireSerial(keys, device.Serial, nid), r.releaseSerial(keys, previous.Serial, nid))
+	}
+
+	if err := r.transactWrite(ctx, writes); err != nil {
+		return model.Device{}, fmt.Errorf("failed to update device: %w", err)
+	}
+
+	device.Version = previous.Version + 1
+
+	return device, nil
+}
+
+// SoftDelete marks a device as deleted, hiding it from reads until it is restored.
+// A non-zero expiresAt lets DynamoDB TTL purge the item once it is reached.
+// A soft-deleted device no longer counts as a reference to its device model and
+// releases its serial.
+func (r *DeviceRepository) SoftDelete(
+	ctx context.Context,
+	id string,
+	deletedBy string,
+	deletedAt time.Time,
+	expiresAt time.Time,
+) error {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	update := "SET deletedAt = :deletedAt, deletedBy = :deletedBy ADD #version :one"
+	values := map[string]types.AttributeValue{
+		":deletedAt":   &types.AttributeValueMemberS{Value: deletedAt.UTC().Format(time.RFC3339Nano)},
+		":deletedBy":   &types.AttributeValueMemberS{Value: deletedBy},
+		":deviceModel": &types.AttributeValueMemberS{Value: current.DeviceModel},
+		":serial":      &types.AttributeValueMemberS{Value: current.Serial},
+		":one":         &types.AttributeValueMemberN{Value: "1"},
+	}
+
+	if !expiresAt.IsZero() {
+		update = "SET deletedAt = :deletedAt, deletedBy = :deletedBy, expiresAt = :expiresAt ADD #version :one"
+		values[":expiresAt"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)}
+	}
+
+	err = r.transactWrite(ctx, []txWrite{
+		{
+			item: types.TransactWriteItem{
+				Update: &types.Update{
+					TableName:        &r.tableName,
+					Key:              keyOf(current.PK),
+					UpdateExpression: aws.String(update),
+					ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(deletedAt) " +
+						"AND #deviceModel = :deviceModel AND #serial = :serial"),
+					ExpressionAttributeNames: map[string]string{
+						"#deviceModel": "deviceModel",
+						"#serial":      "serial",
+						"#version":     "version",
+					},
+					ExpressionAttributeValues:           values,
+					ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+				},
+			},
+			failed: func(reason types.CancellationReason) error {
+				if reason.Item == nil || reason.Item["deletedAt"] != nil {
+					return deviceNotFoundError()
+				}
+
+				return exception.DeviceVersionStaleError()
+			},
+		},
+		r.releaseModel(keys, current.DeviceModel),
+		r.releaseSerial(keys, current.Serial, normalizeID(current.ID)),
 	})
 	if err != nil {
-		return model.Device{}, fmt.Errorf("failed to get device: %w", err)
+		return fmt.Errorf("failed to soft delete device: %w", err)
 	}
 
-	if out.Item == nil {
-		err := exception.ErrRecordNotFound
-		err.MessageVars = map[string]interface{}{
-			"name": "device",
-		}
-		err.UICode = exception.DeviceNotFound
+	return nil
+}
+
+// Restore clears the soft delete markers of a device. Restoring a device that
+// is not deleted is a no-op returning the stored device. The restore fails when
+// the device model of the device has been deleted or its serial has been claimed
+// by another device in the meantime.
+func (r *DeviceRepository) Restore(ctx context.Context, id string) (model.Device, error) {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return model.Device{}, err
+	}
+
+	current, err := r.get(ctx, id)
+	if err != nil {
+		return model.Device{}, err
+	}
+
+	if current.DeletedAt == nil {
+		return current, nil
+	}
+
+	restoredConcurrently := false
+
+	err = r.transactWrite(ctx, []txWrite{
+		{
+			item: types.TransactWriteItem{
+				Update: &types.Update{
+					TableName:        &r.tableName,
+					Key:              keyOf(current.PK),
+					UpdateExpression: aws.String("REMOVE deletedAt, deletedBy, expiresAt ADD #version :one"),
+					ConditionExpression: aws.String("attribute_exists(deletedAt) " +
+						"AND #deviceModel = :deviceModel AND #serial = :serial"),
+					ExpressionAttributeNames: map[string]string{
+						"#deviceModel": "deviceModel",
+						"#serial":      "serial",
+						"#version":     "version",
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":deviceModel": &types.AttributeValueMemberS{Value: current.DeviceModel},
+						":serial":      &types.AttributeValueMemberS{Value: current.Serial},
+						":one":         &types.AttributeValueMemberN{Value: "1"},
+					},
+					ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+				},
+			},
+			failed: func(reason types.CancellationReason) error {
+				if reason.Item != nil && reason.Item["deletedAt"] == nil {
+					restoredConcurrently = true
+				}
+
+				return deviceConcurrentWriteError(reason.Item)
+			},
+		},
+		r.acquireModel(keys, current.DeviceModel),
+		r.acquireSerial(keys, current.Serial, normalizeID(current.ID)),
+	})
+
+	switch {
+	case restoredConcurrently:
+		// restoring is idempotent
+		return r.get(ctx, id)
+	case err != nil:
+		return model.Device{}, fmt.Errorf("failed to restore device: %w", err)
+	}
+
+	current.DeletedAt = nil
+	current.DeletedBy = ""
+	current.ExpiresAt = 0
+	current.Version++
+
+	return current, nil
+}
+
+// Delete physically removes a device, including soft-deleted ones. Only devices
+// that are not soft-deleted still hold a reference to their device model and serial.
+func (r *DeviceRepository) Delete(ctx context.Context, id string) error {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := r.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	condition := "attribute_exists(PK) AND attribute_not_exists(deletedAt) " +
+		"AND #deviceModel = :deviceModel AND #serial = :serial"
+	if current.DeletedAt != nil {
+		condition = "attribute_exists(PK) AND attribute_exists(deletedAt) " +
+			"AND #deviceModel = :deviceModel AND #serial = :serial"
+	}
+
+	writes := []txWrite{
+		{
+			item: types.TransactWriteItem{
+				Delete: &types.Delete{
+					TableName:           &r.tableName,
+					Key:                 keyOf(current.PK),
+					ConditionExpression: aws.String(condition),
+					ExpressionAttributeNames: map[string]string{
+						"#deviceModel": "deviceModel",
+						"#serial":      "serial",
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":deviceModel": &types.AttributeValueMemberS{Value: current.DeviceModel},
+						":serial":      &types.AttributeValueMemberS{Value: current.Serial},
+					},
+					ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+				},
+			},
+			failed: func(reason types.CancellationReason) error {
+				return deviceConcurrentWriteError(reason.Item)
+			},
+		},
+	}
+
+	if current.DeletedAt == nil {
+		writes = append(writes,
+			r.releaseModel(keys, current.DeviceModel),
+			r.releaseSerial(keys, current.Serial, normalizeID(current.ID)),
+		)
+	}
+
+	if err := r.transactWrite(ctx, writes); err != nil {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+
+	return nil
+}
+
+// List scans the device items of the tenant page by page until limit devices are collected
+// or the table ends. The returned cursor is empty when there are no more devices.
+func (r *DeviceRepository) List(ctx context.Context, limit int32, cursor string) ([]model.Device, string, error) {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	items, nextCursor, err := scanPage(ctx, r.db, r.cursorCodec, &dynamodb.ScanInput{
+		TableName:        &r.tableName,
+		FilterExpression: aws.String("begins_with(PK, :prefix) AND attribute_not_exists(deletedAt)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":prefix": &types.AttributeValueMemberS{Value: keys.of(devicePrefix)},
+		},
+	}, limit, cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list devices: %w", err)
+	}
+
+	devices := []model.Device{}
+
+	err = attributevalue.UnmarshalListOfMaps(items, &devices)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal devices: %w", err)
+	}
 
+	return devices, nextCursor, nil
+}
+
+// ListByModel queries the devices of a device model through the device model index,
+// page by page like List. The index is keyed by device model ID, the range key condition
+// on PK restricts the query to the devices of the tenant. The returned cursor is only valid
+// for the same device model.
+func (r *DeviceRepository) ListByModel(
+	ctx context.Context,
+	deviceModel string,
+	limit int32,
+	cursor string,
+) ([]model.Device, string, error) {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	items, nextCursor, err := queryPage(ctx, r.db, r.cursorCodec, &dynamodb.QueryInput{
+		TableName:              &r.tableName,
+		IndexName:              aws.String(db.DeviceModelIndex),
+		KeyConditionExpression: aws.String("#deviceModel = :deviceModel AND begins_with(PK, :prefix)"),
+		FilterExpression:       aws.String("attribute_not_exists(deletedAt)"),
+		ExpressionAttributeNames: map[string]string{
+			"#deviceModel": db.DeviceModelIndexKey,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":deviceModel": &types.AttributeValueMemberS{Value: deviceModel},
+			":prefix":      &types.AttributeValueMemberS{Value: keys.of(devicePrefix)},
+		},
+	}, db.DeviceModelIndexKey, deviceModel, limit, cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list devices by model: %w", err)
+	}
+
+	devices := []model.Device{}
+
+	err = attributevalue.UnmarshalListOfMaps(items, &devices)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal devices: %w", err)
+	}
+
+	return devices, nextCursor, nil
+}
+
+// get reads a device including soft-deleted ones.
+func (r *DeviceRepository) get(ctx context.Context, id string) (model.Device, error) {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
 		return model.Device{}, err
 	}
 
+	out, err := r.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &r.tableName,
+		Key:       keyOf(keys.device(id)),
+	})
+	if err != nil {
+		return model.Device{}, fmt.Errorf("failed to get device: %w", err)
+	}
+
+	if out.Item == nil {
+		return model.Device{}, deviceNotFoundError()
+	}
+
 	device := model.Device{}
 
 	err = attributevalue.UnmarshalMap(out.Item, &device)
@@ -78,6 +777,150 @@ func (r *DeviceRepository) GetByID(ctx context.Context, id string) (model.Device
 	return device, nil
 }
 
+// acquireModel counts the device as a reference of its device model, the model must exist.
+func (r *DeviceRepository) acquireModel(keys keyspace, deviceModel string) txWrite {
+	return txWrite{
+		item:   r.modelCounter(keys, deviceModel, 1),
+		failed: func(types.CancellationReason) error { return unknownDeviceModelError() },
+	}
+}
+
+// releaseModel removes the device from the references of its device model.
+func (r *DeviceRepository) releaseModel(keys keyspace, deviceModel string) txWrite {
+	return txWrite{item: r.modelCounter(keys, deviceModel, -1)}
+}
+
+func (r *DeviceRepository) modelCounter(keys keyspace, deviceModel string, delta int64) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:           &r.tableName,
+			Key:                 keyOf(keys.deviceModel(deviceModel)),
+			UpdateExpression:    aws.String("ADD deviceCount :delta"),
+			ConditionExpression: aws.String("attribute_exists(PK)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":delta": &types.AttributeValueMemberN{Value: strconv.FormatInt(delta, 10)},
+			},
+		},
+	}
+}
+
+// acquireSerial writes the guard item reserving serial for the device, it fails
+// when the serial is owned by another device.
+func (r *DeviceRepository) acquireSerial(keys keyspace, serial string, deviceID string) txWrite {
+	return txWrite{
+		item: types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: &r.tableName,
+				Item: map[string]types.AttributeValue{
+					"PK":       &types.AttributeValueMemberS{Value: keys.serial(serial)},
+					"deviceId": &types.AttributeValueMemberS{Value: deviceID},
+				},
+				ConditionExpression: aws.String("attribute_not_exists(PK) OR deviceId = :deviceId"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":deviceId": &types.AttributeValueMemberS{Value: deviceID},
+				},
+			},
+		},
+		failed: func(types.CancellationReason) error { return deviceSerialAlreadyExistError() },
+	}
+}
+
+// releaseSerial deletes the guard item of serial when it is owned by the device.
+func (r *DeviceRepository) releaseSerial(keys keyspace, serial string, deviceID string) txWrite {
+	return txWrite{
+		item: types.TransactWriteItem{
+			Delete: &types.Delete{
+				TableName:           &r.tableName,
+				Key:                 keyOf(keys.serial(serial)),
+				ConditionExpression: aws.String("deviceId = :deviceId"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":deviceId": &types.AttributeValueMemberS{Value: deviceID},
+				},
+			},
+		},
+	}
+}
+
+// transactWrite writes all items in a single transaction. When it is canceled by a failed
+// condition the error of the first failed item is returned, failed best effort items are
+// dropped and the transaction is retried without them.
+func (r *DeviceRepository) transactWrite(ctx context.Context, writes []txWrite) error {
+	for {
+		items := make([]types.TransactWriteItem, 0, len(writes))
+		for _, write := range writes {
+			items = append(items, write.item)
+		}
+
+		_, err := r.db.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: items,
+		})
+		if err == nil {
+			return nil
+		}
+
+		reasons := cancellationReasons(err)
+		kept := make([]txWrite, 0, len(writes))
+
+		for i, write := range writes {
+			if !conditionFailedAt(reasons, i) {
+				kept = append(kept, write)
+
+				continue
+			}
+
+			if write.failed != nil {
+				return write.failed(reasons[i])
+			}
+		}
+
+		if len(kept) == len(writes) {
+			return err
+		}
+
+		writes = kept
+	}
+}
+
 func normalizeID(id string) string {
 	return strings.TrimPrefix(id, "/devices/")
 }
+
+func deviceAlreadyExistError() exception.ApplicationError {
+	err := exception.ErrConflict
+	err.MessageVars = map[string]interface{}{
+		"name": "device",
+	}
+	err.UICode = exception.DeviceAlreadyExist
+
+	return err
+}
+
+func deviceSerialAlreadyExistError() exception.ApplicationError {
+	err := exception.ErrConflict
+	err.MessageVars = map[string]interface{}{
+		"name": "device serial",
+	}
+	err.UICode = exception.DeviceSerialAlreadyExist
+
+	return err
+}
+
+func deviceNotFoundError() exception.ApplicationError {
+	err := exception.ErrRecordNotFound
+	err.MessageVars = map[string]interface{}{
+		"name": "device",
+	}
+	err.UICode = exception.DeviceNotFound
+
+	return err
+}
+
+// deviceConcurrentWriteError maps a failed condition on a device read just before the write,
+// item holds the device as stored when the condition failed.
+func deviceConcurrentWriteError(item map[string]types.AttributeValue) exception.ApplicationError {
+	if item == nil {
+		return deviceNotFoundError()
+	}
+
+	return exception.DeviceVersionStaleError()
+}
@@ -0,0 +1,641 @@
+//go:build unit
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ijalalfrz/go-serverless/internal/app/model"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/pagination"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTenant = "acme"
+
+var testKeys = keyspace{prefix: tenantPrefix + testTenant + "#"}
+
+// testContext returns the context of the repository calls of the test tenant.
+func testContext() context.Context {
+	return tenant.NewContext(context.Background(), testTenant)
+}
+
+func newTestDeviceRepository() (*DeviceRepository, *FakeDynamoDB) {
+	store := NewFakeDynamoDB()
+	seedDeviceModel(store, "model-x")
+
+	return NewDeviceRepository(store, "devices", pagination.NewCursorCodec("secret")), store
+}
+
+func seedDeviceModel(store *FakeDynamoDB, id string) {
+	store.Put(item{
+		"PK":          &types.AttributeValueMemberS{Value: testKeys.deviceModel(id)},
+		"id":          &types.AttributeValueMemberS{Value: "/devicemodels/" + id},
+		"deviceCount": &types.AttributeValueMemberN{Value: "0"},
+	})
+}
+
+func deviceCount(store *FakeDynamoDB, id string) string {
+	count, ok := store.Get(testKeys.deviceModel(id))["deviceCount"].(*types.AttributeValueMemberN)
+	if !ok {
+		return ""
+	}
+
+	return count.Value
+}
+
+func testDevice(id string) model.Device {
+	return model.Device{
+		ID:          id,
+		DeviceModel: "/devicemodels/model-x",
+		Name:        "Device " + id,
+		Note:        "Note",
+		Serial:      "SN-" + id,
+		Version:     1,
+	}
+}
+
+func TestDeviceRepository_Create(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+
+		err := repo.Create(testContext(), testDevice("/devices/id1"))
+		assert.NoError(t, err)
+
+		device, err := repo.GetByID(testContext(), "id1")
+		assert.NoError(t, err)
+		assert.Equal(t, testKeys.device("id1"), device.PK)
+		assert.Equal(t, "Device /devices/id1", device.Name)
+		assert.Equal(t, "1", deviceCount(store, "model-x"))
+	})
+
+	t.Run("unknown_device_model", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+
+		device := testDevice("id1")
+		device.DeviceModel = "/devicemodels/unknown"
+
+		err := repo.Create(testContext(), device)
+		assert.ErrorIs(t, err, exception.ErrReferenceNotFound)
+
+		var appErr exception.ApplicationError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, exception.DeviceModelUnknown, appErr.UICode)
+		assert.Nil(t, store.Get(testKeys.device("id1")))
+		assert.Nil(t, store.Get(testKeys.deviceModel("unknown")))
+	})
+
+	t.Run("already_exists", func(t *testing.T) {
+		repo, _ := newTestDeviceRepository()
+
+		assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+
+		duplicate := testDevice("id1")
+		duplicate.Name = "Overwrite"
+
+		err := repo.Create(testContext(), duplicate)
+		assert.ErrorIs(t, err, exception.ErrConflict)
+
+		stored, _ := repo.GetByID(testContext(), "id1")
+		assert.Equal(t, "Device id1", stored.Name)
+	})
+
+	t.Run("concurrent_creates_only_one_wins", func(t *testing.T) {
+		const writers = 50
+
+		var (
+			repo, st  = newTestDeviceRepository()
+			wg        sync.WaitGroup
+			errs      = make([]error, writers)
+			startLine = make(chan struct{})
+		)
+
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				device := testDevice("id1")
+				device.Name = fmt.Sprintf("writer-%d", i)
+
+				<-startLine
+				errs[i] = repo.Create(testContext(), device)
+			}(i)
+		}
+
+		close(startLine)
+		wg.Wait()
+
+		winner := -1
+
+		for i, err := range errs {
+			if err == nil {
+				assert.Equal(t, -1, winner, "more than one writer succeeded")
+				winner = i
+
+				continue
+			}
+
+			assert.ErrorIs(t, err, exception.ErrConflict)
+
+			var appErr exception.ApplicationError
+			assert.True(t, errors.As(err, &appErr))
+			assert.Equal(t, exception.DeviceAlreadyExist, appErr.UICode)
+		}
+
+		assert.NotEqual(t, -1, winner, "no writer succeeded")
+
+		stored, err := repo.GetByID(testContext(), "id1")
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("writer-%d", winner), stored.Name)
+		assert.Equal(t, "1", deviceCount(st, "model-x"))
+	})
+}
+
+func TestDeviceRepository_Update(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repo, _ := newTestDeviceRepository()
+		assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+
+		device := testDevice("id1")
+		device.Name = "Renamed"
+
+		updated, err := repo.Update(testContext(), device, testDevice("id1"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Renamed", updated.Name)
+		assert.Equal(t, int64(2), updated.Version)
+
+		stored, err := repo.GetByID(testContext(), "id1")
+		assert.NoError(t, err)
+		assert.Equal(t, updated.Version, stored.Version)
+	})
+
+	t.Run("stale_version", func(t *testing.T) {
+		repo, _ := newTestDeviceRepository()
+		assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+
+		previous := testDevice("id1")
+		previous.Version = 5
+
+		_, err := repo.Update(testContext(), testDevice("id1"), previous)
+		assert.ErrorIs(t, err, exception.ErrPreconditionFailed)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		repo, _ := newTestDeviceRepository()
+
+		_, err := repo.Update(testContext(), testDevice("id1"), testDevice("id1"))
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+	})
+
+	t.Run("device_model_changed", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+		seedDeviceModel(store, "model-y")
+		assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+
+		device := testDevice("id1")
+		device.DeviceModel = "/devicemodels/model-y"
+
+		_, err := repo.Update(testContext(), device, testDevice("id1"))
+		assert.NoError(t, err)
+		assert.Equal(t, "0", deviceCount(store, "model-x"))
+		assert.Equal(t, "1", deviceCount(store, "model-y"))
+	})
+
+	t.Run("unknown_device_model", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+		assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+
+		device := testDevice("id1")
+		device.DeviceModel = "/devicemodels/unknown"
+
+		_, err := repo.Update(testContext(), device, testDevice("id1"))
+		assert.ErrorIs(t, err, exception.ErrReferenceNotFound)
+		assert.Equal(t, "1", deviceCount(store, "model-x"))
+	})
+
+	t.Run("previous_device_model_missing", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+		seedDeviceModel(store, "model-y")
+		store.Put(item{
+			"PK":          &types.AttributeValueMemberS{Value: testKeys.device("id1")},
+			"id":          &types.AttributeValueMemberS{Value: "id1"},
+			"deviceModel": &types.AttributeValueMemberS{Value: "/devicemodels/legacy"},
+			"version":     &types.AttributeValueMemberN{Value: "1"},
+		})
+
+		previous, err := repo.GetByID(testContext(), "id1")
+		assert.NoError(t, err)
+
+		device := previous
+		device.DeviceModel = "/devicemodels/model-y"
+
+		_, err = repo.Update(testContext(), device, previous)
+		assert.NoError(t, err)
+		assert.Equal(t, "1", deviceCount(store, "model-y"))
+		assert.Nil(t, store.Get(testKeys.deviceModel("legacy")))
+	})
+}
+
+func TestDeviceRepository_SoftDeleteAndRestore(t *testing.T) {
+	repo, store := newTestDeviceRepository()
+	now := time.Now()
+
+	assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+	assert.NoError(t, repo.SoftDelete(testContext(), "id1", "user-1", now, now.Add(time.Hour)))
+
+	_, err := repo.GetByID(testContext(), "id1")
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+	assert.NotNil(t, store.Get(testKeys.device("id1"))["expiresAt"])
+	assert.Equal(t, "0", deviceCount(store, "model-x"))
+
+	err = repo.SoftDelete(testContext(), "id1", "user-1", now, time.Time{})
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+	restored, err := repo.Restore(testContext(), "id1")
+	assert.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Zero(t, restored.ExpiresAt)
+	assert.Equal(t, "1", deviceCount(store, "model-x"))
+
+	_, err = repo.GetByID(testContext(), "id1")
+	assert.NoError(t, err)
+
+	_, err = repo.Restore(testContext(), "id2")
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+}
+
+func TestDeviceRepository_RestoreDeletedDeviceModel(t *testing.T) {
+	repo, store := newTestDeviceRepository()
+	modelRepo := NewDeviceModelRepository(store, "devices", pagination.NewCursorCodec("secret"))
+
+	assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+	assert.NoError(t, repo.SoftDelete(testContext(), "id1", "user-1", time.Now(), time.Time{}))
+	assert.NoError(t, modelRepo.Delete(testContext(), "model-x"))
+
+	_, err := repo.Restore(testContext(), "id1")
+	assert.ErrorIs(t, err, exception.ErrReferenceNotFound)
+	assert.NotNil(t, store.Get(testKeys.device("id1"))["deletedAt"])
+}
+
+func TestDeviceRepository_Delete(t *testing.T) {
+	repo, store := newTestDeviceRepository()
+
+	assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+	assert.NoError(t, repo.Delete(testContext(), "id1"))
+	assert.Nil(t, store.Get(testKeys.device("id1")))
+	assert.Equal(t, "0", deviceCount(store, "model-x"))
+
+	err := repo.Delete(testContext(), "id1")
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+	assert.NoError(t, repo.Create(testContext(), testDevice("id2")))
+	assert.NoError(t, repo.SoftDelete(testContext(), "id2", "user-1", time.Now(), time.Time{}))
+	assert.NoError(t, repo.Delete(testContext(), "id2"))
+	assert.Equal(t, "0", deviceCount(store, "model-x"))
+}
+
+func TestDeviceRepository_List(t *testing.T) {
+	repo, _ := newTestDeviceRepository()
+
+	for _, id := range []string{"id1", "id2", "id3", "id4", "id5"} {
+		assert.NoError(t, repo.Create(testContext(), testDevice(id)))
+	}
+
+	assert.NoError(t, repo.SoftDelete(testContext(), "id2", "user-1", time.Now(), time.Time{}))
+
+	var (
+		ids    []string
+		cursor string
+		pages  int
+	)
+
+	for {
+		devices, next, err := repo.List(testContext(), 2, cursor)
+		assert.NoError(t, err)
+
+		for _, device := range devices {
+			ids = append(ids, device.ID)
+		}
+
+		pages++
+		cursor = next
+
+		if cursor == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, []string{"id1", "id3", "id4", "id5"}, ids)
+	// the last page only evaluates the device model item sharing the table
+	assert.Equal(t, 3, pages)
+
+	_, _, err := repo.List(testContext(), 2, "forged.cursor")
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
+
+func TestDeviceRepository_ListByModel(t *testing.T) {
+	repo, store := newTestDeviceRepository()
+	seedDeviceModel(store, "model-y")
+
+	for _, id := range []string{"id1", "id2", "id3", "id4", "id5"} {
+		device := testDevice(id)
+		if id == "id3" {
+			device.DeviceModel = "/devicemodels/model-y"
+		}
+
+		assert.NoError(t, repo.Create(testContext(), device))
+	}
+
+	assert.NoError(t, repo.SoftDelete(testContext(), "id2", "user-1", time.Now(), time.Time{}))
+
+	var (
+		ids    []string
+		cursor string
+	)
+
+	for {
+		devices, next, err := repo.ListByModel(testContext(), "/devicemodels/model-x", 2, cursor)
+		assert.NoError(t, err)
+
+		for _, device := range devices {
+			ids = append(ids, device.ID)
+		}
+
+		cursor = next
+
+		if cursor == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, []string{"id1", "id4", "id5"}, ids)
+
+	devices, next, err := repo.ListByModel(testContext(), "/devicemodels/model-x", 1, "")
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+
+	// a cursor of one device model cannot page through another one
+	_, _, err = repo.ListByModel(testContext(), "/devicemodels/model-y", 1, next)
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	devices, _, err = repo.ListByModel(testContext(), "/devicemodels/unknown", 2, "")
+	assert.NoError(t, err)
+	assert.Empty(t, devices)
+}
+
+func TestDeviceRepository_BatchCreate(t *testing.T) {
+	t.Run("chunks", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+
+		devices := make([]model.Device, 0, 30)
+		for i := range 30 {
+			devices = append(devices, testDevice(fmt.Sprintf("id%02d", i)))
+		}
+
+		// retried with backoff
+		store.throttledWrites = 2
+		store.throttledGets = 1
+
+		for _, err := range repo.BatchCreate(testContext(), devices) {
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, "30", deviceCount(store, "model-x"))
+		assert.NotNil(t, store.Get(testKeys.serial("SN-id29")))
+
+		stored, err := repo.GetByID(testContext(), "id29")
+		assert.NoError(t, err)
+		assert.Equal(t, testKeys.device("id29"), stored.PK)
+	})
+
+	t.Run("per_item_errors", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+		assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+
+		takenSerial := testDevice("id3")
+		takenSerial.Serial = "SN-id1"
+
+		unknownModel := testDevice("id4")
+		unknownModel.DeviceModel = "/devicemodels/unknown"
+
+		errs := repo.BatchCreate(testContext(), []model.Device{
+			testDevice("id1"),
+			testDevice("id2"),
+			testDevice("/devices/id2"),
+			takenSerial,
+			unknownModel,
+		})
+
+		assert.Len(t, errs, 5)
+		assert.ErrorIs(t, errs[0], exception.ErrConflict)
+		assert.NoError(t, errs[1])
+		assert.ErrorIs(t, errs[2], exception.ErrConflict)
+		assert.ErrorIs(t, errs[3], exception.ErrConflict)
+		assert.ErrorIs(t, errs[4], exception.ErrReferenceNotFound)
+
+		var appErr exception.ApplicationError
+		assert.True(t, errors.As(errs[3], &appErr))
+		assert.Equal(t, exception.DeviceSerialAlreadyExist, appErr.UICode)
+
+		assert.Equal(t, "2", deviceCount(store, "model-x"))
+	})
+
+	t.Run("rollback_unprocessed", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+		store.throttledWrites = maxBatchAttempts
+
+		errs := repo.BatchCreate(testContext(), []model.Device{testDevice("id1")})
+		assert.ErrorIs(t, errs[0], errBatchUnprocessed)
+
+		// the device item was written, its serial guard was not
+		assert.Nil(t, store.Get(testKeys.device("id1")))
+		assert.Nil(t, store.Get(testKeys.serial("SN-id1")))
+		assert.Equal(t, "0", deviceCount(store, "model-x"))
+	})
+}
+
+func TestDeviceRepository_BatchGet(t *testing.T) {
+	repo, store := newTestDeviceRepository()
+
+	for _, id := range []string{"id1", "id2"} {
+		assert.NoError(t, repo.Create(testContext(), testDevice(id)))
+	}
+
+	assert.NoError(t, repo.SoftDelete(testContext(), "id2", "user-1", time.Now(), time.Time{}))
+
+	store.throttledGets = 1
+
+	devices, errs := repo.BatchGet(testContext(), []string{"/devices/id1", "id2", "id3", "id1"})
+	assert.Len(t, devices, 4)
+	assert.NoError(t, errs[0])
+	assert.Equal(t, "Device id1", devices[0].Name)
+	assert.ErrorIs(t, errs[1], exception.ErrRecordNotFound)
+	assert.ErrorIs(t, errs[2], exception.ErrRecordNotFound)
+	assert.NoError(t, errs[3])
+	assert.Equal(t, devices[0], devices[3])
+}
+
+func TestDeviceRepository_UniqueSerial(t *testing.T) {
+	t.Run("create_duplicate_serial", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+		assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+
+		duplicate := testDevice("id2")
+		duplicate.Serial = "SN-id1"
+
+		err := repo.Create(testContext(), duplicate)
+		assert.ErrorIs(t, err, exception.ErrConflict)
+
+		var appErr exception.ApplicationError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, exception.DeviceSerialAlreadyExist, appErr.UICode)
+		assert.Nil(t, store.Get(testKeys.device("id2")))
+		assert.Equal(t, "1", deviceCount(store, "model-x"))
+	})
+
+	t.Run("serial_change_releases_previous_serial", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+		assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+		assert.NoError(t, repo.Create(testContext(), testDevice("id2")))
+
+		device := testDevice("id1")
+		device.Serial = "SN-id2"
+
+		_, err := repo.Update(testContext(), device, testDevice("id1"))
+		assert.ErrorIs(t, err, exception.ErrConflict)
+
+		device.Serial = "SN-new"
+
+		_, err = repo.Update(testContext(), device, testDevice("id1"))
+		assert.NoError(t, err)
+		assert.Nil(t, store.Get(testKeys.serial("SN-id1")))
+
+		reuse := testDevice("id3")
+		reuse.Serial = "SN-id1"
+		assert.NoError(t, repo.Create(testContext(), reuse))
+	})
+
+	t.Run("soft_delete_releases_serial", func(t *testing.T) {
+		repo, _ := newTestDeviceRepository()
+		assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+		assert.NoError(t, repo.SoftDelete(testContext(), "id1", "user-1", time.Now(), time.Time{}))
+
+		reuse := testDevice("id2")
+		reuse.Serial = "SN-id1"
+		assert.NoError(t, repo.Create(testContext(), reuse))
+
+		_, err := repo.Restore(testContext(), "id1")
+		assert.ErrorIs(t, err, exception.ErrConflict)
+
+		assert.NoError(t, repo.Delete(testContext(), "id2"))
+
+		_, err = repo.Restore(testContext(), "id1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("hard_delete_releases_serial", func(t *testing.T) {
+		repo, store := newTestDeviceRepository()
+		assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+		assert.NoError(t, repo.Delete(testContext(), "id1"))
+		assert.Nil(t, store.Get(testKeys.serial("SN-id1")))
+	})
+}
+
+func TestDeviceRepository_GetBySerial(t *testing.T) {
+	repo, _ := newTestDeviceRepository()
+	assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+
+	device, err := repo.GetBySerial(testContext(), "SN-id1")
+	assert.NoError(t, err)
+	assert.Equal(t, "id1", device.ID)
+
+	_, err = repo.GetBySerial(testContext(), "SN-unknown")
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+	assert.NoError(t, repo.SoftDelete(testContext(), "id1", "user-1", time.Now(), time.Time{}))
+
+	_, err = repo.GetBySerial(testContext(), "SN-id1")
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+}
+
+func TestDeviceRepository_TenantIsolation(t *testing.T) {
+	repo, store := newTestDeviceRepository()
+	assert.NoError(t, repo.Create(testContext(), testDevice("id1")))
+
+	other := tenant.NewContext(context.Background(), "globex")
+	otherKeys := keyspace{prefix: tenantPrefix + "globex#"}
+	store.Put(item{
+		"PK":          &types.AttributeValueMemberS{Value: otherKeys.deviceModel("model-x")},
+		"id":          &types.AttributeValueMemberS{Value: "/devicemodels/model-x"},
+		"deviceCount": &types.AttributeValueMemberN{Value: "0"},
+	})
+
+	t.Run("cross_tenant_reads_not_found", func(t *testing.T) {
+		_, err := repo.GetByID(other, "id1")
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+		_, err = repo.GetBySerial(other, "SN-id1")
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+		_, errs := repo.BatchGet(other, []string{"id1"})
+		assert.ErrorIs(t, errs[0], exception.ErrRecordNotFound)
+
+		devices, _, err := repo.List(other, 10, "")
+		assert.NoError(t, err)
+		assert.Empty(t, devices)
+
+		devices, _, err = repo.ListByModel(other, "/devicemodels/model-x", 10, "")
+		assert.NoError(t, err)
+		assert.Empty(t, devices)
+	})
+
+	t.Run("cross_tenant_writes_not_found", func(t *testing.T) {
+		_, err := repo.Update(other, testDevice("id1"), testDevice("id1"))
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+		err = repo.SoftDelete(other, "id1", "user-1", time.Now(), time.Time{})
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+		err = repo.Delete(other, "id1")
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+		_, err = repo.GetByID(testContext(), "id1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("same_id_and_serial_in_another_tenant", func(t *testing.T) {
+		assert.NoError(t, repo.Create(other, testDevice("id1")))
+		assert.Equal(t, "1", deviceCount(store, "model-x"))
+
+		devices, _, err := repo.ListByModel(testContext(), "/devicemodels/model-x", 10, "")
+		assert.NoError(t, err)
+		assert.Len(t, devices, 1)
+		assert.Equal(t, testKeys.device("id1"), devices[0].PK)
+	})
+
+	t.Run("missing_tenant", func(t *testing.T) {
+		_, err := repo.GetByID(context.Background(), "id1")
+		assert.ErrorIs(t, err, tenant.ErrTenantRequired)
+
+		err = repo.Create(context.Background(), testDevice("id2"))
+		assert.ErrorIs(t, err, tenant.ErrTenantRequired)
+
+		_, _, err = repo.List(context.Background(), 10, "")
+		assert.ErrorIs(t, err, tenant.ErrTenantRequired)
+
+		for _, err := range repo.BatchCreate(context.Background(), []model.Device{testDevice("id2")}) {
+			assert.ErrorIs(t, err, tenant.ErrTenantRequired)
+		}
+
+		assert.Nil(t, store.Get("DEVICE#id2"))
+	})
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ijalalfrz/go-serverless/internal/app/model"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/pagination"
+)
+
+const deviceModelPrefix = "DEVICEMODEL#"
+
+type DeviceModelRepository struct {
+	db          DynamoDBAPI
+	tableName   string
+	cursorCodec *pagination.CursorCodec
+}
+
+func NewDeviceModelRepository(
+	db DynamoDBAPI,
+	tableName string,
+	cursorCodec *pagination.CursorCodec,
+) *DeviceModelRepository {
+	return &DeviceModelRepository{
+		db:          db,
+		tableName:   tableName,
+		cursorCodec: cursorCodec,
+	}
+}
+
+func (r *DeviceModelRepository) Create(ctx context.Context, deviceModel model.DeviceModel) error {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceModel.PK = keys.deviceModel(deviceModel.ID)
+	deviceModel.DeviceCount = 0
+
+	data, err := attributevalue.MarshalMap(deviceModel)
+	if err != nil {
+		return fmt.Errorf("failed to marshal device model: %w", err)
+	}
+
+	_, err = r.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &r.tableName,
+		Item:                data,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return deviceModelAlreadyExistError()
+		}
+
+		return fmt.Errorf("failed to create device model: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DeviceModelRepository) GetByID(ctx context.Context, id string) (model.DeviceModel, error) {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return model.DeviceModel{}, err
+	}
+
+	out, err := r.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &r.tableName,
+		Key:       keyOf(keys.deviceModel(id)),
+	})
+	if err != nil {
+		return model.DeviceModel{}, fmt.Errorf("failed to get device model: %w", err)
+	}
+
+	if out.Item == nil {
+		return model.DeviceModel{}, deviceModelNotFoundError()
+	}
+
+	deviceModel := model.DeviceModel{}
+
+	err = attributevalue.UnmarshalMap(out.Item, &deviceModel)
+	if err != nil {
+		return model.DeviceModel{}, fmt.Errorf("failed to unmarshal device model: %w", err)
+	}
+
+	return deviceModel, nil
+}
+
+// Update replaces the descriptive attributes of a device model when its stored version
+// still equals expectedVersion. The device counter is left untouched.
+func (r *DeviceModelRepository) Update(
+	ctx context.Context,
+	deviceModel model.DeviceModel,
+	expectedVersion int64,
+) (model.DeviceModel, error) {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return model.DeviceModel{}, err
+	}
+
+	specs, err := attributevalue.Marshal(deviceModel.Specs)
+	if err != nil {
+		return model.DeviceModel{}, fmt.Errorf("failed to marshal device model specs: %w", err)
+	}
+
+	out, err := r.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &r.tableName,
+		Key:       keyOf(keys.deviceModel(deviceModel.ID)),
+		UpdateExpression: aws.String("SET manufacturer = :manufacturer, displayName = :displayName, " +
+			"category = :category, specs = :specs ADD #version :one"),
+		ConditionExpression:      aws.String("attribute_exists(PK) AND #version = :expectedVersion"),
+		ExpressionAttributeNames: map[string]string{"#version": "version"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":manufacturer":    &types.AttributeValueMemberS{Value: deviceModel.Manufacturer},
+			":displayName":     &types.AttributeValueMemberS{Value: deviceModel.DisplayName},
+			":category":        &types.AttributeValueMemberS{Value: deviceModel.Category},
+			":specs":           specs,
+			":one":             &types.AttributeValueMemberN{Value: "1"},
+			":expectedVersion": &types.AttributeValueMemberN{Value: strconv.FormatInt(expectedVersion, 10)},
+		},
+		ReturnValues:                        types.ReturnValueAllNew,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			if condErr.Item == nil {
+				return model.DeviceModel{}, deviceModelNotFoundError()
+			}
+
+			return model.DeviceModel{}, exception.DeviceModelVersionStaleError()
+		}
+
+		return model.DeviceModel{}, fmt.Errorf("failed to update device model: %w", err)
+	}
+
+	updated := model.DeviceModel{}
+
+	err = attributevalue.UnmarshalMap(out.Attributes, &updated)
+	if err != nil {
+		return model.DeviceModel{}, fmt.Errorf("failed to unmarshal device model: %w", err)
+	}
+
+	return updated, nil
+}
+
+// Delete removes a device model, it is refused while devices still reference it.
+func (r *DeviceModelRepository) Delete(ctx context.Context, id string) error {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           &r.tableName,
+		Key:                 keyOf(keys.deviceModel(id)),
+		ConditionExpression: aws.String("attribute_exists(PK) AND (attribute_not_exists(deviceCount) OR deviceCount <= :zero)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":zero": &types.AttributeValueMemberN{Value: "0"},
+		},
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			if condErr.Item == nil {
+				return deviceModelNotFoundError()
+			}
+
+			return deviceModelInUseError()
+		}
+
+		return fmt.Errorf("failed to delete device model: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DeviceModelRepository) List(ctx context.Context, limit int32, cursor string) ([]model.DeviceModel, string, error) {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	items, nextCursor, err := scanPage(ctx, r.db, r.cursorCodec, &dynamodb.ScanInput{
+		TableName:        &r.tableName,
+		FilterExpression: aws.String("begins_with(PK, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":prefix": &types.AttributeValueMemberS{Value: keys.of(deviceModelPrefix)},
+		},
+	}, limit, cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list device models: %w", err)
+	}
+
+	deviceModels := []model.DeviceModel{}
+
+	err = attributevalue.UnmarshalListOfMaps(items, &deviceModels)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal device models: %w", err)
+	}
+
+	return deviceModels, nextCursor, nil
+}
+
+func deviceModelAlreadyExistError() exception.ApplicationError {
+	err := exception.ErrConflict
+	err.MessageVars = map[string]interface{}{
+		"name": "device model",
+	}
+	err.UICode = exception.DeviceModelAlreadyExist
+
+	return err
+}
+
+func deviceModelNotFoundError() exception.ApplicationError {
+	err := exception.ErrRecordNotFound
+	err.MessageVars = map[string]interface{}{
+		"name": "device model",
+	}
+	err.UICode = exception.DeviceModelNotFound
+
+	return err
+}
+
+func deviceModelInUseError() exception.ApplicationError {
+	err := exception.ErrRecordInUse
+	err.MessageVars = map[string]interface{}{
+		"name": "device model",
+	}
+	err.UICode = exception.DeviceModelInUse
+
+	return err
+}
+
+func unknownDeviceModelError() exception.ApplicationError {
+	err := exception.ErrReferenceNotFound
+	err.MessageVars = map[string]interface{}{
+		"name": "device model",
+	}
+	err.UICode = exception.DeviceModelUnknown
+
+	return err
+}
@@ -0,0 +1,135 @@
+//go:build unit
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/model"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/pagination"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDeviceModelRepository() (*DeviceModelRepository, *FakeDynamoDB) {
+	store := NewFakeDynamoDB()
+
+	return NewDeviceModelRepository(store, "devices", pagination.NewCursorCodec("secret")), store
+}
+
+func testDeviceModel(id string) model.DeviceModel {
+	return model.DeviceModel{
+		ID:           id,
+		Manufacturer: "Acme",
+		DisplayName:  "Model " + id,
+		Category:     "sensor",
+		Specs:        map[string]string{"battery": "AA"},
+		Version:      1,
+	}
+}
+
+func TestDeviceModelRepository_Create(t *testing.T) {
+	repo, _ := newTestDeviceModelRepository()
+
+	assert.NoError(t, repo.Create(testContext(), testDeviceModel("/devicemodels/m1")))
+
+	stored, err := repo.GetByID(testContext(), "m1")
+	assert.NoError(t, err)
+	assert.Equal(t, testKeys.deviceModel("m1"), stored.PK)
+	assert.Equal(t, map[string]string{"battery": "AA"}, stored.Specs)
+
+	err = repo.Create(testContext(), testDeviceModel("/devicemodels/m1"))
+	assert.ErrorIs(t, err, exception.ErrConflict)
+
+	_, err = repo.GetByID(testContext(), "m2")
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+}
+
+func TestDeviceModelRepository_Update(t *testing.T) {
+	repo, _ := newTestDeviceModelRepository()
+	assert.NoError(t, repo.Create(testContext(), testDeviceModel("m1")))
+
+	deviceModel := testDeviceModel("m1")
+	deviceModel.DisplayName = "Renamed"
+
+	updated, err := repo.Update(testContext(), deviceModel, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.DisplayName)
+	assert.Equal(t, int64(2), updated.Version)
+
+	_, err = repo.Update(testContext(), deviceModel, 1)
+	assert.ErrorIs(t, err, exception.ErrPreconditionFailed)
+
+	_, err = repo.Update(testContext(), testDeviceModel("m2"), 1)
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+}
+
+func TestDeviceModelRepository_Delete(t *testing.T) {
+	repo, store := newTestDeviceModelRepository()
+	devices := NewDeviceRepository(store, "devices", pagination.NewCursorCodec("secret"))
+
+	assert.NoError(t, repo.Create(testContext(), testDeviceModel("model-x")))
+	assert.NoError(t, devices.Create(testContext(), testDevice("id1")))
+
+	err := repo.Delete(testContext(), "model-x")
+	assert.ErrorIs(t, err, exception.ErrRecordInUse)
+
+	var appErr exception.ApplicationError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, exception.DeviceModelInUse, appErr.UICode)
+
+	assert.NoError(t, devices.Delete(testContext(), "id1"))
+	assert.NoError(t, repo.Delete(testContext(), "model-x"))
+
+	err = repo.Delete(testContext(), "model-x")
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+}
+
+func TestDeviceModelRepository_List(t *testing.T) {
+	repo, store := newTestDeviceModelRepository()
+	devices := NewDeviceRepository(store, "devices", pagination.NewCursorCodec("secret"))
+
+	for _, id := range []string{"model-x", "m2", "m3"} {
+		assert.NoError(t, repo.Create(testContext(), testDeviceModel(id)))
+	}
+
+	assert.NoError(t, devices.Create(testContext(), testDevice("id1")))
+
+	deviceModels, next, err := repo.List(testContext(), 10, "")
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Len(t, deviceModels, 3)
+}
+
+func TestDeviceModelRepository_TenantIsolation(t *testing.T) {
+	repo, store := newTestDeviceModelRepository()
+	devices := NewDeviceRepository(store, "devices", pagination.NewCursorCodec("secret"))
+	assert.NoError(t, repo.Create(testContext(), testDeviceModel("m1")))
+
+	other := tenant.NewContext(context.Background(), "globex")
+
+	_, err := repo.GetByID(other, "m1")
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+	_, err = repo.Update(other, testDeviceModel("m1"), 1)
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+	err = repo.Delete(other, "m1")
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+
+	deviceModels, _, err := repo.List(other, 10, "")
+	assert.NoError(t, err)
+	assert.Empty(t, deviceModels)
+
+	// devices can only reference the device models of their tenant
+	device := testDevice("id1")
+	device.DeviceModel = "/devicemodels/m1"
+	err = devices.Create(other, device)
+	assert.ErrorIs(t, err, exception.ErrReferenceNotFound)
+
+	_, err = repo.GetByID(context.Background(), "m1")
+	assert.ErrorIs(t, err, tenant.ErrTenantRequired)
+}
@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/pagination"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
+)
+
+const (
+	conditionalCheckFailed = "ConditionalCheckFailed"
+	tenantPrefix           = "TENANT#"
+)
+
+// Limits of the batch APIs, unprocessed items are retried maxBatchAttempts times in total
+// with an exponential backoff starting at batchRetryDelay.
+const (
+	maxBatchWriteItems = 25
+	maxBatchGetKeys    = 100
+	maxBatchAttempts   = 5
+	batchRetryDelay    = 50 * time.Millisecond
+)
+
+var errBatchUnprocessed = errors.New("item not processed by DynamoDB, retry later")
+
+// DynamoDBAPI is the subset of the DynamoDB client used by the repositories.
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+}
+
+func keyOf(pk string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: pk},
+	}
+}
+
+// keyspace builds the keys of the items of a tenant, they are all prefixed with
+// TENANT#<tenant>#. Device and device model keys are only built through a keyspace, which
+// can only be obtained from a context carrying a tenant, so no repository call can reach
+// the items of another tenant or run without a tenant.
+type keyspace struct {
+	prefix string
+}
+
+// tenantKeys returns the keyspace of the tenant of ctx.
+func tenantKeys(ctx context.Context) (keyspace, error) {
+	id, err := tenant.FromContext(ctx)
+	if err != nil {
+		return keyspace{}, err
+	}
+
+	return keyspace{prefix: tenantPrefix + id + "#"}, nil
+}
+
+// of returns the prefix shared by the keys of the tenant items of a kind.
+func (k keyspace) of(kind string) string {
+	return k.prefix + kind
+}
+
+func (k keyspace) device(id string) string {
+	return k.of(devicePrefix) + normalizeID(id)
+}
+
+func (k keyspace) serial(serial string) string {
+	return k.of(serialPrefix) + serial
+}
+
+func (k keyspace) deviceModel(id string) string {
+	return k.of(deviceModelPrefix) + strings.TrimPrefix(id, "/devicemodels/")
+}
+
+func (k keyspace) idempotency(key string) string {
+	return k.of(idempotencyPrefix) + key
+}
+
+// cancellationReasons returns the per item reasons of a canceled transaction, nil for any other error.
+func cancellationReasons(err error) []types.CancellationReason {
+	var txErr *types.TransactionCanceledException
+	if errors.As(err, &txErr) {
+		return txErr.CancellationReasons
+	}
+
+	return nil
+}
+
+// conditionFailedAt reports whether the transaction item at index failed its condition.
+func conditionFailedAt(reasons []types.CancellationReason, index int) bool {
+	return index < len(reasons) && aws.ToString(reasons[index].Code) == conditionalCheckFailed
+}
+
+// scanPage runs the scan page by page until limit items are collected or the table ends,
+// the page boundaries are carried by a signed cursor.
+func scanPage(
+	ctx context.Context,
+	db DynamoDBAPI,
+	codec *pagination.CursorCodec,
+	input *dynamodb.ScanInput,
+	limit int32,
+	cursor string,
+) ([]map[string]types.AttributeValue, string, error) {
+	startKey, err := codec.Decode(cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode cursor: %w", err)
+	}
+
+	return collectPages(codec, startKey, limit, func(startKey map[string]types.AttributeValue, limit int32) (
+		[]map[string]types.AttributeValue, map[string]types.AttributeValue, error,
+	) {
+		input.ExclusiveStartKey = startKey
+		input.Limit = aws.Int32(limit)
+
+		out, err := db.Scan(ctx, input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan: %w", err)
+		}
+
+		return out.Items, out.LastEvaluatedKey, nil
+	})
+}
+
+// queryPage runs the query page by page like scanPage. partitionKey and partition are the
+// attribute and value of the queried partition, cursors issued for another partition are
+// rejected as invalid.
+func queryPage(
+	ctx context.Context,
+	db DynamoDBAPI,
+	codec *pagination.CursorCodec,
+	input *dynamodb.QueryInput,
+	partitionKey string,
+	partition string,
+	limit int32,
+	cursor string,
+) ([]map[string]types.AttributeValue, string, error) {
+	startKey, err := codec.Decode(cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode cursor: %w", err)
+	}
+
+	if startKey != nil {
+		value, ok := startKey[partitionKey].(*types.AttributeValueMemberS)
+		if !ok || value.Value != partition {
+			return nil, "", fmt.Errorf("cursor of another partition: %w", pagination.ErrInvalidCursor)
+		}
+	}
+
+	return collectPages(codec, startKey, limit, func(startKey map[string]types.AttributeValue, limit int32) (
+		[]map[string]types.AttributeValue, map[string]types.AttributeValue, error,
+	) {
+		input.ExclusiveStartKey = startKey
+		input.Limit = aws.Int32(limit)
+
+		out, err := db.Query(ctx, input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to query: %w", err)
+		}
+
+		return out.Items, out.LastEvaluatedKey, nil
+	})
+}
+
+// collectPages fetches pages until limit items are collected or there are no more pages.
+func collectPages(
+	codec *pagination.CursorCodec,
+	startKey map[string]types.AttributeValue,
+	limit int32,
+	fetch func(startKey map[string]types.AttributeValue, limit int32) (
+		[]map[string]types.AttributeValue, map[string]types.AttributeValue, error),
+) ([]map[string]types.AttributeValue, string, error) {
+	items := make([]map[string]types.AttributeValue, 0, limit)
+
+	for {
+		page, lastKey, err := fetch(startKey, limit-int32(len(items)))
+		if err != nil {
+			return nil, "", err
+		}
+
+		items = append(items, page...)
+		startKey = lastKey
+
+		if startKey == nil || int32(len(items)) >= limit {
+			break
+		}
+	}
+
+	nextCursor, err := codec.Encode(startKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return items, nextCursor, nil
+}
+
+// batchWrite writes the requests in chunks of maxBatchWriteItems and retries unprocessed items
+// with backoff. The returned errors are aligned with requests, nil for written items.
+// The requests must not contain the same key twice.
+func batchWrite(ctx context.Context, db DynamoDBAPI, tableName string, requests []types.WriteRequest) []error {
+	errs := make([]error, len(requests))
+	index := make(map[string]int, len(requests))
+
+	for i, request := range requests {
+		index[writeRequestKey(request)] = i
+	}
+
+	fail := func(pending []types.WriteRequest, err error) {
+		for _, request := range pending {
+			errs[index[writeRequestKey(request)]] = err
+		}
+	}
+
+	for start := 0; start < len(requests); start += maxBatchWriteItems {
+		pending := requests[start:min(start+maxBatchWriteItems, len(requests))]
+
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt == maxBatchAttempts {
+				fail(pending, errBatchUnprocessed)
+
+				break
+			}
+
+			if err := backoff(ctx, attempt); err != nil {
+				fail(pending, err)
+
+				break
+			}
+
+			out, err := db.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]types.WriteRequest{tableName: pending},
+			})
+			if err != nil {
+				fail(pending, fmt.Errorf("failed to batch write: %w", err))
+
+				break
+			}
+
+			pending = out.UnprocessedItems[tableName]
+		}
+	}
+
+	return errs
+}
+
+// batchGet reads the items of the given partition keys in chunks of maxBatchGetKeys and retries
+// unprocessed keys with backoff. Found items are returned by key, keys which could not be read
+// are returned with their error, keys in neither map do not exist.
+func batchGet(
+	ctx context.Context,
+	db DynamoDBAPI,
+	tableName string,
+	keys []string,
+) (map[string]map[string]types.AttributeValue, map[string]error) {
+	items := make(map[string]map[string]types.AttributeValue, len(keys))
+	errs := map[string]error{}
+
+	unique := make([]map[string]types.AttributeValue, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+
+			unique = append(unique, keyOf(key))
+		}
+	}
+
+	fail := func(pending []map[string]types.AttributeValue, err error) {
+		for _, key := range pending {
+			errs[attributeString(key, "PK")] = err
+		}
+	}
+
+	for start := 0; start < len(unique); start += maxBatchGetKeys {
+		pending := unique[start:min(start+maxBatchGetKeys, len(unique))]
+
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt == maxBatchAttempts {
+				fail(pending, errBatchUnprocessed)
+
+				break
+			}
+
+			if err := backoff(ctx, attempt); err != nil {
+				fail(pending, err)
+
+				break
+			}
+
+			out, err := db.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: map[string]types.KeysAndAttributes{
+					tableName: {Keys: pending, ConsistentRead: aws.Bool(true)},
+				},
+			})
+			if err != nil {
+				fail(pending, fmt.Errorf("failed to batch get: %w", err))
+
+				break
+			}
+
+			for _, item := range out.Responses[tableName] {
+				items[attributeString(item, "PK")] = item
+			}
+
+			pending = out.UnprocessedKeys[tableName].Keys
+		}
+	}
+
+	return items, errs
+}
+
+// backoff waits before the given retry attempt, the first attempt does not wait.
+func backoff(ctx context.Context, attempt int) error {
+	if attempt == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(batchRetryDelay << (attempt - 1))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("batch retry: %w", ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+func writeRequestKey(request types.WriteRequest) string {
+	if request.PutRequest != nil {
+		return attributeString(request.PutRequest.Item, "PK")
+	}
+
+	if request.DeleteRequest != nil {
+		return attributeString(request.DeleteRequest.Key, "PK")
+	}
+
+	return ""
+}
+
+func attributeString(item map[string]types.AttributeValue, name string) string {
+	if value, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return value.Value
+	}
+
+	return ""
+}
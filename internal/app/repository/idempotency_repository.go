@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ijalalfrz/go-serverless/internal/app/model"
+)
+
+const (
+	idempotencyPrefix = "IDEMPOTENCY#"
+	lockIDSize        = 16
+)
+
+// DefaultIdempotencyLockTimeout is the lock timeout used when NewIdempotencyRepository is given
+// none, a lock which expires at once would let a retry run the request a second time.
+const DefaultIdempotencyLockTimeout = 30 * time.Second
+
+var errIdempotencyLockLost = errors.New("idempotency lock taken over by another request")
+
+// IdempotencyRepository stores the requests sent with an idempotency key in the keyspace of
+// their tenant. Records expire after ttl through the table TTL, a lock is held for at most
+// lockTimeout so that the key of a request which died while running can be reused, a zero
+// lockTimeout uses DefaultIdempotencyLockTimeout.
+type IdempotencyRepository struct {
+	db          DynamoDBAPI
+	tableName   string
+	ttl         time.Duration
+	lockTimeout time.Duration
+	now         func() time.Time
+}
+
+func NewIdempotencyRepository(
+	db DynamoDBAPI,
+	tableName string,
+	ttl time.Duration,
+	lockTimeout time.Duration,
+) *IdempotencyRepository {
+	if lockTimeout <= 0 {
+		lockTimeout = DefaultIdempotencyLockTimeout
+	}
+
+	return &IdempotencyRepository{
+		db:          db,
+		tableName:   tableName,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+		now:         time.Now,
+	}
+}
+
+// Lock locks key for the request with fingerprint and returns its record. When the key is
+// locked by a running request, or holds a response, the stored record is returned with false.
+// Expired records and stale locks are replaced.
+func (r *IdempotencyRepository) Lock(
+	ctx context.Context,
+	key string,
+	fingerprint string,
+) (model.IdempotencyRecord, bool, error) {
+	keys, err := tenantKeys(ctx)
+	if err != nil {
+		return model.IdempotencyRecord{}, false, err
+	}
+
+	lockID := make([]byte, lockIDSize)
+	if _, err := rand.Read(lockID); err != nil {
+		return model.IdempotencyRecord{}, false, fmt.Errorf("failed to generate lock id: %w", err)
+	}
+
+	now := r.now()
+	record := model.IdempotencyRecord{
+		PK:          keys.idempotency(key),
+		Key:         key,
+		Fingerprint: fingerprint,
+		LockID:      hex.EncodeToString(lockID),
+		LockedUntil: now.Add(r.lockTimeout).Unix(),
+		ExpiresAt:   now.Add(r.ttl).Unix(),
+	}
+
+	data, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return model.IdempotencyRecord{}, false, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	_, err = r.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &r.tableName,
+		Item:      data,
+		// the table TTL deletes expired items lazily
+		ConditionExpression: aws.String("attribute_not_exists(PK) OR expiresAt <= :now " +
+			"OR (attribute_exists(lockId) AND lockedUntil <= :now)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if !errors.As(err, &condErr) {
+			return model.IdempotencyRecord{}, false, fmt.Errorf("failed to lock idempotency key: %w", err)
+		}
+
+		stored := model.IdempotencyRecord{}
+
+		if err := attributevalue.UnmarshalMap(condErr.Item, &stored); err != nil {
+			return model.IdempotencyRecord{}, false, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+		}
+
+		return stored, false, nil
+	}
+
+	return record, true, nil
+}
+
+// Complete stores the response of a locked record and releases the lock, it fails when the
+// lock has been taken over by another request.
+func (r *IdempotencyRepository) Complete(ctx context.Context, record model.IdempotencyRecord) error {
+	lockID := record.LockID
+	record.LockID = ""
+	record.LockedUntil = 0
+
+	data, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	_, err = r.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &r.tableName,
+		Item:                data,
+		ConditionExpression: aws.String("lockId = :lockId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":lockId": &types.AttributeValueMemberS{Value: lockID},
+		},
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return errIdempotencyLockLost
+		}
+
+		return fmt.Errorf("failed to complete idempotency record: %w", err)
+	}
+
+	return nil
+}
+
+// Unlock deletes a locked record so that the key can be retried, records locked by another
+// request are left untouched.
+func (r *IdempotencyRepository) Unlock(ctx context.Context, record model.IdempotencyRecord) error {
+	_, err := r.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           &r.tableName,
+		Key:                 keyOf(record.PK),
+		ConditionExpression: aws.String("lockId = :lockId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":lockId": &types.AttributeValueMemberS{Value: record.LockID},
+		},
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to unlock idempotency key: %w", err)
+	}
+
+	return nil
+}
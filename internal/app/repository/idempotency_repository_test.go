@@ -0,0 +1,130 @@
+//go:build unit
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIdempotencyRepository(now *time.Time) (*IdempotencyRepository, *FakeDynamoDB) {
+	store := NewFakeDynamoDB()
+	repo := NewIdempotencyRepository(store, "devices", time.Hour, time.Minute)
+	repo.now = func() time.Time { return *now }
+
+	return repo, store
+}
+
+func TestIdempotencyRepository_Lock(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo, store := newTestIdempotencyRepository(&now)
+
+	record, locked, err := repo.Lock(testContext(), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	assert.Equal(t, testKeys.idempotency("tx-1"), record.PK)
+	assert.NotEmpty(t, record.LockID)
+	assert.Equal(t, now.Add(time.Minute).Unix(), record.LockedUntil)
+	assert.Equal(t, now.Add(time.Hour).Unix(), record.ExpiresAt)
+	assert.NotNil(t, store.Get(testKeys.idempotency("tx-1")))
+
+	stored, locked, err := repo.Lock(testContext(), "tx-1", "other")
+	assert.NoError(t, err)
+	assert.False(t, locked)
+	assert.True(t, stored.Locked())
+	assert.Equal(t, "fingerprint", stored.Fingerprint)
+
+	// the lock of a request which died is taken over
+	now = now.Add(2 * time.Minute)
+
+	retried, locked, err := repo.Lock(testContext(), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	assert.NotEqual(t, record.LockID, retried.LockID)
+
+	// the stale request can neither store its response nor unlock the key
+	assert.ErrorIs(t, repo.Complete(testContext(), record), errIdempotencyLockLost)
+	assert.NoError(t, repo.Unlock(testContext(), record))
+
+	_, locked, err = repo.Lock(testContext(), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+	assert.False(t, locked)
+
+	_, _, err = repo.Lock(context.Background(), "tx-1", "fingerprint")
+	assert.ErrorIs(t, err, tenant.ErrTenantRequired)
+}
+
+func TestIdempotencyRepository_LockDefaultTimeout(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewIdempotencyRepository(NewFakeDynamoDB(), "devices", time.Hour, 0)
+	repo.now = func() time.Time { return now }
+
+	record, locked, err := repo.Lock(testContext(), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	assert.Equal(t, now.Add(DefaultIdempotencyLockTimeout).Unix(), record.LockedUntil)
+
+	// the lock is held while the first request runs
+	_, locked, err = repo.Lock(testContext(), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+	assert.False(t, locked)
+}
+
+func TestIdempotencyRepository_Complete(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo, _ := newTestIdempotencyRepository(&now)
+
+	record, _, err := repo.Lock(testContext(), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+
+	record.StatusCode = 201
+	record.Header = map[string][]string{"Etag": {`"1"`}}
+	record.Body = []byte(`{"id":"1"}`)
+	assert.NoError(t, repo.Complete(testContext(), record))
+
+	stored, locked, err := repo.Lock(testContext(), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+	assert.False(t, locked)
+	assert.False(t, stored.Locked())
+	assert.Equal(t, 201, stored.StatusCode)
+	assert.Equal(t, record.Header, stored.Header)
+	assert.Equal(t, record.Body, stored.Body)
+
+	// responses are not replayed after their TTL
+	now = now.Add(time.Hour)
+
+	_, locked, err = repo.Lock(testContext(), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+	assert.True(t, locked)
+}
+
+func TestIdempotencyRepository_Unlock(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo, store := newTestIdempotencyRepository(&now)
+
+	record, _, err := repo.Lock(testContext(), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Unlock(testContext(), record))
+	assert.Nil(t, store.Get(testKeys.idempotency("tx-1")))
+
+	_, locked, err := repo.Lock(testContext(), "tx-1", "other")
+	assert.NoError(t, err)
+	assert.True(t, locked)
+}
+
+func TestIdempotencyRepository_TenantIsolation(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo, _ := newTestIdempotencyRepository(&now)
+
+	_, locked, err := repo.Lock(testContext(), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	_, locked, err = repo.Lock(tenant.NewContext(context.Background(), "globex"), "tx-1", "fingerprint")
+	assert.NoError(t, err)
+	assert.True(t, locked)
+}
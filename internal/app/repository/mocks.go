@@ -0,0 +1,934 @@
+//go:build unit
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/db"
+)
+
+var errUnsupportedExpression = errors.New("unsupported expression")
+
+type item = map[string]types.AttributeValue
+
+// FakeDynamoDB is an in-memory DynamoDB table keyed by PK. Every call runs under
+// a single lock, so conditional writes are atomic like in DynamoDB. It understands
+// the subset of condition and update expressions used by the repositories.
+type FakeDynamoDB struct {
+	mu    sync.Mutex
+	items map[string]item
+	// throttledWrites and throttledGets are the numbers of upcoming batch calls which leave
+	// their last item unprocessed.
+	throttledWrites int
+	throttledGets   int
+}
+
+func NewFakeDynamoDB() *FakeDynamoDB {
+	return &FakeDynamoDB{items: map[string]item{}}
+}
+
+func (f *FakeDynamoDB) GetItem(_ context.Context, params *dynamodb.GetItemInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return &dynamodb.GetItemOutput{Item: copyItem(f.items[pk(params.Key)])}, nil
+}
+
+func (f *FakeDynamoDB) PutItem(_ context.Context, params *dynamodb.PutItemInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := pk(params.Item)
+
+	if err := f.check(key, params.ConditionExpression, params.ExpressionAttributeNames,
+		params.ExpressionAttributeValues, params.ReturnValuesOnConditionCheckFailure); err != nil {
+		return nil, err
+	}
+
+	f.items[key] = copyItem(params.Item)
+
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *FakeDynamoDB) UpdateItem(_ context.Context, params *dynamodb.UpdateItemInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.UpdateItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := pk(params.Key)
+
+	if err := f.check(key, params.ConditionExpression, params.ExpressionAttributeNames,
+		params.ExpressionAttributeValues, params.ReturnValuesOnConditionCheckFailure); err != nil {
+		return nil, err
+	}
+
+	updated := copyItem(f.items[key])
+	if updated == nil {
+		updated = copyItem(params.Key)
+	}
+
+	if err := applyUpdate(updated, *params.UpdateExpression, params.ExpressionAttributeNames,
+		params.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+
+	f.items[key] = updated
+
+	out := &dynamodb.UpdateItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllNew {
+		out.Attributes = copyItem(updated)
+	}
+
+	return out, nil
+}
+
+func (f *FakeDynamoDB) DeleteItem(_ context.Context, params *dynamodb.DeleteItemInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := pk(params.Key)
+
+	if err := f.check(key, params.ConditionExpression, params.ExpressionAttributeNames,
+		params.ExpressionAttributeValues, params.ReturnValuesOnConditionCheckFailure); err != nil {
+		return nil, err
+	}
+
+	delete(f.items, key)
+
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+// TransactWriteItems checks every condition first and only then applies the writes,
+// a failed condition cancels the whole transaction like in DynamoDB.
+func (f *FakeDynamoDB) TransactWriteItems(_ context.Context, params *dynamodb.TransactWriteItemsInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.TransactWriteItemsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ops := make([]txOp, 0, len(params.TransactItems))
+
+	for _, txItem := range params.TransactItems {
+		op, err := newTxOp(txItem)
+		if err != nil {
+			return nil, err
+		}
+
+		ops = append(ops, op)
+	}
+
+	reasons := make([]types.CancellationReason, len(ops))
+	canceled := false
+
+	for i, op := range ops {
+		reasons[i].Code = aws.String("None")
+
+		if op.condition == nil {
+			continue
+		}
+
+		ok, err := evalCondition(f.items[op.key], *op.condition, op.names, op.values)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			canceled = true
+			reasons[i].Code = aws.String(conditionalCheckFailed)
+
+			if op.returnOld == types.ReturnValuesOnConditionCheckFailureAllOld {
+				reasons[i].Item = copyItem(f.items[op.key])
+			}
+		}
+	}
+
+	if canceled {
+		return nil, &types.TransactionCanceledException{
+			Message:             aws.String("Transaction cancelled"),
+			CancellationReasons: reasons,
+		}
+	}
+
+	for _, op := range ops {
+		if err := op.apply(f.items); err != nil {
+			return nil, err
+		}
+	}
+
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+// Scan walks the items in PK order, Limit bounds the evaluated items before filtering.
+func (f *FakeDynamoDB) Scan(_ context.Context, params *dynamodb.ScanInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.ScanOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	items, lastKey, err := f.walk(f.sortedKeys(), params.ExclusiveStartKey, params.Limit,
+		params.FilterExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.ScanOutput{Items: items, LastEvaluatedKey: lastKey}, nil
+}
+
+// Query walks the items matching the key condition in PK order. Only the device model
+// index is known, it holds the items having a deviceModel attribute, queries without
+// index run against the table.
+func (f *FakeDynamoDB) Query(_ context.Context, params *dynamodb.QueryInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.QueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	indexKey := ""
+
+	if params.IndexName != nil {
+		if *params.IndexName != db.DeviceModelIndex {
+			return nil, fmt.Errorf("%w: unknown index %s", errUnsupportedExpression, *params.IndexName)
+		}
+
+		indexKey = db.DeviceModelIndexKey
+	}
+
+	keys := []string{}
+
+	for _, key := range f.sortedKeys() {
+		if indexKey != "" && f.items[key][indexKey] == nil {
+			continue
+		}
+
+		matched, err := evalCondition(f.items[key], aws.ToString(params.KeyConditionExpression),
+			params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+		if err != nil {
+			return nil, err
+		}
+
+		if matched {
+			keys = append(keys, key)
+		}
+	}
+
+	items, lastKey, err := f.walk(keys, params.ExclusiveStartKey, params.Limit,
+		params.FilterExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues,
+		func(it item) item {
+			if indexKey != "" {
+				return item{"PK": it["PK"], indexKey: it[indexKey]}
+			}
+
+			return item{"PK": it["PK"]}
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.QueryOutput{Items: items, LastEvaluatedKey: lastKey}, nil
+}
+
+func (f *FakeDynamoDB) sortedKeys() []string {
+	keys := make([]string, 0, len(f.items))
+	for key := range f.items {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// walk evaluates the sorted keys after the start key until limit items are evaluated,
+// lastKeyOf builds the LastEvaluatedKey of an item and defaults to its PK.
+func (f *FakeDynamoDB) walk(
+	keys []string,
+	startKey item,
+	limit *int32,
+	filter *string,
+	names map[string]string,
+	values item,
+	lastKeyOf func(it item) item,
+) ([]map[string]types.AttributeValue, item, error) {
+	if lastKeyOf == nil {
+		lastKeyOf = func(it item) item { return item{"PK": it["PK"]} }
+	}
+
+	start := ""
+	if startKey != nil {
+		start = pk(startKey)
+	}
+
+	var (
+		items     []map[string]types.AttributeValue
+		evaluated int32
+	)
+
+	for i, key := range keys {
+		if start != "" && key <= start {
+			continue
+		}
+
+		evaluated++
+
+		matched := true
+
+		if filter != nil {
+			var err error
+
+			matched, err = evalCondition(f.items[key], *filter, names, values)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if matched {
+			items = append(items, copyItem(f.items[key]))
+		}
+
+		if limit != nil && evaluated >= *limit {
+			if i < len(keys)-1 {
+				return items, lastKeyOf(f.items[key]), nil
+			}
+
+			break
+		}
+	}
+
+	return items, nil, nil
+}
+
+// BatchWriteItem applies the put and delete requests without conditions.
+func (f *FakeDynamoDB) BatchWriteItem(_ context.Context, params *dynamodb.BatchWriteItemInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.BatchWriteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]types.WriteRequest{}}
+
+	for table, requests := range params.RequestItems {
+		if len(requests) > 25 {
+			return nil, fmt.Errorf("%w: too many write requests", errUnsupportedExpression)
+		}
+
+		if f.throttledWrites > 0 && len(requests) > 0 {
+			f.throttledWrites--
+			out.UnprocessedItems[table] = requests[len(requests)-1:]
+			requests = requests[:len(requests)-1]
+		}
+
+		for _, request := range requests {
+			switch {
+			case request.PutRequest != nil:
+				f.items[pk(request.PutRequest.Item)] = copyItem(request.PutRequest.Item)
+			case request.DeleteRequest != nil:
+				delete(f.items, pk(request.DeleteRequest.Key))
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// BatchGetItem returns the existing items of the requested keys.
+func (f *FakeDynamoDB) BatchGetItem(_ context.Context, params *dynamodb.BatchGetItemInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.BatchGetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := &dynamodb.BatchGetItemOutput{
+		Responses:       map[string][]map[string]types.AttributeValue{},
+		UnprocessedKeys: map[string]types.KeysAndAttributes{},
+	}
+
+	for table, request := range params.RequestItems {
+		keys := request.Keys
+		if len(keys) > 100 {
+			return nil, fmt.Errorf("%w: too many keys", errUnsupportedExpression)
+		}
+
+		if f.throttledGets > 0 && len(keys) > 0 {
+			f.throttledGets--
+			out.UnprocessedKeys[table] = types.KeysAndAttributes{Keys: keys[len(keys)-1:]}
+			keys = keys[:len(keys)-1]
+		}
+
+		for _, key := range keys {
+			if it, ok := f.items[pk(key)]; ok {
+				out.Responses[table] = append(out.Responses[table], copyItem(it))
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// Put stores an item without any condition, it is meant to seed tests.
+func (f *FakeDynamoDB) Put(it item) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.items[pk(it)] = copyItem(it)
+}
+
+// Get returns a copy of the stored item or nil.
+func (f *FakeDynamoDB) Get(key string) item {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return copyItem(f.items[key])
+}
+
+func (f *FakeDynamoDB) check(
+	key string,
+	condition *string,
+	names map[string]string,
+	values item,
+	returnOld types.ReturnValuesOnConditionCheckFailure,
+) error {
+	if condition == nil {
+		return nil
+	}
+
+	ok, err := evalCondition(f.items[key], *condition, names, values)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return nil
+	}
+
+	condErr := &types.ConditionalCheckFailedException{Message: condition}
+	if returnOld == types.ReturnValuesOnConditionCheckFailureAllOld {
+		condErr.Item = copyItem(f.items[key])
+	}
+
+	return condErr
+}
+
+// txOp is a transaction item reduced to what the fake needs to check and apply it.
+type txOp struct {
+	key       string
+	condition *string
+	names     map[string]string
+	values    item
+	returnOld types.ReturnValuesOnConditionCheckFailure
+	apply     func(items map[string]item) error
+}
+
+func newTxOp(txItem types.TransactWriteItem) (txOp, error) {
+	switch {
+	case txItem.Put != nil:
+		put := txItem.Put
+		key := pk(put.Item)
+
+		return txOp{
+			key: key, condition: put.ConditionExpression, names: put.ExpressionAttributeNames,
+			values: put.ExpressionAttributeValues, returnOld: put.ReturnValuesOnConditionCheckFailure,
+			apply: func(items map[string]item) error {
+				items[key] = copyItem(put.Item)
+
+				return nil
+			},
+		}, nil
+	case txItem.Update != nil:
+		update := txItem.Update
+		key := pk(update.Key)
+
+		return txOp{
+			key: key, condition: update.ConditionExpression, names: update.ExpressionAttributeNames,
+			values: update.ExpressionAttributeValues, returnOld: update.ReturnValuesOnConditionCheckFailure,
+			apply: func(items map[string]item) error {
+				updated := copyItem(items[key])
+				if updated == nil {
+					updated = copyItem(update.Key)
+				}
+
+				if err := applyUpdate(updated, *update.UpdateExpression, update.ExpressionAttributeNames,
+					update.ExpressionAttributeValues); err != nil {
+					return err
+				}
+
+				items[key] = updated
+
+				return nil
+			},
+		}, nil
+	case txItem.Delete != nil:
+		del := txItem.Delete
+		key := pk(del.Key)
+
+		return txOp{
+			key: key, condition: del.ConditionExpression, names: del.ExpressionAttributeNames,
+			values: del.ExpressionAttributeValues, returnOld: del.ReturnValuesOnConditionCheckFailure,
+			apply: func(items map[string]item) error {
+				delete(items, key)
+
+				return nil
+			},
+		}, nil
+	case txItem.ConditionCheck != nil:
+		check := txItem.ConditionCheck
+
+		return txOp{
+			key: pk(check.Key), condition: check.ConditionExpression, names: check.ExpressionAttributeNames,
+			values: check.ExpressionAttributeValues, returnOld: check.ReturnValuesOnConditionCheckFailure,
+			apply: func(map[string]item) error { return nil },
+		}, nil
+	}
+
+	return txOp{}, fmt.Errorf("%w: empty transaction item", errUnsupportedExpression)
+}
+
+func pk(key item) string {
+	if str, ok := key["PK"].(*types.AttributeValueMemberS); ok {
+		return str.Value
+	}
+
+	return ""
+}
+
+func copyItem(it item) item {
+	if it == nil {
+		return nil
+	}
+
+	cp := make(item, len(it))
+	for k, v := range it {
+		cp[k] = v
+	}
+
+	return cp
+}
+
+// expression parsing
+
+type exprParser struct {
+	tokens []string
+	pos    int
+	names  map[string]string
+	values item
+}
+
+func tokenize(expr string) []string {
+	var (
+		tokens []string
+		runes  = []rune(expr)
+	)
+
+	isIdent := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '#' || r == ':' || r == '.'
+	}
+
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case isIdent(r):
+			j := i
+			for j < len(runes) && isIdent(runes[j]) {
+				j++
+			}
+
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case r == '<' || r == '>':
+			if i+1 < len(runes) && (runes[i+1] == '=' || runes[i+1] == '>') {
+				tokens = append(tokens, string(runes[i:i+2]))
+				i += 2
+			} else {
+				tokens = append(tokens, string(r))
+				i++
+			}
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+
+	return tokens
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+func (p *exprParser) next() string {
+	tok := p.peek()
+	p.pos++
+
+	return tok
+}
+
+func (p *exprParser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		return fmt.Errorf("%w: expected %q got %q", errUnsupportedExpression, tok, got)
+	}
+
+	return nil
+}
+
+func (p *exprParser) path(tok string) string {
+	if name, ok := p.names[tok]; ok {
+		return name
+	}
+
+	return tok
+}
+
+// operand resolves a value placeholder or an attribute path against the item.
+func (p *exprParser) operand(it item) (types.AttributeValue, error) {
+	tok := p.next()
+
+	if strings.HasPrefix(tok, ":") {
+		val, ok := p.values[tok]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing value %s", errUnsupportedExpression, tok)
+		}
+
+		return val, nil
+	}
+
+	if tok == "if_not_exists" {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+
+		current := it[p.path(p.next())]
+
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+
+		fallback, err := p.operand(it)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+
+		if current != nil {
+			return current, nil
+		}
+
+		return fallback, nil
+	}
+
+	return it[p.path(tok)], nil
+}
+
+func evalCondition(it item, expr string, names map[string]string, values item) (bool, error) {
+	parser := &exprParser{tokens: tokenize(expr), names: names, values: values}
+
+	ok, err := parser.or(it)
+	if err != nil {
+		return false, err
+	}
+
+	if parser.pos != len(parser.tokens) {
+		return false, fmt.Errorf("%w: trailing %q", errUnsupportedExpression, parser.peek())
+	}
+
+	return ok, nil
+}
+
+func (p *exprParser) or(it item) (bool, error) {
+	left, err := p.and(it)
+	if err != nil {
+		return false, err
+	}
+
+	for strings.EqualFold(p.peek(), "OR") {
+		p.next()
+
+		right, err := p.and(it)
+		if err != nil {
+			return false, err
+		}
+
+		left = left || right
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) and(it item) (bool, error) {
+	left, err := p.not(it)
+	if err != nil {
+		return false, err
+	}
+
+	for strings.EqualFold(p.peek(), "AND") {
+		p.next()
+
+		right, err := p.not(it)
+		if err != nil {
+			return false, err
+		}
+
+		left = left && right
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) not(it item) (bool, error) {
+	if strings.EqualFold(p.peek(), "NOT") {
+		p.next()
+
+		ok, err := p.not(it)
+
+		return !ok, err
+	}
+
+	return p.primary(it)
+}
+
+func (p *exprParser) primary(it item) (bool, error) {
+	switch tok := p.peek(); tok {
+	case "(":
+		p.next()
+
+		ok, err := p.or(it)
+		if err != nil {
+			return false, err
+		}
+
+		return ok, p.expect(")")
+	case "attribute_exists", "attribute_not_exists":
+		p.next()
+
+		if err := p.expect("("); err != nil {
+			return false, err
+		}
+
+		_, exists := it[p.path(p.next())]
+
+		return exists == (tok == "attribute_exists"), p.expect(")")
+	case "begins_with":
+		p.next()
+
+		if err := p.expect("("); err != nil {
+			return false, err
+		}
+
+		attr, err := p.operand(it)
+		if err != nil {
+			return false, err
+		}
+
+		if err := p.expect(","); err != nil {
+			return false, err
+		}
+
+		prefix, err := p.operand(it)
+		if err != nil {
+			return false, err
+		}
+
+		str, _ := attr.(*types.AttributeValueMemberS)
+		pre, _ := prefix.(*types.AttributeValueMemberS)
+
+		return str != nil && pre != nil && strings.HasPrefix(str.Value, pre.Value), p.expect(")")
+	}
+
+	left, err := p.operand(it)
+	if err != nil {
+		return false, err
+	}
+
+	operator := p.next()
+
+	right, err := p.operand(it)
+	if err != nil {
+		return false, err
+	}
+
+	return compare(left, operator, right)
+}
+
+func compare(left types.AttributeValue, operator string, right types.AttributeValue) (bool, error) {
+	if left == nil || right == nil {
+		return operator == "<>" && (left != nil || right != nil), nil
+	}
+
+	cmp := 0
+
+	switch lv := left.(type) {
+	case *types.AttributeValueMemberN:
+		rv, ok := right.(*types.AttributeValueMemberN)
+		if !ok {
+			return operator == "<>", nil
+		}
+
+		l, _ := strconv.ParseFloat(lv.Value, 64)
+		r, _ := strconv.ParseFloat(rv.Value, 64)
+
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case *types.AttributeValueMemberS:
+		rv, ok := right.(*types.AttributeValueMemberS)
+		if !ok {
+			return operator == "<>", nil
+		}
+
+		cmp = strings.Compare(lv.Value, rv.Value)
+	default:
+		if !reflect.DeepEqual(left, right) {
+			cmp = 1
+		}
+	}
+
+	switch operator {
+	case "=":
+		return cmp == 0, nil
+	case "<>":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+
+	return false, fmt.Errorf("%w: operator %q", errUnsupportedExpression, operator)
+}
+
+// applyUpdate supports SET (with + and -), REMOVE and ADD on numbers.
+func applyUpdate(it item, expr string, names map[string]string, values item) error {
+	parser := &exprParser{tokens: tokenize(expr), names: names, values: values}
+	// evaluate right hand sides against the item as it was before the update
+	before := copyItem(it)
+	clause := ""
+
+	for parser.peek() != "" {
+		switch tok := strings.ToUpper(parser.peek()); tok {
+		case "SET", "REMOVE", "ADD":
+			clause = tok
+			parser.next()
+
+			continue
+		case ",":
+			parser.next()
+
+			continue
+		}
+
+		attr := parser.path(parser.next())
+
+		var err error
+
+		switch clause {
+		case "SET":
+			err = parser.applySet(it, before, attr)
+		case "REMOVE":
+			delete(it, attr)
+		case "ADD":
+			err = parser.applyAdd(it, before, attr)
+		default:
+			err = fmt.Errorf("%w: clause %q", errUnsupportedExpression, clause)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *exprParser) applySet(it, before item, attr string) error {
+	if err := p.expect("="); err != nil {
+		return err
+	}
+
+	val, err := p.operand(before)
+	if err != nil {
+		return err
+	}
+
+	if op := p.peek(); op == "+" || op == "-" {
+		p.next()
+
+		delta, err := p.operand(before)
+		if err != nil {
+			return err
+		}
+
+		val = addNumbers(val, delta, op == "-")
+	}
+
+	it[attr] = val
+
+	return nil
+}
+
+func (p *exprParser) applyAdd(it, before item, attr string) error {
+	delta, err := p.operand(before)
+	if err != nil {
+		return err
+	}
+
+	current := before[attr]
+	if current == nil {
+		current = &types.AttributeValueMemberN{Value: "0"}
+	}
+
+	it[attr] = addNumbers(current, delta, false)
+
+	return nil
+}
+
+func addNumbers(left, right types.AttributeValue, subtract bool) types.AttributeValue {
+	lv, _ := left.(*types.AttributeValueMemberN)
+	rv, _ := right.(*types.AttributeValueMemberN)
+
+	var l, r int64
+	if lv != nil {
+		l, _ = strconv.ParseInt(lv.Value, 10, 64)
+	}
+
+	if rv != nil {
+		r, _ = strconv.ParseInt(rv.Value, 10, 64)
+	}
+
+	if subtract {
+		r = -r
+	}
+
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(l+r, 10)}
+}
@@ -9,41 +9,213 @@ import (
 	"github.com/ijalalfrz/go-serverless/internal/app/config"
 	"github.com/ijalalfrz/go-serverless/internal/app/dto"
 	"github.com/ijalalfrz/go-serverless/internal/app/endpoint"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tracing"
 	httptransport "github.com/ijalalfrz/go-serverless/internal/pkg/transport/http"
 )
 
-// MakeHTTPRouter builds the HTTP router with all the service endpoints.
+// Scopes required by the API routes when authentication is enabled.
+const (
+	ScopeDevicesRead       = "devices:read"
+	ScopeDevicesWrite      = "devices:write"
+	ScopeDeviceModelsRead  = "devicemodels:read"
+	ScopeDeviceModelsWrite = "devicemodels:write"
+	ScopeAPIKeysRead       = "apikeys:read"
+	ScopeAPIKeysWrite      = "apikeys:write"
+)
+
+// MakeHTTPRouter builds the HTTP router with all the service endpoints. When authentication
+// is enabled the API routes require a token verified by tokenVerifier, or an API key verified
+// by apiKeyVerifier, granting their scopes. Mutating requests sent with an X-Transaction-Id
+// header are deduplicated with idempotencyStore, nil disables it. The served requests are
+// recorded by requestMetrics and traced by tracer, and the requests whose handler panicked are
+// stored in snapshotSink, nil disables them.
 func MakeHTTPRouter(
 	endpts endpoint.Endpoint,
+	tokenVerifier httptransport.TokenVerifier,
+	apiKeyVerifier httptransport.APIKeyVerifier,
+	idempotencyStore httptransport.IdempotencyStore,
+	requestMetrics httptransport.RequestMetrics,
+	tracer *tracing.Tracer,
+	snapshotSink httptransport.SnapshotSink,
 	cfg config.Config,
 ) *chi.Mux {
 	// Initialize Router
 	router := chi.NewRouter()
 
+	router.Use(httptransport.RequestIDMiddleware())
+
+	if tracer != nil {
+		router.Use(httptransport.TracingMiddleware(tracer))
+	}
+
+	if requestMetrics != nil {
+		router.Use(httptransport.MetricsMiddleware(requestMetrics))
+	}
+
 	router.Get("/health", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 	})
 
+	loggingOptions := httptransport.LoggingOptions{
+		RedactFields:      cfg.Logging.RedactFields,
+		RedactHeaders:     cfg.Logging.RedactHeaders,
+		MaxBodySize:       cfg.Logging.MaxBodySize,
+		SuccessSampleRate: cfg.Logging.SuccessSampleRate,
+	}
+
 	router.Route("/api", func(router chi.Router) {
 		router.Use(
-			httptransport.LoggingMiddleware(slog.Default()),
+			httptransport.LoggingMiddleware(slog.Default(), loggingOptions),
 			httptransport.CORSMiddleware(cfg.HTTP.AllowedOrigin),
-			httptransport.Recoverer(slog.Default()),
+			httptransport.Recoverer(slog.Default(), httptransport.RecovererOptions{
+				Metrics:   requestMetrics,
+				Snapshots: snapshotSink,
+				Sanitize:  loggingOptions,
+			}),
+			httptransport.HeaderMiddleware(),
 			render.SetContentType(render.ContentTypeJSON),
+			httptransport.TimeoutMiddleware(cfg.HTTP.Timeout, cfg.Lambda.DeadlineMargin),
 		)
 
+		if cfg.Auth.Enabled {
+			router.Use(
+				httptransport.APIKeyMiddleware(apiKeyVerifier),
+				httptransport.AuthMiddleware(tokenVerifier),
+			)
+		}
+
+		router.Use(httptransport.TenantMiddleware())
+
+		if idempotencyStore != nil {
+			router.Use(httptransport.IdempotencyMiddleware(idempotencyStore, slog.Default()))
+		}
+
+		scopes := func(scopes ...string) func(http.Handler) http.Handler {
+			if !cfg.Auth.Enabled {
+				return func(next http.Handler) http.Handler { return next }
+			}
+
+			return httptransport.RequireScopes(scopes...)
+		}
+
+		router.With(scopes(ScopeDevicesWrite)).Post("/devices:batchCreate", httptransport.MakeHandlerFunc(
+			endpts.Device.BatchCreateDevices,
+			httptransport.DecodeRequest[dto.BatchCreateDevicesRequest],
+			httptransport.ResponseWithBody,
+		))
+
+		router.With(scopes(ScopeDevicesRead)).Post("/devices:batchGet", httptransport.MakeHandlerFunc(
+			endpts.Device.BatchGetDevices,
+			httptransport.DecodeRequest[dto.BatchGetDevicesRequest],
+			httptransport.ResponseWithBody,
+		))
+
 		router.Route("/devices", func(router chi.Router) {
-			router.Post("/", httptransport.MakeHandlerFunc(
+			router.With(scopes(ScopeDevicesWrite)).Post("/", httptransport.MakeHandlerFunc(
 				endpts.Device.CreateDevice,
 				httptransport.DecodeRequest[dto.CreateDeviceRequest],
 				httptransport.CreatedResponse,
 			))
 
-			router.Get("/{id}", httptransport.MakeHandlerFunc(
+			router.With(scopes(ScopeDevicesRead)).Get("/", httptransport.MakeHandlerFunc(
+				endpts.Device.ListDevices,
+				httptransport.DecodeRequest[dto.ListDevicesRequest],
+				httptransport.ResponseWithBody,
+			))
+
+			router.With(scopes(ScopeDevicesRead)).Get("/{id}", httptransport.MakeHandlerFunc(
 				endpts.Device.GetDeviceByID,
 				httptransport.DecodeRequest[dto.GetDeviceByIDRequest],
 				httptransport.ResponseWithBody,
 			))
+
+			router.With(scopes(ScopeDevicesWrite)).Put("/{id}", httptransport.MakeHandlerFunc(
+				endpts.Device.UpdateDevice,
+				httptransport.DecodeRequest[dto.UpdateDeviceRequest],
+				httptransport.ResponseWithBody,
+			))
+
+			router.With(scopes(ScopeDevicesWrite)).Patch("/{id}", httptransport.MakeHandlerFunc(
+				endpts.Device.PatchDevice,
+				httptransport.DecodeRequest[dto.PatchDeviceRequest],
+				httptransport.ResponseWithBody,
+			))
+
+			router.With(scopes(ScopeDevicesWrite)).Delete("/{id}", httptransport.MakeHandlerFunc(
+				endpts.Device.DeleteDevice,
+				httptransport.DecodeRequest[dto.DeleteDeviceRequest],
+				httptransport.NoContentResponse,
+			))
+
+			router.With(scopes(ScopeDevicesWrite)).Post("/{id}:restore", httptransport.MakeHandlerFunc(
+				endpts.Device.RestoreDevice,
+				httptransport.DecodeRequest[dto.RestoreDeviceRequest],
+				httptransport.ResponseWithBody,
+			))
+		})
+
+		router.Route("/devicemodels", func(router chi.Router) {
+			router.With(scopes(ScopeDeviceModelsWrite)).Post("/", httptransport.MakeHandlerFunc(
+				endpts.DeviceModel.CreateDeviceModel,
+				httptransport.DecodeRequest[dto.CreateDeviceModelRequest],
+				httptransport.CreatedResponse,
+			))
+
+			router.With(scopes(ScopeDeviceModelsRead)).Get("/", httptransport.MakeHandlerFunc(
+				endpts.DeviceModel.ListDeviceModels,
+				httptransport.DecodeRequest[dto.ListDeviceModelsRequest],
+				httptransport.ResponseWithBody,
+			))
+
+			router.With(scopes(ScopeDeviceModelsRead)).Get("/{id}", httptransport.MakeHandlerFunc(
+				endpts.DeviceModel.GetDeviceModelByID,
+				httptransport.DecodeRequest[dto.GetDeviceModelByIDRequest],
+				httptransport.ResponseWithBody,
+			))
+
+			router.With(scopes(ScopeDevicesRead)).Get("/{id}/devices", httptransport.MakeHandlerFunc(
+				endpts.Device.ListDevicesByModel,
+				httptransport.DecodeRequest[dto.ListDevicesByModelRequest],
+				httptransport.ResponseWithBody,
+			))
+
+			router.With(scopes(ScopeDeviceModelsWrite)).Put("/{id}", httptransport.MakeHandlerFunc(
+				endpts.DeviceModel.UpdateDeviceModel,
+				httptransport.DecodeRequest[dto.UpdateDeviceModelRequest],
+				httptransport.ResponseWithBody,
+			))
+
+			router.With(scopes(ScopeDeviceModelsWrite)).Delete("/{id}", httptransport.MakeHandlerFunc(
+				endpts.DeviceModel.DeleteDeviceModel,
+				httptransport.DecodeRequest[dto.DeleteDeviceModelRequest],
+				httptransport.NoContentResponse,
+			))
+		})
+
+		router.Route("/apikeys", func(router chi.Router) {
+			router.With(scopes(ScopeAPIKeysWrite)).Post("/", httptransport.MakeHandlerFunc(
+				endpts.APIKey.CreateAPIKey,
+				httptransport.DecodeRequest[dto.CreateAPIKeyRequest],
+				httptransport.CreatedResponseWithBody,
+			))
+
+			router.With(scopes(ScopeAPIKeysRead)).Get("/", httptransport.MakeHandlerFunc(
+				endpts.APIKey.ListAPIKeys,
+				httptransport.DecodeRequest[dto.ListAPIKeysRequest],
+				httptransport.ResponseWithBody,
+			))
+
+			router.With(scopes(ScopeAPIKeysWrite)).Post("/{id}:rotate", httptransport.MakeHandlerFunc(
+				endpts.APIKey.RotateAPIKey,
+				httptransport.DecodeRequest[dto.RotateAPIKeyRequest],
+				httptransport.ResponseWithBody,
+			))
+
+			router.With(scopes(ScopeAPIKeysWrite)).Delete("/{id}", httptransport.MakeHandlerFunc(
+				endpts.APIKey.RevokeAPIKey,
+				httptransport.DecodeRequest[dto.RevokeAPIKeyRequest],
+				httptransport.NoContentResponse,
+			))
 		})
 	})
 
@@ -3,12 +3,22 @@
 package router
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/ijalalfrz/go-serverless/internal/app/config"
 	"github.com/ijalalfrz/go-serverless/internal/app/endpoint"
+	"github.com/ijalalfrz/go-serverless/internal/app/repository"
+	"github.com/ijalalfrz/go-serverless/internal/app/service"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/pagination"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestConfigRoute(t *testing.T) {
@@ -26,6 +36,12 @@ func TestConfigRoute(t *testing.T) {
 		endpoint.Endpoint{
 			Device: endpoint.Device{},
 		},
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
 		cfg,
 	)
 
@@ -47,12 +63,114 @@ func TestConfigRoute(t *testing.T) {
 			path:        "/api/devices",
 			shouldMatch: true,
 		},
+		{
+			name:        "List devices",
+			method:      http.MethodGet,
+			path:        "/api/devices",
+			shouldMatch: true,
+		},
 		{
 			name:        "Get device",
 			method:      http.MethodGet,
 			path:        "/api/devices/device-123",
 			shouldMatch: true,
 		},
+		{
+			name:        "Update device",
+			method:      http.MethodPut,
+			path:        "/api/devices/device-123",
+			shouldMatch: true,
+		},
+		{
+			name:        "Patch device",
+			method:      http.MethodPatch,
+			path:        "/api/devices/device-123",
+			shouldMatch: true,
+		},
+		{
+			name:        "Delete device",
+			method:      http.MethodDelete,
+			path:        "/api/devices/device-123",
+			shouldMatch: true,
+		},
+		{
+			name:        "Restore device",
+			method:      http.MethodPost,
+			path:        "/api/devices/device-123:restore",
+			shouldMatch: true,
+		},
+		{
+			name:        "Batch create devices",
+			method:      http.MethodPost,
+			path:        "/api/devices:batchCreate",
+			shouldMatch: true,
+		},
+		{
+			name:        "Batch get devices",
+			method:      http.MethodPost,
+			path:        "/api/devices:batchGet",
+			shouldMatch: true,
+		},
+		{
+			name:        "Create device model",
+			method:      http.MethodPost,
+			path:        "/api/devicemodels",
+			shouldMatch: true,
+		},
+		{
+			name:        "List device models",
+			method:      http.MethodGet,
+			path:        "/api/devicemodels",
+			shouldMatch: true,
+		},
+		{
+			name:        "Get device model",
+			method:      http.MethodGet,
+			path:        "/api/devicemodels/model-123",
+			shouldMatch: true,
+		},
+		{
+			name:        "List devices of device model",
+			method:      http.MethodGet,
+			path:        "/api/devicemodels/model-123/devices",
+			shouldMatch: true,
+		},
+		{
+			name:        "Update device model",
+			method:      http.MethodPut,
+			path:        "/api/devicemodels/model-123",
+			shouldMatch: true,
+		},
+		{
+			name:        "Delete device model",
+			method:      http.MethodDelete,
+			path:        "/api/devicemodels/model-123",
+			shouldMatch: true,
+		},
+		{
+			name:        "Create api key",
+			method:      http.MethodPost,
+			path:        "/api/apikeys",
+			shouldMatch: true,
+		},
+		{
+			name:        "List api keys",
+			method:      http.MethodGet,
+			path:        "/api/apikeys",
+			shouldMatch: true,
+		},
+		{
+			name:        "Rotate api key",
+			method:      http.MethodPost,
+			path:        "/api/apikeys/0123456789abcdef:rotate",
+			shouldMatch: true,
+		},
+		{
+			name:        "Revoke api key",
+			method:      http.MethodDelete,
+			path:        "/api/apikeys/0123456789abcdef",
+			shouldMatch: true,
+		},
 	}
 
 	chiCtx := chi.NewRouteContext()
@@ -69,3 +187,177 @@ func TestConfigRoute(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticatedRoutes(t *testing.T) {
+	cfg := config.Config{Auth: config.Auth{Enabled: true, HMACSecret: "secret"}}
+	router := MakeHTTPRouter(
+		endpoint.Endpoint{},
+		auth.NewVerifier(nil, auth.VerifierConfig{}),
+		stubAPIKeyVerifier{
+			"valid-key":      {Subject: "apikey:1", Scopes: []string{ScopeDevicesRead}, Tenant: "acme"},
+			"tenantless-key": {Subject: "apikey:2", Scopes: []string{ScopeDevicesRead}},
+			"cross-key":      {Subject: "apikey:3", Scopes: []string{ScopeDevicesRead, tenant.CrossTenantScope}},
+		},
+		nil,
+		nil,
+		nil,
+		nil,
+		cfg,
+	)
+
+	testCases := []struct {
+		method string
+		path   string
+		apiKey string
+		tenant string
+		status int
+	}{
+		{method: http.MethodGet, path: "/health", status: http.StatusNoContent},
+		{method: http.MethodGet, path: "/api/devices", status: http.StatusUnauthorized},
+		{method: http.MethodPost, path: "/api/devicemodels", status: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/api/devices", apiKey: "other-key", status: http.StatusUnauthorized},
+		{method: http.MethodPost, path: "/api/apikeys", apiKey: "valid-key", status: http.StatusForbidden},
+		{method: http.MethodGet, path: "/api/devices", apiKey: "valid-key", tenant: "globex", status: http.StatusForbidden},
+		{method: http.MethodGet, path: "/api/devices", apiKey: "tenantless-key", tenant: "globex", status: http.StatusForbidden},
+		{method: http.MethodGet, path: "/api/devices", apiKey: "cross-key", status: http.StatusBadRequest},
+		{method: http.MethodGet, path: "/api/devices", apiKey: "cross-key", tenant: "a#b", status: http.StatusBadRequest},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.method+" "+testCase.path+" "+testCase.apiKey+" "+testCase.tenant, func(t *testing.T) {
+			req := httptest.NewRequest(testCase.method, testCase.path, nil)
+			if testCase.apiKey != "" {
+				req.Header.Set("X-API-Key", testCase.apiKey)
+			}
+
+			if testCase.tenant != "" {
+				req.Header.Set("X-Tenant-Id", testCase.tenant)
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, testCase.status, resp.Code)
+		})
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	store := repository.NewFakeDynamoDB()
+	cursorCodec := pagination.NewCursorCodec("secret")
+	router := MakeHTTPRouter(
+		endpoint.Endpoint{
+			Device: endpoint.NewDeviceEndpoint(service.NewDeviceService(
+				repository.NewDeviceRepository(store, "devices", cursorCodec), 0)),
+			DeviceModel: endpoint.NewDeviceModelEndpoint(service.NewDeviceModelService(
+				repository.NewDeviceModelRepository(store, "devices", cursorCodec))),
+		},
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		config.Config{},
+	)
+
+	serve := func(method string, path string, tenant string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		if tenant != "" {
+			req.Header.Set("X-Tenant-Id", tenant)
+		}
+
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		return resp
+	}
+
+	resp := serve(http.MethodPost, "/api/devicemodels", "acme",
+		`{"id":"/devicemodels/m1","manufacturer":"Acme","displayName":"M1","category":"sensor"}`)
+	assert.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	resp = serve(http.MethodPost, "/api/devices", "acme",
+		`{"id":"/devices/d1","deviceModel":"/devicemodels/m1","name":"D1","note":"note","serial":"SN-1"}`)
+	assert.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/devices/d1", "acme", "").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/devicemodels/m1", "acme", "").Code)
+
+	for _, path := range []string{"/api/devices/d1", "/api/devicemodels/m1"} {
+		resp := serve(http.MethodGet, path, "globex", "")
+		assert.Equal(t, http.StatusNotFound, resp.Code, path)
+	}
+
+	resp = serve(http.MethodGet, "/api/devices", "globex", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "d1")
+
+	resp = serve(http.MethodGet, "/api/devices/d1", "", "")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "TENANT_REQUIRED")
+	assert.NotEmpty(t, resp.Header().Get("X-Request-Id"))
+	assert.Contains(t, resp.Body.String(), `"requestId":"`+resp.Header().Get("X-Request-Id")+`"`)
+}
+
+func TestIdempotentRequests(t *testing.T) {
+	store := repository.NewFakeDynamoDB()
+	router := MakeHTTPRouter(
+		endpoint.Endpoint{
+			DeviceModel: endpoint.NewDeviceModelEndpoint(service.NewDeviceModelService(
+				repository.NewDeviceModelRepository(store, "devices", pagination.NewCursorCodec("secret")))),
+		},
+		nil,
+		nil,
+		repository.NewIdempotencyRepository(store, "devices", time.Hour, time.Minute),
+		nil,
+		nil,
+		nil,
+		config.Config{},
+	)
+
+	serve := func(key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/devicemodels", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant-Id", "acme")
+		req.Header.Set("X-Transaction-Id", key)
+
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		return resp
+	}
+
+	body := `{"id":"/devicemodels/m1","manufacturer":"Acme","displayName":"M1","category":"sensor"}`
+
+	first := serve("tx-1", body)
+	assert.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	replay := serve("tx-1", body)
+	assert.Equal(t, http.StatusCreated, replay.Code, replay.Body.String())
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+
+	reused := serve("tx-1", `{"id":"/devicemodels/m2","manufacturer":"Acme","displayName":"M2","category":"sensor"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.Contains(t, reused.Body.String(), "IDEMPOTENCY_KEY_REUSED")
+
+	// a new key runs the request again
+	duplicate := serve("tx-2", body)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+	assert.Contains(t, duplicate.Body.String(), "DEVICE_MODEL_ALREADY_EXIST")
+}
+
+type stubAPIKeyVerifier map[string]auth.Claims
+
+func (s stubAPIKeyVerifier) VerifyAPIKey(_ context.Context, key string) (auth.Claims, error) {
+	claims, ok := s[key]
+	if !ok {
+		return auth.Claims{}, auth.ErrInvalidAPIKey
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/app/model"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
+)
+
+// APIKeySubjectPrefix prefixes the API key ID in the subject of API key callers.
+const APIKeySubjectPrefix = "apikey:"
+
+// maxCachedAPIKeys bounds the verified key cache, it is cleared when full.
+const maxCachedAPIKeys = 1000
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, apiKey model.APIKey) error
+	GetByID(ctx context.Context, id string) (model.APIKey, error)
+	List(ctx context.Context, limit int32, cursor string) ([]model.APIKey, string, error)
+	Rotate(ctx context.Context, id string, salt []byte, hash []byte, rotatedAt time.Time) (model.APIKey, error)
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+}
+
+type APIKeyService struct {
+	apiKeyRepo APIKeyRepository
+	cache      *apiKeyCache
+	now        func() time.Time
+}
+
+// NewAPIKeyService returns the API key service, verified keys are cached for cacheTTL so that
+// warm invocations skip the table lookup. A zero cacheTTL disables the cache.
+func NewAPIKeyService(apiKeyRepo APIKeyRepository, cacheTTL time.Duration) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		cache:      newAPIKeyCache(cacheTTL),
+		now:        time.Now,
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary      Create API Key
+// @Description  Mint an API key, the key is only returned in this response
+// @Tags         APIKey
+// @ID           createAPIKey
+// @Produce      json
+// @Param        req body create api key	body		dto.CreateAPIKeyRequest	true	"API Key"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      201  {object}  dto.APIKeySecretResponse	"Created"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/apikeys [post].
+func (s *APIKeyService) CreateAPIKey(
+	ctx context.Context,
+	req dto.CreateAPIKeyRequest,
+) (dto.APIKeySecretResponse, error) {
+	// a caller can not grant the scopes it does not hold
+	if req.Caller != nil && !req.Caller.HasScopes(req.Scopes...) {
+		err := exception.ErrForbidden
+		err.UICode = exception.InsufficientScope
+		err.Localizable = lang.Localizable{
+			MessageID:   "errors.insufficient_scope",
+			Message:     "insufficient scope",
+			MessageVars: map[string]interface{}{"scopes": strings.Join(req.Scopes, ", ")},
+		}
+
+		return dto.APIKeySecretResponse{}, err
+	}
+
+	id, secret, err := auth.GenerateAPIKey()
+	if err != nil {
+		return dto.APIKeySecretResponse{}, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	salt, hash, err := auth.HashAPIKeySecret(secret)
+	if err != nil {
+		return dto.APIKeySecretResponse{}, fmt.Errorf("failed to hash api key: %w", err)
+	}
+
+	apiKey := model.APIKey{
+		ID:        id,
+		Name:      req.Name,
+		Owner:     req.Owner,
+		Scopes:    req.Scopes,
+		Salt:      salt,
+		Hash:      hash,
+		CreatedAt: s.now().UTC(),
+	}
+
+	if req.ExpiresAt != nil {
+		apiKey.ExpiresAt = req.ExpiresAt.Unix()
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return dto.APIKeySecretResponse{}, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return dto.APIKeySecretResponse{
+		APIKeyResponse: toAPIKeyResponse(apiKey),
+		Key:            auth.FormatAPIKey(id, secret),
+	}, nil
+}
+
+// ListAPIKeys godoc
+// @Summary      List API Keys
+// @Description  List API keys using cursor pagination, revoked keys included
+// @Tags         APIKey
+// @ID           listAPIKeys
+// @Produce      json
+// @Param        limit query int false "Page size (1-100)" default(20)
+// @Param        cursor query string false "Cursor returned as nextCursor by the previous page"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Success      200  {object}  dto.ListAPIKeysResponse	"OK"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/apikeys [get].
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, req dto.ListAPIKeysRequest) (dto.ListAPIKeysResponse, error) {
+	apiKeys, nextCursor, err := s.apiKeyRepo.List(ctx, req.Limit, req.Cursor)
+	if err != nil {
+		return dto.ListAPIKeysResponse{}, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	resp := dto.ListAPIKeysResponse{
+		APIKeys:    make([]dto.APIKeyResponse, 0, len(apiKeys)),
+		NextCursor: nextCursor,
+	}
+
+	for _, apiKey := range apiKeys {
+		resp.APIKeys = append(resp.APIKeys, toAPIKeyResponse(apiKey))
+	}
+
+	return resp, nil
+}
+
+// RotateAPIKey godoc
+// @Summary      Rotate API Key
+// @Description  Replace the secret of an API key, the previous key stops working
+// @Tags         APIKey
+// @ID           rotateAPIKey
+// @Produce      json
+// @Param        id path string true "API Key ID"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      200  {object}  dto.APIKeySecretResponse	"OK"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      404  {object}  dto.ErrorResponse	"Record not found"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/apikeys/{id}:rotate [post].
+func (s *APIKeyService) RotateAPIKey(
+	ctx context.Context,
+	req dto.RotateAPIKeyRequest,
+) (dto.APIKeySecretResponse, error) {
+	secret, err := auth.GenerateAPIKeySecret()
+	if err != nil {
+		return dto.APIKeySecretResponse{}, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	salt, hash, err := auth.HashAPIKeySecret(secret)
+	if err != nil {
+		return dto.APIKeySecretResponse{}, fmt.Errorf("failed to hash api key: %w", err)
+	}
+
+	apiKey, err := s.apiKeyRepo.Rotate(ctx, req.ID, salt, hash, s.now().UTC())
+	if err != nil {
+		return dto.APIKeySecretResponse{}, fmt.Errorf("failed to rotate api key: %w", err)
+	}
+
+	s.cache.evict(req.ID)
+
+	return dto.APIKeySecretResponse{
+		APIKeyResponse: toAPIKeyResponse(apiKey),
+		Key:            auth.FormatAPIKey(apiKey.ID, secret),
+	}, nil
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke API Key
+// @Description  Revoke an API key, the key is kept and listed as revoked
+// @Tags         APIKey
+// @ID           revokeAPIKey
+// @Produce      json
+// @Param        id path string true "API Key ID"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      204  "No Content"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      404  {object}  dto.ErrorResponse	"Record not found"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/apikeys/{id} [delete].
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, req dto.RevokeAPIKeyRequest) error {
+	if err := s.apiKeyRepo.Revoke(ctx, req.ID, s.now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	s.cache.evict(req.ID)
+
+	return nil
+}
+
+// VerifyAPIKey returns the claims of a valid API key, unknown, revoked, expired and
+// mismatching keys fail with auth.ErrInvalidAPIKey. The cache is local to the instance,
+// keys revoked through another instance stay valid there for at most the cache TTL.
+func (s *APIKeyService) VerifyAPIKey(ctx context.Context, key string) (auth.Claims, error) {
+	now := s.now()
+
+	if claims, ok := s.cache.get(key, now); ok {
+		return claims, nil
+	}
+
+	id, secret, err := auth.ParseAPIKey(key)
+	if err != nil {
+		return auth.Claims{}, err //nolint:wrapcheck
+	}
+
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, exception.ErrRecordNotFound) {
+			return auth.Claims{}, fmt.Errorf("%w: unknown key", auth.ErrInvalidAPIKey)
+		}
+
+		return auth.Claims{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if apiKey.RevokedAt != nil {
+		return auth.Claims{}, fmt.Errorf("%w: revoked key", auth.ErrInvalidAPIKey)
+	}
+
+	var expiresAt time.Time
+	if apiKey.ExpiresAt > 0 {
+		// the table TTL deletes expired items lazily
+		expiresAt = time.Unix(apiKey.ExpiresAt, 0)
+		if !now.Before(expiresAt) {
+			return auth.Claims{}, fmt.Errorf("%w: expired key", auth.ErrInvalidAPIKey)
+		}
+	}
+
+	if !auth.VerifyAPIKeySecret(secret, apiKey.Salt, apiKey.Hash) {
+		return auth.Claims{}, fmt.Errorf("%w: secret mismatch", auth.ErrInvalidAPIKey)
+	}
+
+	claims := auth.Claims{
+		Subject:   APIKeySubjectPrefix + apiKey.ID,
+		ExpiresAt: expiresAt,
+		Scopes:    apiKey.Scopes,
+		Tenant:    apiKey.Tenant,
+		Raw: map[string]interface{}{
+			"sub":            APIKeySubjectPrefix + apiKey.ID,
+			"owner":          apiKey.Owner,
+			auth.TenantClaim: apiKey.Tenant,
+		},
+	}
+
+	s.cache.put(key, apiKey.ID, claims, now)
+
+	return claims, nil
+}
+
+func toAPIKeyResponse(apiKey model.APIKey) dto.APIKeyResponse {
+	resp := dto.APIKeyResponse{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Owner:     apiKey.Owner,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+		RotatedAt: apiKey.RotatedAt,
+		RevokedAt: apiKey.RevokedAt,
+	}
+
+	if apiKey.ExpiresAt > 0 {
+		expiresAt := time.Unix(apiKey.ExpiresAt, 0).UTC()
+		resp.ExpiresAt = &expiresAt
+	}
+
+	return resp
+}
+
+// apiKeyCache caches the claims of verified keys by the hash of the key, so that the keys
+// themselves are not kept in memory.
+type apiKeyCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]apiKeyCacheEntry
+}
+
+type apiKeyCacheEntry struct {
+	id        string
+	claims    auth.Claims
+	expiresAt time.Time
+}
+
+func newAPIKeyCache(ttl time.Duration) *apiKeyCache {
+	return &apiKeyCache{
+		ttl:     ttl,
+		entries: make(map[[sha256.Size]byte]apiKeyCacheEntry),
+	}
+}
+
+func (c *apiKeyCache) get(key string, now time.Time) (auth.Claims, bool) {
+	if c.ttl <= 0 {
+		return auth.Claims{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	digest := sha256.Sum256([]byte(key))
+
+	entry, ok := c.entries[digest]
+	if !ok {
+		return auth.Claims{}, false
+	}
+
+	if !now.Before(entry.expiresAt) {
+		delete(c.entries, digest)
+
+		return auth.Claims{}, false
+	}
+
+	return entry.claims, true
+}
+
+func (c *apiKeyCache) put(key string, id string, claims auth.Claims, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCachedAPIKeys {
+		clear(c.entries)
+	}
+
+	expiresAt := now.Add(c.ttl)
+	if !claims.ExpiresAt.IsZero() && claims.ExpiresAt.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt
+	}
+
+	c.entries[sha256.Sum256([]byte(key))] = apiKeyCacheEntry{
+		id:        id,
+		claims:    claims,
+		expiresAt: expiresAt,
+	}
+}
+
+func (c *apiKeyCache) evict(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for digest, entry := range c.entries {
+		if entry.id == id {
+			delete(c.entries, digest)
+		}
+	}
+}
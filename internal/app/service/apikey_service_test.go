@@ -0,0 +1,188 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestAPIKey(t *testing.T, svc *APIKeyService, expiresAt *time.Time) dto.APIKeySecretResponse {
+	t.Helper()
+
+	resp, err := svc.CreateAPIKey(tenant.NewContext(context.Background(), "acme"), dto.CreateAPIKeyRequest{
+		Name:      "ingest",
+		Scopes:    []string{"devices:write"},
+		ExpiresAt: expiresAt,
+		Owner:     "user-1",
+	})
+	assert.NoError(t, err)
+
+	return resp
+}
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo := &MockAPIKeyRepository{}
+		svc := NewAPIKeyService(mockRepo, 0)
+
+		expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		resp := createTestAPIKey(t, svc, &expiresAt)
+
+		assert.Equal(t, "user-1", resp.Owner)
+		assert.Equal(t, expiresAt.Unix(), resp.ExpiresAt.Unix())
+		assert.NotEmpty(t, resp.Key)
+
+		// only the salted hash is stored
+		stored, err := mockRepo.GetByID(context.Background(), resp.ID)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(stored.Hash), resp.Key)
+
+		_, secret, err := auth.ParseAPIKey(resp.Key)
+		assert.NoError(t, err)
+		assert.True(t, auth.VerifyAPIKeySecret(secret, stored.Salt, stored.Hash))
+	})
+
+	t.Run("scope_escalation", func(t *testing.T) {
+		svc := NewAPIKeyService(&MockAPIKeyRepository{}, 0)
+
+		_, err := svc.CreateAPIKey(context.Background(), dto.CreateAPIKeyRequest{
+			Name:   "ingest",
+			Scopes: []string{"devices:write", "apikeys:write"},
+			Caller: &auth.Claims{Scopes: []string{"devices:write", "apikeys:read"}},
+		})
+
+		var appErr exception.ApplicationError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusForbidden, appErr.StatusCode)
+		assert.Equal(t, exception.InsufficientScope, appErr.UICode)
+	})
+
+	t.Run("db_error", func(t *testing.T) {
+		svc := NewAPIKeyService(&MockAPIKeyRepository{err: ErrMockDB}, 0)
+
+		_, err := svc.CreateAPIKey(context.Background(), dto.CreateAPIKeyRequest{Name: "ingest"})
+		assert.ErrorIs(t, err, ErrMockDB)
+	})
+}
+
+func TestAPIKeyService_ListAPIKeys(t *testing.T) {
+	svc := NewAPIKeyService(&MockAPIKeyRepository{}, 0)
+	created := createTestAPIKey(t, svc, nil)
+
+	resp, err := svc.ListAPIKeys(context.Background(), dto.ListAPIKeysRequest{Limit: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, []dto.APIKeyResponse{created.APIKeyResponse}, resp.APIKeys)
+}
+
+func TestAPIKeyService_VerifyAPIKey(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		svc := NewAPIKeyService(&MockAPIKeyRepository{}, 0)
+		created := createTestAPIKey(t, svc, nil)
+
+		claims, err := svc.VerifyAPIKey(context.Background(), created.Key)
+		assert.NoError(t, err)
+		assert.Equal(t, "apikey:"+created.ID, claims.Subject)
+		assert.Equal(t, "acme", claims.Tenant)
+		assert.True(t, claims.HasScopes("devices:write"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		svc := NewAPIKeyService(&MockAPIKeyRepository{}, 0)
+		created := createTestAPIKey(t, svc, nil)
+
+		for _, key := range []string{"malformed", "unknown.secret", created.ID + ".wrong-secret"} {
+			_, err := svc.VerifyAPIKey(context.Background(), key)
+			assert.ErrorIs(t, err, auth.ErrInvalidAPIKey, key)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		svc := NewAPIKeyService(&MockAPIKeyRepository{}, 0)
+
+		expiresAt := time.Now().Add(time.Hour)
+		created := createTestAPIKey(t, svc, &expiresAt)
+
+		svc.now = func() time.Time { return expiresAt.Add(time.Second) }
+
+		_, err := svc.VerifyAPIKey(context.Background(), created.Key)
+		assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+	})
+
+	t.Run("db_error", func(t *testing.T) {
+		svc := NewAPIKeyService(&MockAPIKeyRepository{err: ErrMockDB}, 0)
+
+		_, err := svc.VerifyAPIKey(context.Background(), "id.secret")
+		assert.ErrorIs(t, err, ErrMockDB)
+		assert.NotErrorIs(t, err, auth.ErrInvalidAPIKey)
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		mockRepo := &MockAPIKeyRepository{}
+		svc := NewAPIKeyService(mockRepo, time.Minute)
+		created := createTestAPIKey(t, svc, nil)
+
+		for range 3 {
+			_, err := svc.VerifyAPIKey(context.Background(), created.Key)
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, 1, mockRepo.getCalls)
+
+		now := time.Now()
+		svc.now = func() time.Time { return now.Add(2 * time.Minute) }
+
+		_, err := svc.VerifyAPIKey(context.Background(), created.Key)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, mockRepo.getCalls)
+	})
+}
+
+func TestAPIKeyService_RotateAPIKey(t *testing.T) {
+	svc := NewAPIKeyService(&MockAPIKeyRepository{}, time.Minute)
+	created := createTestAPIKey(t, svc, nil)
+
+	_, err := svc.VerifyAPIKey(context.Background(), created.Key)
+	assert.NoError(t, err)
+
+	rotated, err := svc.RotateAPIKey(context.Background(), dto.RotateAPIKeyRequest{ID: created.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, rotated.ID)
+	assert.NotEqual(t, created.Key, rotated.Key)
+	assert.NotNil(t, rotated.RotatedAt)
+
+	// the cached previous key stops working immediately
+	_, err = svc.VerifyAPIKey(context.Background(), created.Key)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	_, err = svc.VerifyAPIKey(context.Background(), rotated.Key)
+	assert.NoError(t, err)
+
+	_, err = svc.RotateAPIKey(context.Background(), dto.RotateAPIKeyRequest{ID: "unknown"})
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+}
+
+func TestAPIKeyService_RevokeAPIKey(t *testing.T) {
+	svc := NewAPIKeyService(&MockAPIKeyRepository{}, time.Minute)
+	created := createTestAPIKey(t, svc, nil)
+
+	_, err := svc.VerifyAPIKey(context.Background(), created.Key)
+	assert.NoError(t, err)
+
+	assert.NoError(t, svc.RevokeAPIKey(context.Background(), dto.RevokeAPIKeyRequest{ID: created.ID}))
+
+	_, err = svc.VerifyAPIKey(context.Background(), created.Key)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	err = svc.RevokeAPIKey(context.Background(), dto.RevokeAPIKeyRequest{ID: created.ID})
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+}
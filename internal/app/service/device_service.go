@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/ijalalfrz/go-serverless/internal/app/dto"
 	"github.com/ijalalfrz/go-serverless/internal/app/model"
@@ -13,14 +16,29 @@ import (
 type DeviceRepository interface {
 	Create(ctx context.Context, device model.Device) error
 	GetByID(ctx context.Context, id string) (model.Device, error)
+	GetBySerial(ctx context.Context, serial string) (model.Device, error)
+	List(ctx context.Context, limit int32, cursor string) ([]model.Device, string, error)
+	ListByModel(ctx context.Context, deviceModel string, limit int32, cursor string) ([]model.Device, string, error)
+	Update(ctx context.Context, device model.Device, previous model.Device) (model.Device, error)
+	SoftDelete(ctx context.Context, id string, deletedBy string, deletedAt time.Time, expiresAt time.Time) error
+	Restore(ctx context.Context, id string) (model.Device, error)
+	Delete(ctx context.Context, id string) error
+	BatchCreate(ctx context.Context, devices []model.Device) []error
+	BatchGet(ctx context.Context, ids []string) ([]model.Device, []error)
 }
 
 type DeviceService struct {
-	deviceRepo DeviceRepository
+	deviceRepo          DeviceRepository
+	softDeleteRetention time.Duration
+	now                 func() time.Time
 }
 
-func NewDeviceService(deviceRepo DeviceRepository) *DeviceService {
-	return &DeviceService{deviceRepo: deviceRepo}
+func NewDeviceService(deviceRepo DeviceRepository, softDeleteRetention time.Duration) *DeviceService {
+	return &DeviceService{
+		deviceRepo:          deviceRepo,
+		softDeleteRetention: softDeleteRetention,
+		now:                 time.Now,
+	}
 }
 
 // CreateDevice godoc
@@ -30,10 +48,17 @@ func NewDeviceService(deviceRepo DeviceRepository) *DeviceService {
 // @ID           createDevice
 // @Produce      json
 // @Param        req body create device	body		dto.CreateDeviceRequest	true	"Device"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
 // @Success      201  "Created"
 // @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
 // @Failure      409  {object}  dto.ErrorResponse	"Conflict"
+// @Failure      422  {object}  dto.ErrorResponse	"Unknown device model"
 // @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /api/devices [post].
 func (s *DeviceService) CreateDevice(ctx context.Context, req dto.CreateDeviceRequest) error {
 	device := model.Device{
@@ -42,29 +67,150 @@ func (s *DeviceService) CreateDevice(ctx context.Context, req dto.CreateDeviceRe
 		Name:        req.Name,
 		Note:        req.Note,
 		Serial:      req.Serial,
+		Version:     1,
 	}
 
-	existingDevice, err := s.deviceRepo.GetByID(ctx, req.ID)
-	if err != nil && !errors.Is(err, exception.ErrRecordNotFound) {
-		return fmt.Errorf("failed to get device: %w", err)
+	if err := s.deviceRepo.Create(ctx, device); err != nil {
+		return fmt.Errorf("failed to create device: %w", err)
 	}
 
-	// If device exists (no error and ID is not empty)
-	if err == nil && existingDevice.ID != "" {
-		err := exception.ErrConflict
-		err.MessageVars = map[string]interface{}{
-			"name": "device",
+	return nil
+}
+
+// BatchCreateDevices godoc
+// @Summary      Batch Create Devices
+// @Description  Create up to 500 Devices, each entry reports its own status and localized error
+// @Tags         Device
+// @ID           batchCreateDevices
+// @Produce      json
+// @Param        req body batch create devices	body		dto.BatchCreateDevicesRequest	true	"Devices"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      200  {object}  dto.BatchDevicesResponse	"OK"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devices:batchCreate [post].
+func (s *DeviceService) BatchCreateDevices(
+	ctx context.Context,
+	req dto.BatchCreateDevicesRequest,
+) (dto.BatchDevicesResponse, error) {
+	resp := dto.BatchDevicesResponse{Results: make([]dto.BatchDeviceResult, len(req.Devices))}
+	devices := make([]model.Device, 0, len(req.Devices))
+	indexes := make([]int, 0, len(req.Devices))
+
+	for i, entry := range req.Devices {
+		resp.Results[i] = dto.BatchDeviceResult{Index: i, ID: entry.ID}
+
+		if err := entry.Validate(); err != nil {
+			setBatchError(ctx, &resp.Results[i], err)
+
+			continue
 		}
-		err.UICode = exception.DeviceAlreadyExist
 
-		return err
+		devices = append(devices, model.Device{
+			ID:          entry.ID,
+			DeviceModel: entry.DeviceModel,
+			Name:        entry.Name,
+			Note:        entry.Note,
+			Serial:      entry.Serial,
+			Version:     1,
+		})
+		indexes = append(indexes, i)
 	}
 
-	if err := s.deviceRepo.Create(ctx, device); err != nil {
-		return fmt.Errorf("failed to create device: %w", err)
+	if len(devices) == 0 {
+		return resp, nil
 	}
 
-	return nil
+	for j, err := range s.deviceRepo.BatchCreate(ctx, devices) {
+		result := &resp.Results[indexes[j]]
+
+		if err != nil {
+			setBatchError(ctx, result, fmt.Errorf("failed to create device: %w", err))
+
+			continue
+		}
+
+		device := toDeviceResponse(devices[j])
+		result.Status = http.StatusCreated
+		result.Device = &device
+	}
+
+	return resp, nil
+}
+
+// BatchGetDevices godoc
+// @Summary      Batch Get Devices
+// @Description  Get up to 500 Devices by ID, each entry reports its own status and localized error
+// @Tags         Device
+// @ID           batchGetDevices
+// @Produce      json
+// @Param        req body batch get devices	body		dto.BatchGetDevicesRequest	true	"Device IDs"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      200  {object}  dto.BatchDevicesResponse	"OK"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devices:batchGet [post].
+func (s *DeviceService) BatchGetDevices(
+	ctx context.Context,
+	req dto.BatchGetDevicesRequest,
+) (dto.BatchDevicesResponse, error) {
+	resp := dto.BatchDevicesResponse{Results: make([]dto.BatchDeviceResult, len(req.IDs))}
+	ids := make([]string, 0, len(req.IDs))
+	indexes := make([]int, 0, len(req.IDs))
+
+	for i, id := range req.IDs {
+		resp.Results[i] = dto.BatchDeviceResult{Index: i, ID: id}
+
+		if strings.TrimPrefix(id, "/devices/") == "" {
+			setBatchError(ctx, &resp.Results[i],
+				dto.NewInvalidRequestError(errors.New("device id is required"), dto.RequiredDeviceID))
+
+			continue
+		}
+
+		ids = append(ids, id)
+		indexes = append(indexes, i)
+	}
+
+	if len(ids) == 0 {
+		return resp, nil
+	}
+
+	devices, errs := s.deviceRepo.BatchGet(ctx, ids)
+
+	for j, err := range errs {
+		result := &resp.Results[indexes[j]]
+
+		if err != nil {
+			setBatchError(ctx, result, fmt.Errorf("failed to get device: %w", err))
+
+			continue
+		}
+
+		device := toDeviceResponse(devices[j])
+		result.Status = http.StatusOK
+		result.Device = &device
+	}
+
+	return resp, nil
+}
+
+// setBatchError reports err as the outcome of a batch entry.
+func setBatchError(ctx context.Context, result *dto.BatchDeviceResult, err error) {
+	status, errResp := dto.NewErrorResponse(ctx, err)
+
+	result.Status = status
+	result.Error = &errResp
 }
 
 // GetDeviceByID godoc
@@ -74,10 +220,16 @@ func (s *DeviceService) CreateDevice(ctx context.Context, req dto.CreateDeviceRe
 // @ID           getDeviceByID
 // @Produce      json
 // @Param        id path string true "Device ID"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
 // @Success      200  {object}  dto.DeviceResponse	"OK"
+// @Header       200  {string}  ETag	"Device version"
 // @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
 // @Failure      404  {object}  dto.ErrorResponse	"Record not found"
 // @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /api/devices/{id} [get].
 func (s *DeviceService) GetDeviceByID(ctx context.Context, req dto.GetDeviceByIDRequest) (dto.DeviceResponse, error) {
 	device, err := s.deviceRepo.GetByID(ctx, req.ID)
@@ -85,11 +237,316 @@ func (s *DeviceService) GetDeviceByID(ctx context.Context, req dto.GetDeviceByID
 		return dto.DeviceResponse{}, fmt.Errorf("failed to get device: %w", err)
 	}
 
+	return toDeviceResponse(device), nil
+}
+
+// UpdateDevice godoc
+// @Summary      Update Device
+// @Description  Replace the mutable attributes of a Device
+// @Tags         Device
+// @ID           updateDevice
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Device ID"
+// @Param        If-Match header string false "ETag of the version being replaced"
+// @Param        req body dto.UpdateDeviceRequest true "Device"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      200  {object}  dto.DeviceResponse	"OK"
+// @Header       200  {string}  ETag	"Device version"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      404  {object}  dto.ErrorResponse	"Record not found"
+// @Failure      409  {object}  dto.ErrorResponse	"Serial already exist"
+// @Failure      412  {object}  dto.ErrorResponse	"Precondition Failed"
+// @Failure      422  {object}  dto.ErrorResponse	"Unknown device model"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devices/{id} [put].
+func (s *DeviceService) UpdateDevice(ctx context.Context, req dto.UpdateDeviceRequest) (dto.DeviceResponse, error) {
+	return s.updateDevice(ctx, req.ID, req.IfMatch, func(device *model.Device) {
+		device.DeviceModel = req.DeviceModel
+		device.Name = req.Name
+		device.Note = req.Note
+		device.Serial = req.Serial
+	})
+}
+
+// PatchDevice godoc
+// @Summary      Patch Device
+// @Description  Partially update a Device using JSON Merge Patch
+// @Tags         Device
+// @ID           patchDevice
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        id path string true "Device ID"
+// @Param        If-Match header string false "ETag of the version being patched"
+// @Param        req body dto.PatchDeviceRequest true "Merge patch"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      200  {object}  dto.DeviceResponse	"OK"
+// @Header       200  {string}  ETag	"Device version"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      404  {object}  dto.ErrorResponse	"Record not found"
+// @Failure      409  {object}  dto.ErrorResponse	"Serial already exist"
+// @Failure      412  {object}  dto.ErrorResponse	"Precondition Failed"
+// @Failure      422  {object}  dto.ErrorResponse	"Unknown device model"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devices/{id} [patch].
+func (s *DeviceService) PatchDevice(ctx context.Context, req dto.PatchDeviceRequest) (dto.DeviceResponse, error) {
+	return s.updateDevice(ctx, req.ID, req.IfMatch, func(device *model.Device) {
+		if req.DeviceModel != nil {
+			device.DeviceModel = *req.DeviceModel
+		}
+
+		if req.Name != nil {
+			device.Name = *req.Name
+		}
+
+		if req.Note != nil {
+			device.Note = *req.Note
+		}
+
+		if req.Serial != nil {
+			device.Serial = *req.Serial
+		}
+	})
+}
+
+// updateDevice applies the change on the current device, the write is rejected
+// when the device was modified after it has been read.
+func (s *DeviceService) updateDevice(
+	ctx context.Context,
+	id string,
+	ifMatch string,
+	apply func(device *model.Device),
+) (dto.DeviceResponse, error) {
+	device, err := s.deviceRepo.GetByID(ctx, id)
+	if err != nil {
+		return dto.DeviceResponse{}, fmt.Errorf("failed to get device: %w", err)
+	}
+
+	if !dto.IfMatch(ifMatch, device.Version) {
+		return dto.DeviceResponse{}, exception.DeviceVersionStaleError()
+	}
+
+	previous := device
+	apply(&device)
+
+	updated, err := s.deviceRepo.Update(ctx, device, previous)
+	if err != nil {
+		return dto.DeviceResponse{}, fmt.Errorf("failed to update device: %w", err)
+	}
+
+	return toDeviceResponse(updated), nil
+}
+
+// DeleteDevice godoc
+// @Summary      Delete Device
+// @Description  Soft delete a Device, or remove it permanently with hard=true
+// @Tags         Device
+// @ID           deleteDevice
+// @Produce      json
+// @Param        id path string true "Device ID"
+// @Param        hard query bool false "Remove the device permanently"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      204  "No Content"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      404  {object}  dto.ErrorResponse	"Record not found"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devices/{id} [delete].
+func (s *DeviceService) DeleteDevice(ctx context.Context, req dto.DeleteDeviceRequest) error {
+	if req.Hard {
+		if err := s.deviceRepo.Delete(ctx, req.ID); err != nil {
+			return fmt.Errorf("failed to delete device: %w", err)
+		}
+
+		return nil
+	}
+
+	var (
+		deletedAt = s.now()
+		expiresAt time.Time
+	)
+
+	if s.softDeleteRetention > 0 {
+		expiresAt = deletedAt.Add(s.softDeleteRetention)
+	}
+
+	if err := s.deviceRepo.SoftDelete(ctx, req.ID, req.DeletedBy, deletedAt, expiresAt); err != nil {
+		return fmt.Errorf("failed to soft delete device: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreDevice godoc
+// @Summary      Restore Device
+// @Description  Restore a soft deleted Device
+// @Tags         Device
+// @ID           restoreDevice
+// @Produce      json
+// @Param        id path string true "Device ID"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      200  {object}  dto.DeviceResponse	"OK"
+// @Header       200  {string}  ETag	"Device version"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      404  {object}  dto.ErrorResponse	"Record not found"
+// @Failure      409  {object}  dto.ErrorResponse	"Serial already exist"
+// @Failure      422  {object}  dto.ErrorResponse	"Unknown device model"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devices/{id}:restore [post].
+func (s *DeviceService) RestoreDevice(ctx context.Context, req dto.RestoreDeviceRequest) (dto.DeviceResponse, error) {
+	device, err := s.deviceRepo.Restore(ctx, req.ID)
+	if err != nil {
+		return dto.DeviceResponse{}, fmt.Errorf("failed to restore device: %w", err)
+	}
+
+	return toDeviceResponse(device), nil
+}
+
+// ListDevices godoc
+// @Summary      List Devices
+// @Description  List Devices using cursor pagination
+// @Tags         Device
+// @ID           listDevices
+// @Produce      json
+// @Param        limit query int false "Page size (1-100)" default(20)
+// @Param        cursor query string false "Cursor returned as nextCursor by the previous page"
+// @Param        serial query string false "Only return the device owning this serial"
+// @Param        deviceModel query string false "Only return the devices of this device model"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Success      200  {object}  dto.ListDevicesResponse	"OK"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devices [get].
+func (s *DeviceService) ListDevices(ctx context.Context, req dto.ListDevicesRequest) (dto.ListDevicesResponse, error) {
+	if req.Serial != "" {
+		return s.findDeviceBySerial(ctx, req.Serial, req.DeviceModel)
+	}
+
+	if req.DeviceModel != "" {
+		return s.listDevicesByModel(ctx, req.DeviceModel, req.Limit, req.Cursor)
+	}
+
+	devices, nextCursor, err := s.deviceRepo.List(ctx, req.Limit, req.Cursor)
+	if err != nil {
+		return dto.ListDevicesResponse{}, fmt.Errorf("failed to list devices: %w", err)
+	}
+
+	return toListDevicesResponse(devices, nextCursor), nil
+}
+
+// ListDevicesByModel godoc
+// @Summary      List Devices By Device Model
+// @Description  List the Devices of a Device Model using cursor pagination, unknown Device Models have no Devices
+// @Tags         DeviceModel
+// @ID           listDevicesByModel
+// @Produce      json
+// @Param        id path string true "Device Model ID"
+// @Param        limit query int false "Page size (1-100)" default(20)
+// @Param        cursor query string false "Cursor returned as nextCursor by the previous page"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Success      200  {object}  dto.ListDevicesResponse	"OK"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devicemodels/{id}/devices [get].
+func (s *DeviceService) ListDevicesByModel(
+	ctx context.Context,
+	req dto.ListDevicesByModelRequest,
+) (dto.ListDevicesResponse, error) {
+	deviceModel := "/devicemodels/" + strings.TrimPrefix(req.ID, "/devicemodels/")
+
+	return s.listDevicesByModel(ctx, deviceModel, req.Limit, req.Cursor)
+}
+
+func (s *DeviceService) listDevicesByModel(
+	ctx context.Context,
+	deviceModel string,
+	limit int32,
+	cursor string,
+) (dto.ListDevicesResponse, error) {
+	devices, nextCursor, err := s.deviceRepo.ListByModel(ctx, deviceModel, limit, cursor)
+	if err != nil {
+		return dto.ListDevicesResponse{}, fmt.Errorf("failed to list devices by model: %w", err)
+	}
+
+	return toListDevicesResponse(devices, nextCursor), nil
+}
+
+func toListDevicesResponse(devices []model.Device, nextCursor string) dto.ListDevicesResponse {
+	resp := dto.ListDevicesResponse{
+		Devices:    make([]dto.DeviceResponse, 0, len(devices)),
+		NextCursor: nextCursor,
+	}
+
+	for _, device := range devices {
+		resp.Devices = append(resp.Devices, toDeviceResponse(device))
+	}
+
+	return resp
+}
+
+// findDeviceBySerial returns a single page holding the device owning serial, if any.
+// A non-empty deviceModel additionally requires the device to be of that model.
+func (s *DeviceService) findDeviceBySerial(
+	ctx context.Context,
+	serial string,
+	deviceModel string,
+) (dto.ListDevicesResponse, error) {
+	resp := dto.ListDevicesResponse{
+		Devices: []dto.DeviceResponse{},
+	}
+
+	device, err := s.deviceRepo.GetBySerial(ctx, serial)
+	if errors.Is(err, exception.ErrRecordNotFound) {
+		return resp, nil
+	}
+
+	if err != nil {
+		return dto.ListDevicesResponse{}, fmt.Errorf("failed to get device by serial: %w", err)
+	}
+
+	if deviceModel != "" && device.DeviceModel != deviceModel {
+		return resp, nil
+	}
+
+	resp.Devices = append(resp.Devices, toDeviceResponse(device))
+
+	return resp, nil
+}
+
+func toDeviceResponse(device model.Device) dto.DeviceResponse {
 	return dto.DeviceResponse{
 		ID:          device.ID,
 		DeviceModel: device.DeviceModel,
 		Name:        device.Name,
 		Note:        device.Note,
 		Serial:      device.Serial,
-	}, nil
+		Version:     device.Version,
+	}
 }
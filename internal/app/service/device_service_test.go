@@ -4,7 +4,9 @@ package service
 
 import (
 	"context"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/ijalalfrz/go-serverless/internal/app/dto"
 	"github.com/ijalalfrz/go-serverless/internal/app/model"
@@ -15,7 +17,7 @@ import (
 func TestDeviceService_CreateDevice(t *testing.T) {
 	createDeviceRequest := func(name string, req dto.CreateDeviceRequest, mockRepo *MockDeviceRepository, wantErr error) func(t *testing.T) {
 		return func(t *testing.T) {
-			svc := NewDeviceService(mockRepo)
+			svc := NewDeviceService(mockRepo, testRetention)
 			err := svc.CreateDevice(context.Background(), req)
 			if wantErr != nil {
 				assert.ErrorIs(t, err, wantErr)
@@ -64,8 +66,8 @@ func TestDeviceService_CreateDevice(t *testing.T) {
 		exception.ErrConflict,
 	))
 
-	t.Run("get_by_id_error", createDeviceRequest(
-		"get_by_id_error",
+	t.Run("create_error", createDeviceRequest(
+		"create_error",
 		dto.CreateDeviceRequest{
 			ID:          "device-3",
 			DeviceModel: "Model Z",
@@ -73,21 +75,21 @@ func TestDeviceService_CreateDevice(t *testing.T) {
 			Note:        "Test Note 3",
 			Serial:      "SN003",
 		},
-		&MockDeviceRepository{devices: mockDevices, getByIDErr: ErrMockDB},
+		&MockDeviceRepository{devices: mockDevices, createErr: ErrMockDB},
 		ErrMockDB,
 	))
 
-	t.Run("create_error_after_check", createDeviceRequest(
-		"create_error_after_check",
+	t.Run("unknown_device_model", createDeviceRequest(
+		"unknown_device_model",
 		dto.CreateDeviceRequest{
 			ID:          "device-3",
-			DeviceModel: "Model Z",
+			DeviceModel: "/devicemodels/unknown",
 			Name:        "Test Device 3",
 			Note:        "Test Note 3",
 			Serial:      "SN003",
 		},
-		&MockDeviceRepository{devices: mockDevices, createErr: ErrMockDB},
-		ErrMockDB,
+		&MockDeviceRepository{devices: mockDevices, createErr: exception.ErrReferenceNotFound},
+		exception.ErrReferenceNotFound,
 	))
 
 	t.Run("empty_request_fields", createDeviceRequest(
@@ -107,7 +109,7 @@ func TestDeviceService_CreateDevice(t *testing.T) {
 func TestDeviceService_GetDeviceByID(t *testing.T) {
 	getDeviceByIDRequest := func(name string, req dto.GetDeviceByIDRequest, mockRepo *MockDeviceRepository, want dto.DeviceResponse, wantErr error) func(t *testing.T) {
 		return func(t *testing.T) {
-			svc := NewDeviceService(mockRepo)
+			svc := NewDeviceService(mockRepo, testRetention)
 			got, err := svc.GetDeviceByID(context.Background(), req)
 			if wantErr != nil {
 				assert.ErrorIs(t, err, wantErr)
@@ -128,6 +130,7 @@ func TestDeviceService_GetDeviceByID(t *testing.T) {
 			Name:        mockDevices[0].Name,
 			Note:        mockDevices[0].Note,
 			Serial:      mockDevices[0].Serial,
+			Version:     mockDevices[0].Version,
 		},
 		nil,
 	))
@@ -165,17 +168,440 @@ func TestDeviceService_GetDeviceByID(t *testing.T) {
 	))
 }
 
+func TestDeviceService_UpdateDevice(t *testing.T) {
+	updateDeviceRequest := func(name string, req dto.UpdateDeviceRequest, mockRepo *MockDeviceRepository, want dto.DeviceResponse, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			svc := NewDeviceService(mockRepo, testRetention)
+			got, err := svc.UpdateDevice(context.Background(), req)
+			if wantErr != nil {
+				assert.ErrorIs(t, err, wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+	}
+
+	req := dto.UpdateDeviceRequest{
+		ID:          "device-1",
+		DeviceModel: "/devicemodels/model-z",
+		Name:        "Updated Device",
+		Note:        "Updated Note",
+		Serial:      "SN001",
+	}
+	updated := dto.DeviceResponse{
+		ID:          "device-1",
+		DeviceModel: "/devicemodels/model-z",
+		Name:        "Updated Device",
+		Note:        "Updated Note",
+		Serial:      "SN001",
+		Version:     2,
+	}
+
+	withIfMatch := func(ifMatch string) dto.UpdateDeviceRequest {
+		r := req
+		r.IfMatch = ifMatch
+
+		return r
+	}
+
+	t.Run("success_without_if_match", updateDeviceRequest(
+		"success_without_if_match",
+		req,
+		&MockDeviceRepository{devices: copyDevices(mockDevices)},
+		updated,
+		nil,
+	))
+
+	t.Run("success_with_matching_if_match", updateDeviceRequest(
+		"success_with_matching_if_match",
+		withIfMatch(`"1"`),
+		&MockDeviceRepository{devices: copyDevices(mockDevices)},
+		updated,
+		nil,
+	))
+
+	t.Run("stale_if_match", updateDeviceRequest(
+		"stale_if_match",
+		withIfMatch(`"0"`),
+		&MockDeviceRepository{devices: copyDevices(mockDevices)},
+		dto.DeviceResponse{},
+		exception.ErrPreconditionFailed,
+	))
+
+	t.Run("weak_if_match", updateDeviceRequest(
+		"weak_if_match",
+		withIfMatch(`W/"1"`),
+		&MockDeviceRepository{devices: copyDevices(mockDevices)},
+		dto.DeviceResponse{},
+		exception.ErrPreconditionFailed,
+	))
+
+	t.Run("concurrent_modification", updateDeviceRequest(
+		"concurrent_modification",
+		req,
+		&MockDeviceRepository{devices: copyDevices(mockDevices), updateErr: exception.ErrPreconditionFailed},
+		dto.DeviceResponse{},
+		exception.ErrPreconditionFailed,
+	))
+
+	t.Run("not_found", updateDeviceRequest(
+		"not_found",
+		dto.UpdateDeviceRequest{ID: "non-existent"},
+		&MockDeviceRepository{devices: copyDevices(mockDevices)},
+		dto.DeviceResponse{},
+		exception.ErrRecordNotFound,
+	))
+}
+
+func TestDeviceService_PatchDevice(t *testing.T) {
+	patchDeviceRequest := func(name string, req dto.PatchDeviceRequest, mockRepo *MockDeviceRepository, want dto.DeviceResponse, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			svc := NewDeviceService(mockRepo, testRetention)
+			got, err := svc.PatchDevice(context.Background(), req)
+			if wantErr != nil {
+				assert.ErrorIs(t, err, wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+	}
+
+	name := "Patched Device"
+
+	t.Run("patch_only_given_members", patchDeviceRequest(
+		"patch_only_given_members",
+		dto.PatchDeviceRequest{ID: "device-2", IfMatch: `"3"`, Name: &name},
+		&MockDeviceRepository{devices: copyDevices(mockDevices)},
+		dto.DeviceResponse{
+			ID:          mockDevices[1].ID,
+			DeviceModel: mockDevices[1].DeviceModel,
+			Name:        name,
+			Note:        mockDevices[1].Note,
+			Serial:      mockDevices[1].Serial,
+			Version:     4,
+		},
+		nil,
+	))
+
+	t.Run("stale_if_match", patchDeviceRequest(
+		"stale_if_match",
+		dto.PatchDeviceRequest{ID: "device-2", IfMatch: `"2"`, Name: &name},
+		&MockDeviceRepository{devices: copyDevices(mockDevices)},
+		dto.DeviceResponse{},
+		exception.ErrPreconditionFailed,
+	))
+
+	t.Run("db_error", patchDeviceRequest(
+		"db_error",
+		dto.PatchDeviceRequest{ID: "device-2", Name: &name},
+		&MockDeviceRepository{err: ErrMockDB},
+		dto.DeviceResponse{},
+		ErrMockDB,
+	))
+}
+
+func TestDeviceService_DeleteDevice(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("soft_delete", func(t *testing.T) {
+		mockRepo := &MockDeviceRepository{devices: copyDevices(mockDevices)}
+		svc := NewDeviceService(mockRepo, testRetention)
+		svc.now = func() time.Time { return now }
+
+		err := svc.DeleteDevice(context.Background(), dto.DeleteDeviceRequest{ID: "device-1", DeletedBy: "user-1"})
+		assert.NoError(t, err)
+
+		deleted := mockRepo.devices[0]
+		assert.Equal(t, now, *deleted.DeletedAt)
+		assert.Equal(t, "user-1", deleted.DeletedBy)
+		assert.Equal(t, now.Add(testRetention).Unix(), deleted.ExpiresAt)
+	})
+
+	t.Run("soft_delete_without_retention", func(t *testing.T) {
+		mockRepo := &MockDeviceRepository{devices: copyDevices(mockDevices)}
+		svc := NewDeviceService(mockRepo, 0)
+		svc.now = func() time.Time { return now }
+
+		err := svc.DeleteDevice(context.Background(), dto.DeleteDeviceRequest{ID: "device-1"})
+		assert.NoError(t, err)
+		assert.Zero(t, mockRepo.devices[0].ExpiresAt)
+	})
+
+	t.Run("soft_delete_already_deleted", func(t *testing.T) {
+		mockRepo := &MockDeviceRepository{devices: copyDevices(mockDevices)}
+		svc := NewDeviceService(mockRepo, testRetention)
+
+		assert.NoError(t, svc.DeleteDevice(context.Background(), dto.DeleteDeviceRequest{ID: "device-1"}))
+
+		err := svc.DeleteDevice(context.Background(), dto.DeleteDeviceRequest{ID: "device-1"})
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+	})
+
+	t.Run("hard_delete", func(t *testing.T) {
+		mockRepo := &MockDeviceRepository{devices: copyDevices(mockDevices)}
+		svc := NewDeviceService(mockRepo, testRetention)
+
+		err := svc.DeleteDevice(context.Background(), dto.DeleteDeviceRequest{ID: "device-1", Hard: true})
+		assert.NoError(t, err)
+		assert.Len(t, mockRepo.devices, len(mockDevices)-1)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		svc := NewDeviceService(&MockDeviceRepository{devices: copyDevices(mockDevices)}, testRetention)
+
+		err := svc.DeleteDevice(context.Background(), dto.DeleteDeviceRequest{ID: "non-existent", Hard: true})
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+	})
+}
+
+func TestDeviceService_RestoreDevice(t *testing.T) {
+	t.Run("restore_soft_deleted", func(t *testing.T) {
+		mockRepo := &MockDeviceRepository{devices: copyDevices(mockDevices)}
+		svc := NewDeviceService(mockRepo, testRetention)
+
+		assert.NoError(t, svc.DeleteDevice(context.Background(), dto.DeleteDeviceRequest{ID: "device-1"}))
+
+		got, err := svc.RestoreDevice(context.Background(), dto.RestoreDeviceRequest{ID: "device-1"})
+		assert.NoError(t, err)
+		assert.Equal(t, toDeviceResponse(mockDevices[0]), got)
+		assert.Nil(t, mockRepo.devices[0].DeletedAt)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		svc := NewDeviceService(&MockDeviceRepository{devices: copyDevices(mockDevices)}, testRetention)
+
+		_, err := svc.RestoreDevice(context.Background(), dto.RestoreDeviceRequest{ID: "non-existent"})
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+	})
+}
+
+func TestDeviceService_ListDevices(t *testing.T) {
+	listDevicesRequest := func(name string, req dto.ListDevicesRequest, mockRepo *MockDeviceRepository, want dto.ListDevicesResponse, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			svc := NewDeviceService(mockRepo, testRetention)
+			got, err := svc.ListDevices(context.Background(), req)
+			if wantErr != nil {
+				assert.ErrorIs(t, err, wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+	}
+
+	t.Run("first_page", listDevicesRequest(
+		"first_page",
+		dto.ListDevicesRequest{Limit: 1},
+		&MockDeviceRepository{devices: mockDevices},
+		dto.ListDevicesResponse{
+			Devices:    []dto.DeviceResponse{toDeviceResponse(mockDevices[0])},
+			NextCursor: "1",
+		},
+		nil,
+	))
+
+	t.Run("last_page", listDevicesRequest(
+		"last_page",
+		dto.ListDevicesRequest{Limit: 1, Cursor: "1"},
+		&MockDeviceRepository{devices: mockDevices},
+		dto.ListDevicesResponse{
+			Devices: []dto.DeviceResponse{toDeviceResponse(mockDevices[1])},
+		},
+		nil,
+	))
+
+	t.Run("empty", listDevicesRequest(
+		"empty",
+		dto.ListDevicesRequest{Limit: 10},
+		&MockDeviceRepository{},
+		dto.ListDevicesResponse{Devices: []dto.DeviceResponse{}},
+		nil,
+	))
+
+	t.Run("db_error", listDevicesRequest(
+		"db_error",
+		dto.ListDevicesRequest{Limit: 10},
+		&MockDeviceRepository{err: ErrMockDB},
+		dto.ListDevicesResponse{},
+		ErrMockDB,
+	))
+
+	t.Run("by_serial", listDevicesRequest(
+		"by_serial",
+		dto.ListDevicesRequest{Limit: 10, Serial: "SN002"},
+		&MockDeviceRepository{devices: mockDevices},
+		dto.ListDevicesResponse{
+			Devices: []dto.DeviceResponse{toDeviceResponse(mockDevices[1])},
+		},
+		nil,
+	))
+
+	t.Run("by_unknown_serial", listDevicesRequest(
+		"by_unknown_serial",
+		dto.ListDevicesRequest{Limit: 10, Serial: "SN999"},
+		&MockDeviceRepository{devices: mockDevices},
+		dto.ListDevicesResponse{Devices: []dto.DeviceResponse{}},
+		nil,
+	))
+
+	t.Run("by_serial_db_error", listDevicesRequest(
+		"by_serial_db_error",
+		dto.ListDevicesRequest{Limit: 10, Serial: "SN002"},
+		&MockDeviceRepository{err: ErrMockDB},
+		dto.ListDevicesResponse{},
+		ErrMockDB,
+	))
+
+	t.Run("by_device_model", listDevicesRequest(
+		"by_device_model",
+		dto.ListDevicesRequest{Limit: 10, DeviceModel: "Model Y"},
+		&MockDeviceRepository{devices: mockDevices},
+		dto.ListDevicesResponse{
+			Devices: []dto.DeviceResponse{toDeviceResponse(mockDevices[1])},
+		},
+		nil,
+	))
+
+	t.Run("by_serial_of_another_device_model", listDevicesRequest(
+		"by_serial_of_another_device_model",
+		dto.ListDevicesRequest{Limit: 10, Serial: "SN002", DeviceModel: "Model X"},
+		&MockDeviceRepository{devices: mockDevices},
+		dto.ListDevicesResponse{Devices: []dto.DeviceResponse{}},
+		nil,
+	))
+}
+
+func TestDeviceService_ListDevicesByModel(t *testing.T) {
+	devices := []model.Device{
+		{ID: "/devices/id1", DeviceModel: "/devicemodels/m1", Serial: "SN001"},
+		{ID: "/devices/id2", DeviceModel: "/devicemodels/m2", Serial: "SN002"},
+		{ID: "/devices/id3", DeviceModel: "/devicemodels/m1", Serial: "SN003"},
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		svc := NewDeviceService(&MockDeviceRepository{devices: devices}, testRetention)
+
+		got, err := svc.ListDevicesByModel(context.Background(), dto.ListDevicesByModelRequest{ID: "m1", Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, dto.ListDevicesResponse{
+			Devices:    []dto.DeviceResponse{toDeviceResponse(devices[0])},
+			NextCursor: "1",
+		}, got)
+
+		got, err = svc.ListDevicesByModel(context.Background(),
+			dto.ListDevicesByModelRequest{ID: "/devicemodels/m1", Limit: 1, Cursor: got.NextCursor})
+		assert.NoError(t, err)
+		assert.Equal(t, dto.ListDevicesResponse{
+			Devices: []dto.DeviceResponse{toDeviceResponse(devices[2])},
+		}, got)
+	})
+
+	t.Run("unknown_device_model", func(t *testing.T) {
+		svc := NewDeviceService(&MockDeviceRepository{devices: devices}, testRetention)
+
+		got, err := svc.ListDevicesByModel(context.Background(), dto.ListDevicesByModelRequest{ID: "m9", Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, dto.ListDevicesResponse{Devices: []dto.DeviceResponse{}}, got)
+	})
+
+	t.Run("db_error", func(t *testing.T) {
+		svc := NewDeviceService(&MockDeviceRepository{err: ErrMockDB}, testRetention)
+
+		_, err := svc.ListDevicesByModel(context.Background(), dto.ListDevicesByModelRequest{ID: "m1", Limit: 10})
+		assert.ErrorIs(t, err, ErrMockDB)
+	})
+}
+
+func TestDeviceService_BatchCreateDevices(t *testing.T) {
+	entry := func(id string, serial string) dto.CreateDeviceRequest {
+		return dto.CreateDeviceRequest{
+			ID:          id,
+			DeviceModel: "/devicemodels/model-x",
+			Name:        "Batch Device",
+			Note:        "Batch Note",
+			Serial:      serial,
+		}
+	}
+
+	t.Run("per_entry_results", func(t *testing.T) {
+		mockRepo := &MockDeviceRepository{devices: copyDevices(mockDevices)}
+		svc := NewDeviceService(mockRepo, testRetention)
+
+		got, err := svc.BatchCreateDevices(context.Background(), dto.BatchCreateDevicesRequest{
+			Devices: []dto.CreateDeviceRequest{
+				entry("/devices/d3", "SN003"),
+				entry("d4", "SN004"),
+				entry("/devices/d3", "SN005"),
+				entry("/devices/d6", "SN001"),
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Results, 4)
+
+		assert.Equal(t, http.StatusCreated, got.Results[0].Status)
+		assert.Equal(t, "/devices/d3", got.Results[0].Device.ID)
+		assert.Nil(t, got.Results[0].Error)
+
+		assert.Equal(t, http.StatusBadRequest, got.Results[1].Status)
+		assert.Equal(t, dto.InvalidRequestDevicePrefix, got.Results[1].Error.UICode)
+		assert.Nil(t, got.Results[1].Device)
+
+		assert.Equal(t, http.StatusConflict, got.Results[2].Status)
+		assert.Equal(t, http.StatusConflict, got.Results[3].Status)
+
+		for i, result := range got.Results {
+			assert.Equal(t, i, result.Index)
+		}
+
+		assert.Len(t, mockRepo.devices, len(mockDevices)+1)
+	})
+
+	t.Run("db_error", func(t *testing.T) {
+		svc := NewDeviceService(&MockDeviceRepository{err: ErrMockDB}, testRetention)
+
+		got, err := svc.BatchCreateDevices(context.Background(), dto.BatchCreateDevicesRequest{
+			Devices: []dto.CreateDeviceRequest{entry("/devices/d3", "SN003")},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, got.Results[0].Status)
+		assert.Equal(t, exception.InternalServerError, got.Results[0].Error.UICode)
+	})
+}
+
+func TestDeviceService_BatchGetDevices(t *testing.T) {
+	svc := NewDeviceService(&MockDeviceRepository{devices: mockDevices}, testRetention)
+
+	got, err := svc.BatchGetDevices(context.Background(), dto.BatchGetDevicesRequest{
+		IDs: []string{"device-2", "/devices/", "device-9"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, dto.BatchDevicesResponse{
+		Results: []dto.BatchDeviceResult{
+			{Index: 0, ID: "device-2", Status: http.StatusOK, Device: ptr(toDeviceResponse(mockDevices[1]))},
+			{Index: 1, ID: "/devices/", Status: http.StatusBadRequest, Error: got.Results[1].Error},
+			{Index: 2, ID: "device-9", Status: http.StatusNotFound, Error: got.Results[2].Error},
+		},
+	}, got)
+	assert.Equal(t, dto.RequiredDeviceID, got.Results[1].Error.UICode)
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
 func TestDeviceService_NewDeviceService(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockRepo := &MockDeviceRepository{}
-		svc := NewDeviceService(mockRepo)
+		svc := NewDeviceService(mockRepo, testRetention)
 
 		assert.NotNil(t, svc)
 		assert.Equal(t, mockRepo, svc.deviceRepo)
 	})
 
 	t.Run("nil_repository", func(t *testing.T) {
-		svc := NewDeviceService(nil)
+		svc := NewDeviceService(nil, testRetention)
 
 		assert.NotNil(t, svc)
 		assert.Nil(t, svc.deviceRepo)
@@ -185,7 +611,7 @@ func TestDeviceService_NewDeviceService(t *testing.T) {
 func TestDeviceService_ErrorMessages(t *testing.T) {
 	t.Run("create_device_error_message", func(t *testing.T) {
 		mockRepo := &MockDeviceRepository{err: ErrMockDB}
-		svc := NewDeviceService(mockRepo)
+		svc := NewDeviceService(mockRepo, testRetention)
 
 		req := dto.CreateDeviceRequest{
 			ID:          "device-3",
@@ -197,12 +623,12 @@ func TestDeviceService_ErrorMessages(t *testing.T) {
 
 		err := svc.CreateDevice(context.Background(), req)
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to get device")
+		assert.Contains(t, err.Error(), "failed to create device")
 	})
 
 	t.Run("create_device_already_exists_message", func(t *testing.T) {
 		mockRepo := &MockDeviceRepository{devices: mockDevices}
-		svc := NewDeviceService(mockRepo)
+		svc := NewDeviceService(mockRepo, testRetention)
 
 		req := dto.CreateDeviceRequest{
 			ID:          "device-1", // Already exists
@@ -219,7 +645,7 @@ func TestDeviceService_ErrorMessages(t *testing.T) {
 
 	t.Run("create_device_create_error_message", func(t *testing.T) {
 		mockRepo := &MockDeviceRepository{devices: mockDevices, createErr: ErrMockDB}
-		svc := NewDeviceService(mockRepo)
+		svc := NewDeviceService(mockRepo, testRetention)
 
 		req := dto.CreateDeviceRequest{
 			ID:          "device-3",
@@ -236,7 +662,7 @@ func TestDeviceService_ErrorMessages(t *testing.T) {
 
 	t.Run("get_device_error_message", func(t *testing.T) {
 		mockRepo := &MockDeviceRepository{err: ErrMockDB}
-		svc := NewDeviceService(mockRepo)
+		svc := NewDeviceService(mockRepo, testRetention)
 
 		req := dto.GetDeviceByIDRequest{ID: "device-1"}
 
@@ -249,7 +675,7 @@ func TestDeviceService_ErrorMessages(t *testing.T) {
 func TestDeviceService_EdgeCases(t *testing.T) {
 	t.Run("create_device_with_maximum_fields", func(t *testing.T) {
 		mockRepo := &MockDeviceRepository{devices: mockDevices}
-		svc := NewDeviceService(mockRepo)
+		svc := NewDeviceService(mockRepo, testRetention)
 
 		req := dto.CreateDeviceRequest{
 			ID:          "device-max-length-id-123456789012345678901234567890",
@@ -273,7 +699,7 @@ func TestDeviceService_EdgeCases(t *testing.T) {
 			Serial:      "SN@#$%",
 		}
 		mockRepo := &MockDeviceRepository{devices: append(mockDevices, specialDevice)}
-		svc := NewDeviceService(mockRepo)
+		svc := NewDeviceService(mockRepo, testRetention)
 
 		req := dto.GetDeviceByIDRequest{ID: "device-special@#$%"}
 
@@ -287,10 +713,9 @@ func TestDeviceService_EdgeCases(t *testing.T) {
 	})
 
 	t.Run("create_device_duplicate_serial", func(t *testing.T) {
-		// This test verifies that the service only checks for ID uniqueness
-		// Serial uniqueness would be a business rule to implement if needed
-		mockRepo := &MockDeviceRepository{devices: mockDevices}
-		svc := NewDeviceService(mockRepo)
+		// serials are unique, the repository rejects a second device claiming the same serial
+		mockRepo := &MockDeviceRepository{devices: copyDevices(mockDevices)}
+		svc := NewDeviceService(mockRepo, testRetention)
 
 		req := dto.CreateDeviceRequest{
 			ID:          "device-3", // New ID
@@ -301,7 +726,7 @@ func TestDeviceService_EdgeCases(t *testing.T) {
 		}
 
 		err := svc.CreateDevice(context.Background(), req)
-		assert.NoError(t, err) // Should succeed since only ID uniqueness is checked
+		assert.ErrorIs(t, err, exception.ErrConflict)
 	})
 }
 
@@ -317,7 +742,7 @@ func TestDeviceService_ResponseMapping(t *testing.T) {
 		}
 
 		mockRepo := &MockDeviceRepository{devices: []model.Device{completeDevice}}
-		svc := NewDeviceService(mockRepo)
+		svc := NewDeviceService(mockRepo, testRetention)
 
 		req := dto.GetDeviceByIDRequest{ID: "complete-device"}
 
@@ -343,7 +768,7 @@ func TestDeviceService_ResponseMapping(t *testing.T) {
 		}
 
 		mockRepo := &MockDeviceRepository{devices: []model.Device{partialDevice}}
-		svc := NewDeviceService(mockRepo)
+		svc := NewDeviceService(mockRepo, testRetention)
 
 		req := dto.GetDeviceByIDRequest{ID: "partial-device"}
 
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/app/model"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+)
+
+type DeviceModelRepository interface {
+	Create(ctx context.Context, deviceModel model.DeviceModel) error
+	GetByID(ctx context.Context, id string) (model.DeviceModel, error)
+	List(ctx context.Context, limit int32, cursor string) ([]model.DeviceModel, string, error)
+	Update(ctx context.Context, deviceModel model.DeviceModel, expectedVersion int64) (model.DeviceModel, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type DeviceModelService struct {
+	deviceModelRepo DeviceModelRepository
+}
+
+func NewDeviceModelService(deviceModelRepo DeviceModelRepository) *DeviceModelService {
+	return &DeviceModelService{
+		deviceModelRepo: deviceModelRepo,
+	}
+}
+
+// CreateDeviceModel godoc
+// @Summary      Create Device Model
+// @Description  Create a Device Model
+// @Tags         DeviceModel
+// @ID           createDeviceModel
+// @Produce      json
+// @Param        req body create device model	body		dto.CreateDeviceModelRequest	true	"Device Model"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      201  "Created"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      409  {object}  dto.ErrorResponse	"Conflict"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devicemodels [post].
+func (s *DeviceModelService) CreateDeviceModel(ctx context.Context, req dto.CreateDeviceModelRequest) error {
+	deviceModel := model.DeviceModel{
+		ID:           req.ID,
+		Manufacturer: req.Manufacturer,
+		DisplayName:  req.DisplayName,
+		Category:     req.Category,
+		Specs:        req.Specs,
+		Version:      1,
+	}
+
+	if err := s.deviceModelRepo.Create(ctx, deviceModel); err != nil {
+		return fmt.Errorf("failed to create device model: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeviceModelByID godoc
+// @Summary      Get Device Model by ID
+// @Description  Get a Device Model by ID
+// @Tags         DeviceModel
+// @ID           getDeviceModelByID
+// @Produce      json
+// @Param        id path string true "Device Model ID"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Success      200  {object}  dto.DeviceModelResponse	"OK"
+// @Header       200  {string}  ETag	"Device model version"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      404  {object}  dto.ErrorResponse	"Record not found"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devicemodels/{id} [get].
+func (s *DeviceModelService) GetDeviceModelByID(
+	ctx context.Context,
+	req dto.GetDeviceModelByIDRequest,
+) (dto.DeviceModelResponse, error) {
+	deviceModel, err := s.deviceModelRepo.GetByID(ctx, req.ID)
+	if err != nil {
+		return dto.DeviceModelResponse{}, fmt.Errorf("failed to get device model: %w", err)
+	}
+
+	return toDeviceModelResponse(deviceModel), nil
+}
+
+// ListDeviceModels godoc
+// @Summary      List Device Models
+// @Description  List Device Models using cursor pagination
+// @Tags         DeviceModel
+// @ID           listDeviceModels
+// @Produce      json
+// @Param        limit query int false "Page size (1-100)" default(20)
+// @Param        cursor query string false "Cursor returned as nextCursor by the previous page"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Success      200  {object}  dto.ListDeviceModelsResponse	"OK"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devicemodels [get].
+func (s *DeviceModelService) ListDeviceModels(
+	ctx context.Context,
+	req dto.ListDeviceModelsRequest,
+) (dto.ListDeviceModelsResponse, error) {
+	deviceModels, nextCursor, err := s.deviceModelRepo.List(ctx, req.Limit, req.Cursor)
+	if err != nil {
+		return dto.ListDeviceModelsResponse{}, fmt.Errorf("failed to list device models: %w", err)
+	}
+
+	resp := dto.ListDeviceModelsResponse{
+		DeviceModels: make([]dto.DeviceModelResponse, 0, len(deviceModels)),
+		NextCursor:   nextCursor,
+	}
+
+	for _, deviceModel := range deviceModels {
+		resp.DeviceModels = append(resp.DeviceModels, toDeviceModelResponse(deviceModel))
+	}
+
+	return resp, nil
+}
+
+// UpdateDeviceModel godoc
+// @Summary      Update Device Model
+// @Description  Replace the attributes of a Device Model
+// @Tags         DeviceModel
+// @ID           updateDeviceModel
+// @Produce      json
+// @Param        id path string true "Device Model ID"
+// @Param        If-Match header string false "ETag of the device model version being replaced"
+// @Param        req body update device model	body		dto.UpdateDeviceModelRequest	true	"Device Model"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      200  {object}  dto.DeviceModelResponse	"OK"
+// @Header       200  {string}  ETag	"Device model version"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      404  {object}  dto.ErrorResponse	"Record not found"
+// @Failure      412  {object}  dto.ErrorResponse	"Precondition Failed"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devicemodels/{id} [put].
+func (s *DeviceModelService) UpdateDeviceModel(
+	ctx context.Context,
+	req dto.UpdateDeviceModelRequest,
+) (dto.DeviceModelResponse, error) {
+	deviceModel, err := s.deviceModelRepo.GetByID(ctx, req.ID)
+	if err != nil {
+		return dto.DeviceModelResponse{}, fmt.Errorf("failed to get device model: %w", err)
+	}
+
+	if !dto.IfMatch(req.IfMatch, deviceModel.Version) {
+		return dto.DeviceModelResponse{}, exception.DeviceModelVersionStaleError()
+	}
+
+	deviceModel.Manufacturer = req.Manufacturer
+	deviceModel.DisplayName = req.DisplayName
+	deviceModel.Category = req.Category
+	deviceModel.Specs = req.Specs
+
+	updated, err := s.deviceModelRepo.Update(ctx, deviceModel, deviceModel.Version)
+	if err != nil {
+		return dto.DeviceModelResponse{}, fmt.Errorf("failed to update device model: %w", err)
+	}
+
+	return toDeviceModelResponse(updated), nil
+}
+
+// DeleteDeviceModel godoc
+// @Summary      Delete Device Model
+// @Description  Delete a Device Model, refused while devices still reference it
+// @Tags         DeviceModel
+// @ID           deleteDeviceModel
+// @Produce      json
+// @Param        id path string true "Device Model ID"
+// @Param        X-Tenant-Id header string false "Tenant, required unless the credentials carry a tenant, needs the tenants:cross scope when authenticated"
+// @Param        X-Transaction-Id header string false "Idempotency key, retries with the same key replay the first response"
+// @Success      204  "No Content"
+// @Failure      400  {object}  dto.ErrorResponse	"Bad Request"
+// @Failure      401  {object}  dto.ErrorResponse	"Unauthorized"
+// @Failure      403  {object}  dto.ErrorResponse	"Forbidden"
+// @Failure      404  {object}  dto.ErrorResponse	"Record not found"
+// @Failure      409  {object}  dto.ErrorResponse	"Device model in use"
+// @Failure      500  {object}  dto.ErrorResponse	"Internal Server Error"
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /api/devicemodels/{id} [delete].
+func (s *DeviceModelService) DeleteDeviceModel(ctx context.Context, req dto.DeleteDeviceModelRequest) error {
+	if err := s.deviceModelRepo.Delete(ctx, req.ID); err != nil {
+		return fmt.Errorf("failed to delete device model: %w", err)
+	}
+
+	return nil
+}
+
+func toDeviceModelResponse(deviceModel model.DeviceModel) dto.DeviceModelResponse {
+	return dto.DeviceModelResponse{
+		ID:           deviceModel.ID,
+		Manufacturer: deviceModel.Manufacturer,
+		DisplayName:  deviceModel.DisplayName,
+		Category:     deviceModel.Category,
+		Specs:        deviceModel.Specs,
+		DeviceCount:  deviceModel.DeviceCount,
+		Version:      deviceModel.Version,
+	}
+}
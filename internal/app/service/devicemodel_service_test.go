@@ -0,0 +1,146 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceModelService_CreateDeviceModel(t *testing.T) {
+	req := dto.CreateDeviceModelRequest{
+		ID:           "/devicemodels/model-z",
+		Manufacturer: "Acme",
+		DisplayName:  "Model Z",
+		Category:     "sensor",
+		Specs:        map[string]string{"range": "10m"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := &MockDeviceModelRepository{deviceModels: copyDeviceModels(mockDeviceModels)}
+		svc := NewDeviceModelService(mockRepo)
+
+		assert.NoError(t, svc.CreateDeviceModel(context.Background(), req))
+
+		created, err := mockRepo.GetByID(context.Background(), req.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), created.Version)
+		assert.Equal(t, req.Specs, created.Specs)
+	})
+
+	t.Run("already_exists", func(t *testing.T) {
+		svc := NewDeviceModelService(&MockDeviceModelRepository{deviceModels: copyDeviceModels(mockDeviceModels)})
+
+		existing := req
+		existing.ID = "/devicemodels/model-x"
+
+		err := svc.CreateDeviceModel(context.Background(), existing)
+		assert.ErrorIs(t, err, exception.ErrConflict)
+	})
+
+	t.Run("db_error", func(t *testing.T) {
+		svc := NewDeviceModelService(&MockDeviceModelRepository{err: ErrMockDB})
+
+		err := svc.CreateDeviceModel(context.Background(), req)
+		assert.ErrorIs(t, err, ErrMockDB)
+	})
+}
+
+func TestDeviceModelService_GetDeviceModelByID(t *testing.T) {
+	svc := NewDeviceModelService(&MockDeviceModelRepository{deviceModels: copyDeviceModels(mockDeviceModels)})
+
+	resp, err := svc.GetDeviceModelByID(context.Background(), dto.GetDeviceModelByIDRequest{ID: "/devicemodels/model-x"})
+	assert.NoError(t, err)
+	assert.Equal(t, dto.DeviceModelResponse{
+		ID:           "/devicemodels/model-x",
+		Manufacturer: "Acme",
+		DisplayName:  "Model X",
+		Category:     "sensor",
+		Specs:        map[string]string{"battery": "AA"},
+		DeviceCount:  1,
+		Version:      1,
+	}, resp)
+
+	_, err = svc.GetDeviceModelByID(context.Background(), dto.GetDeviceModelByIDRequest{ID: "/devicemodels/none"})
+	assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+}
+
+func TestDeviceModelService_ListDeviceModels(t *testing.T) {
+	svc := NewDeviceModelService(&MockDeviceModelRepository{deviceModels: copyDeviceModels(mockDeviceModels)})
+
+	resp, err := svc.ListDeviceModels(context.Background(), dto.ListDeviceModelsRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, resp.DeviceModels, 2)
+
+	svc = NewDeviceModelService(&MockDeviceModelRepository{err: ErrMockDB})
+
+	_, err = svc.ListDeviceModels(context.Background(), dto.ListDeviceModelsRequest{Limit: 10})
+	assert.ErrorIs(t, err, ErrMockDB)
+}
+
+func TestDeviceModelService_UpdateDeviceModel(t *testing.T) {
+	req := dto.UpdateDeviceModelRequest{
+		ID:           "/devicemodels/model-y",
+		Manufacturer: "Acme",
+		DisplayName:  "Model Y2",
+		Category:     "gateway",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		svc := NewDeviceModelService(&MockDeviceModelRepository{deviceModels: copyDeviceModels(mockDeviceModels)})
+
+		matching := req
+		matching.IfMatch = `"2"`
+
+		resp, err := svc.UpdateDeviceModel(context.Background(), matching)
+		assert.NoError(t, err)
+		assert.Equal(t, "Model Y2", resp.DisplayName)
+		assert.Equal(t, int64(3), resp.Version)
+	})
+
+	t.Run("stale_if_match", func(t *testing.T) {
+		svc := NewDeviceModelService(&MockDeviceModelRepository{deviceModels: copyDeviceModels(mockDeviceModels)})
+
+		stale := req
+		stale.IfMatch = `"1"`
+
+		_, err := svc.UpdateDeviceModel(context.Background(), stale)
+		assert.ErrorIs(t, err, exception.ErrPreconditionFailed)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		svc := NewDeviceModelService(&MockDeviceModelRepository{})
+
+		_, err := svc.UpdateDeviceModel(context.Background(), req)
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+	})
+}
+
+func TestDeviceModelService_DeleteDeviceModel(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo := &MockDeviceModelRepository{deviceModels: copyDeviceModels(mockDeviceModels)}
+		svc := NewDeviceModelService(mockRepo)
+
+		err := svc.DeleteDeviceModel(context.Background(), dto.DeleteDeviceModelRequest{ID: "/devicemodels/model-y"})
+		assert.NoError(t, err)
+		assert.Len(t, mockRepo.deviceModels, 1)
+	})
+
+	t.Run("in_use", func(t *testing.T) {
+		svc := NewDeviceModelService(&MockDeviceModelRepository{deviceModels: copyDeviceModels(mockDeviceModels)})
+
+		err := svc.DeleteDeviceModel(context.Background(), dto.DeleteDeviceModelRequest{ID: "/devicemodels/model-x"})
+		assert.ErrorIs(t, err, exception.ErrRecordInUse)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		svc := NewDeviceModelService(&MockDeviceModelRepository{})
+
+		err := svc.DeleteDeviceModel(context.Background(), dto.DeleteDeviceModelRequest{ID: "/devicemodels/model-x"})
+		assert.ErrorIs(t, err, exception.ErrRecordNotFound)
+	})
+}
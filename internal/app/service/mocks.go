@@ -5,11 +5,17 @@ package service
 import (
 	"context"
 	"errors"
+	"strconv"
+	"time"
 
 	"github.com/ijalalfrz/go-serverless/internal/app/model"
 	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
 )
 
+// testRetention is the soft delete retention used by the services under test.
+const testRetention = 24 * time.Hour
+
 // Mock errors.
 var (
 	ErrMockDB      = errors.New("mock db error")
@@ -22,6 +28,17 @@ type MockDeviceRepository struct {
 	err        error
 	getByIDErr error
 	createErr  error
+	updateErr  error
+}
+
+func (m *MockDeviceRepository) find(id string) int {
+	for i, device := range m.devices {
+		if device.ID == id {
+			return i
+		}
+	}
+
+	return -1
 }
 
 func (m *MockDeviceRepository) Create(ctx context.Context, device model.Device) error {
@@ -31,10 +48,36 @@ func (m *MockDeviceRepository) Create(ctx context.Context, device model.Device)
 	if m.err != nil {
 		return m.err
 	}
+	// mirror the conditional write of the repository
+	if m.find(device.ID) >= 0 {
+		return exception.ErrConflict
+	}
+	if _, err := m.GetBySerial(ctx, device.Serial); err == nil {
+		return exception.ErrConflict
+	}
 	m.devices = append(m.devices, device)
 	return nil
 }
 
+func (m *MockDeviceRepository) BatchCreate(ctx context.Context, devices []model.Device) []error {
+	errs := make([]error, len(devices))
+	for i, device := range devices {
+		errs[i] = m.Create(ctx, device)
+	}
+
+	return errs
+}
+
+func (m *MockDeviceRepository) BatchGet(ctx context.Context, ids []string) ([]model.Device, []error) {
+	devices := make([]model.Device, len(ids))
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		devices[i], errs[i] = m.GetByID(ctx, id)
+	}
+
+	return devices, errs
+}
+
 func (m *MockDeviceRepository) GetByID(ctx context.Context, id string) (model.Device, error) {
 	if m.getByIDErr != nil {
 		return model.Device{}, m.getByIDErr
@@ -54,6 +97,333 @@ func (m *MockDeviceRepository) GetByID(ctx context.Context, id string) (model.De
 	return model.Device{}, exception.ErrRecordNotFound
 }
 
+func (m *MockDeviceRepository) GetBySerial(ctx context.Context, serial string) (model.Device, error) {
+	if m.err != nil {
+		return model.Device{}, m.err
+	}
+
+	for _, device := range m.devices {
+		if device.Serial == serial && device.DeletedAt == nil {
+			return device, nil
+		}
+	}
+
+	return model.Device{}, exception.ErrRecordNotFound
+}
+
+func (m *MockDeviceRepository) Update(ctx context.Context, device model.Device, previous model.Device) (model.Device, error) {
+	if m.updateErr != nil {
+		return model.Device{}, m.updateErr
+	}
+	if m.err != nil {
+		return model.Device{}, m.err
+	}
+
+	for i, existing := range m.devices {
+		if existing.ID != device.ID {
+			continue
+		}
+
+		if existing.Version != previous.Version {
+			return model.Device{}, exception.ErrPreconditionFailed
+		}
+
+		device.Version = previous.Version + 1
+		m.devices[i] = device
+
+		return device, nil
+	}
+
+	return model.Device{}, exception.ErrRecordNotFound
+}
+
+func (m *MockDeviceRepository) SoftDelete(ctx context.Context, id string, deletedBy string, deletedAt time.Time, expiresAt time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	i := m.find(id)
+	if i < 0 || m.devices[i].DeletedAt != nil {
+		return exception.ErrRecordNotFound
+	}
+
+	m.devices[i].DeletedAt = &deletedAt
+	m.devices[i].DeletedBy = deletedBy
+	if !expiresAt.IsZero() {
+		m.devices[i].ExpiresAt = expiresAt.Unix()
+	}
+
+	return nil
+}
+
+func (m *MockDeviceRepository) Restore(ctx context.Context, id string) (model.Device, error) {
+	if m.err != nil {
+		return model.Device{}, m.err
+	}
+
+	i := m.find(id)
+	if i < 0 {
+		return model.Device{}, exception.ErrRecordNotFound
+	}
+
+	m.devices[i].DeletedAt = nil
+	m.devices[i].DeletedBy = ""
+	m.devices[i].ExpiresAt = 0
+
+	return m.devices[i], nil
+}
+
+func (m *MockDeviceRepository) Delete(ctx context.Context, id string) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	i := m.find(id)
+	if i < 0 {
+		return exception.ErrRecordNotFound
+	}
+
+	m.devices = append(m.devices[:i], m.devices[i+1:]...)
+
+	return nil
+}
+
+func (m *MockDeviceRepository) List(ctx context.Context, limit int32, cursor string) ([]model.Device, string, error) {
+	if m.err != nil {
+		return nil, "", m.err
+	}
+
+	// cursor is the index of the next device in the mock data
+	start := 0
+	if cursor != "" {
+		start, _ = strconv.Atoi(cursor)
+	}
+
+	if start > len(m.devices) {
+		start = len(m.devices)
+	}
+
+	end := start + int(limit)
+	if end >= len(m.devices) {
+		return m.devices[start:], "", nil
+	}
+
+	return m.devices[start:end], strconv.Itoa(end), nil
+}
+
+func (m *MockDeviceRepository) ListByModel(
+	ctx context.Context,
+	deviceModel string,
+	limit int32,
+	cursor string,
+) ([]model.Device, string, error) {
+	if m.err != nil {
+		return nil, "", m.err
+	}
+
+	devices := []model.Device{}
+
+	for _, device := range m.devices {
+		if device.DeviceModel == deviceModel {
+			devices = append(devices, device)
+		}
+	}
+
+	filtered := &MockDeviceRepository{devices: devices}
+
+	return filtered.List(ctx, limit, cursor)
+}
+
+// copyDevices returns a copy of the test data, so tests mutating the mock do not leak into each other.
+func copyDevices(devices []model.Device) []model.Device {
+	return append([]model.Device(nil), devices...)
+}
+
+// MockDeviceModelRepository implements DeviceModelRepository interface.
+type MockDeviceModelRepository struct {
+	deviceModels []model.DeviceModel
+	err          error
+}
+
+func (m *MockDeviceModelRepository) find(id string) int {
+	for i, deviceModel := range m.deviceModels {
+		if deviceModel.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (m *MockDeviceModelRepository) Create(ctx context.Context, deviceModel model.DeviceModel) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if m.find(deviceModel.ID) >= 0 {
+		return exception.ErrConflict
+	}
+
+	m.deviceModels = append(m.deviceModels, deviceModel)
+
+	return nil
+}
+
+func (m *MockDeviceModelRepository) GetByID(ctx context.Context, id string) (model.DeviceModel, error) {
+	if m.err != nil {
+		return model.DeviceModel{}, m.err
+	}
+
+	i := m.find(id)
+	if i < 0 {
+		return model.DeviceModel{}, exception.ErrRecordNotFound
+	}
+
+	return m.deviceModels[i], nil
+}
+
+func (m *MockDeviceModelRepository) List(ctx context.Context, limit int32, cursor string) ([]model.DeviceModel, string, error) {
+	if m.err != nil {
+		return nil, "", m.err
+	}
+
+	return m.deviceModels, "", nil
+}
+
+func (m *MockDeviceModelRepository) Update(ctx context.Context, deviceModel model.DeviceModel, expectedVersion int64) (model.DeviceModel, error) {
+	if m.err != nil {
+		return model.DeviceModel{}, m.err
+	}
+
+	i := m.find(deviceModel.ID)
+	if i < 0 {
+		return model.DeviceModel{}, exception.ErrRecordNotFound
+	}
+
+	if m.deviceModels[i].Version != expectedVersion {
+		return model.DeviceModel{}, exception.ErrPreconditionFailed
+	}
+
+	deviceModel.Version = expectedVersion + 1
+	m.deviceModels[i] = deviceModel
+
+	return deviceModel, nil
+}
+
+func (m *MockDeviceModelRepository) Delete(ctx context.Context, id string) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	i := m.find(id)
+	if i < 0 {
+		return exception.ErrRecordNotFound
+	}
+
+	// mirror the conditional delete of the repository
+	if m.deviceModels[i].DeviceCount > 0 {
+		return exception.ErrRecordInUse
+	}
+
+	m.deviceModels = append(m.deviceModels[:i], m.deviceModels[i+1:]...)
+
+	return nil
+}
+
+// copyDeviceModels returns a copy of the test data, so tests mutating the mock do not leak into each other.
+func copyDeviceModels(deviceModels []model.DeviceModel) []model.DeviceModel {
+	return append([]model.DeviceModel(nil), deviceModels...)
+}
+
+// MockAPIKeyRepository implements APIKeyRepository interface.
+type MockAPIKeyRepository struct {
+	apiKeys  []model.APIKey
+	err      error
+	getCalls int
+}
+
+func (m *MockAPIKeyRepository) find(id string) int {
+	for i, apiKey := range m.apiKeys {
+		if apiKey.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, apiKey model.APIKey) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if m.find(apiKey.ID) >= 0 {
+		return exception.ErrConflict
+	}
+
+	// mirror the repository storing the tenant of the context
+	apiKey.Tenant, _ = tenant.FromContext(ctx)
+	m.apiKeys = append(m.apiKeys, apiKey)
+
+	return nil
+}
+
+func (m *MockAPIKeyRepository) GetByID(ctx context.Context, id string) (model.APIKey, error) {
+	m.getCalls++
+
+	if m.err != nil {
+		return model.APIKey{}, m.err
+	}
+
+	i := m.find(id)
+	if i < 0 {
+		return model.APIKey{}, exception.ErrRecordNotFound
+	}
+
+	return m.apiKeys[i], nil
+}
+
+func (m *MockAPIKeyRepository) List(ctx context.Context, limit int32, cursor string) ([]model.APIKey, string, error) {
+	if m.err != nil {
+		return nil, "", m.err
+	}
+
+	return m.apiKeys, "", nil
+}
+
+func (m *MockAPIKeyRepository) Rotate(ctx context.Context, id string, salt []byte, hash []byte, rotatedAt time.Time) (model.APIKey, error) {
+	if m.err != nil {
+		return model.APIKey{}, m.err
+	}
+
+	// mirror the conditional update of the repository
+	i := m.find(id)
+	if i < 0 || m.apiKeys[i].RevokedAt != nil {
+		return model.APIKey{}, exception.ErrRecordNotFound
+	}
+
+	m.apiKeys[i].Salt = salt
+	m.apiKeys[i].Hash = hash
+	m.apiKeys[i].RotatedAt = &rotatedAt
+
+	return m.apiKeys[i], nil
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	i := m.find(id)
+	if i < 0 || m.apiKeys[i].RevokedAt != nil {
+		return exception.ErrRecordNotFound
+	}
+
+	m.apiKeys[i].RevokedAt = &revokedAt
+
+	return nil
+}
+
 // Test data.
 var mockDevices = []model.Device{
 	{
@@ -62,6 +432,7 @@ var mockDevices = []model.Device{
 		Name:        "Test Device 1",
 		Note:        "Test Note 1",
 		Serial:      "SN001",
+		Version:     1,
 	},
 	{
 		ID:          "device-2",
@@ -69,5 +440,25 @@ var mockDevices = []model.Device{
 		Name:        "Test Device 2",
 		Note:        "Test Note 2",
 		Serial:      "SN002",
+		Version:     3,
+	},
+}
+
+var mockDeviceModels = []model.DeviceModel{
+	{
+		ID:           "/devicemodels/model-x",
+		Manufacturer: "Acme",
+		DisplayName:  "Model X",
+		Category:     "sensor",
+		Specs:        map[string]string{"battery": "AA"},
+		DeviceCount:  1,
+		Version:      1,
+	},
+	{
+		ID:           "/devicemodels/model-y",
+		Manufacturer: "Acme",
+		DisplayName:  "Model Y",
+		Category:     "gateway",
+		Version:      2,
 	},
 }
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+const (
+	apiKeyIDSize     = 8
+	apiKeySecretSize = 32
+	apiKeySaltSize   = 16
+)
+
+// GenerateAPIKey returns a new API key ID and secret, the key presented by clients is
+// FormatAPIKey(id, secret).
+func GenerateAPIKey() (string, string, error) {
+	id := make([]byte, apiKeyIDSize)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key id: %w", err)
+	}
+
+	secret, err := GenerateAPIKeySecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(id), secret, nil
+}
+
+// GenerateAPIKeySecret returns a new random secret, it is used when rotating a key.
+func GenerateAPIKeySecret() (string, error) {
+	secret := make([]byte, apiKeySecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate api key secret: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// FormatAPIKey joins the key ID and secret, the ID lets the key be looked up without
+// storing the secret.
+func FormatAPIKey(id string, secret string) string {
+	return id + "." + secret
+}
+
+// ParseAPIKey splits a key into its ID and secret.
+func ParseAPIKey(key string) (string, string, error) {
+	id, secret, ok := strings.Cut(key, ".")
+	if !ok || id == "" || secret == "" {
+		return "", "", fmt.Errorf("%w: malformed key", ErrInvalidAPIKey)
+	}
+
+	return id, secret, nil
+}
+
+// HashAPIKeySecret returns a random salt and the salted SHA-256 hash of the secret. The
+// secrets are random, so a fast hash is enough to make a leaked hash useless.
+func HashAPIKeySecret(secret string) ([]byte, []byte, error) {
+	salt := make([]byte, apiKeySaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate api key salt: %w", err)
+	}
+
+	return salt, saltedHash(secret, salt), nil
+}
+
+// VerifyAPIKeySecret reports in constant time whether the secret matches the salted hash.
+func VerifyAPIKeySecret(secret string, salt []byte, hash []byte) bool {
+	return subtle.ConstantTimeCompare(saltedHash(secret, salt), hash) == 1
+}
+
+func saltedHash(secret string, salt []byte) []byte {
+	digest := sha256.New()
+	digest.Write(salt)
+	digest.Write([]byte(secret))
+
+	return digest.Sum(nil)
+}
@@ -0,0 +1,36 @@
+//go:build unit
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey(t *testing.T) {
+	id, secret, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.Len(t, id, 2*apiKeyIDSize)
+
+	parsedID, parsedSecret, err := ParseAPIKey(FormatAPIKey(id, secret))
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsedID)
+	assert.Equal(t, secret, parsedSecret)
+
+	salt, hash, err := HashAPIKeySecret(secret)
+	assert.NoError(t, err)
+	assert.True(t, VerifyAPIKeySecret(secret, salt, hash))
+	assert.False(t, VerifyAPIKeySecret(secret+"x", salt, hash))
+
+	// the same secret is hashed with a new salt every time
+	otherSalt, otherHash, err := HashAPIKeySecret(secret)
+	assert.NoError(t, err)
+	assert.NotEqual(t, salt, otherSalt)
+	assert.NotEqual(t, hash, otherHash)
+
+	for _, key := range []string{"", "no-separator", ".secret", "id."} {
+		_, _, err := ParseAPIKey(key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, key)
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"strings"
+	"time"
+)
+
+// NewAuthorizerContext returns the context a Lambda authorizer attaches to the requests it
+// authorizes, API Gateway only passes string, number and boolean values on.
+func NewAuthorizerContext(claims Claims) map[string]interface{} {
+	authorizerContext := map[string]interface{}{
+		"sub":   claims.Subject,
+		"scope": strings.Join(claims.Scopes, " "),
+	}
+
+	if claims.Issuer != "" {
+		authorizerContext["iss"] = claims.Issuer
+	}
+
+	if claims.Tenant != "" {
+		authorizerContext[TenantClaim] = claims.Tenant
+	}
+
+	if !claims.ExpiresAt.IsZero() {
+		authorizerContext["exp"] = claims.ExpiresAt.Unix()
+	}
+
+	return authorizerContext
+}
+
+// NewAuthorizerClaims returns the claims of a request authorized by a Lambda authorizer
+// returning the context of NewAuthorizerContext.
+func NewAuthorizerClaims(authorizerContext map[string]interface{}) Claims {
+	claims := Claims{Raw: authorizerContext}
+	claims.Subject, _ = authorizerContext["sub"].(string)
+	claims.Issuer, _ = authorizerContext["iss"].(string)
+	claims.Tenant, _ = authorizerContext[TenantClaim].(string)
+
+	if scope, ok := authorizerContext["scope"].(string); ok {
+		claims.Scopes = strings.Fields(scope)
+	}
+
+	// numbers are decoded from the request event as float64
+	if exp, ok := authorizerContext["exp"].(float64); ok {
+		claims.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+
+	return claims
+}
@@ -0,0 +1,46 @@
+//go:build unit
+
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizerContext(t *testing.T) {
+	claims := Claims{
+		Subject:   "user-1",
+		Issuer:    "https://issuer.example.com",
+		ExpiresAt: time.Unix(1714564800, 0),
+		Scopes:    []string{"devices:read", "devices:write"},
+		Tenant:    "acme",
+	}
+
+	// the context reaches the API through the JSON request event
+	data, err := json.Marshal(NewAuthorizerContext(claims))
+	assert.NoError(t, err)
+
+	var authorizerContext map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &authorizerContext))
+
+	got := NewAuthorizerClaims(authorizerContext)
+	assert.Equal(t, claims.Subject, got.Subject)
+	assert.Equal(t, claims.Issuer, got.Issuer)
+	assert.Equal(t, claims.Scopes, got.Scopes)
+	assert.Equal(t, claims.Tenant, got.Tenant)
+	assert.True(t, claims.ExpiresAt.Equal(got.ExpiresAt))
+}
+
+func TestBearerToken(t *testing.T) {
+	token, ok := BearerToken("bearer  abc ")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", token)
+
+	for _, header := range []string{"", "Bearer", "Bearer ", "Basic abc"} {
+		_, ok := BearerToken(header)
+		assert.False(t, ok, header)
+	}
+}
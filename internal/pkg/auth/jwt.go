@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// TenantClaim is the claim holding the tenant of the caller.
+const TenantClaim = "tenant_id"
+
+// es256SignatureSize is the size of the R || S signature of ES256 tokens.
+const es256SignatureSize = 64
+
+// Claims holds the claims of a verified token.
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	// Scopes are read from the space separated scope claim, or from the scp claim.
+	Scopes []string
+	// Tenant is read from the tenant_id claim, it is empty for callers of no particular tenant.
+	Tenant string
+	// Raw holds every claim of the token.
+	Raw map[string]interface{}
+}
+
+// HasScopes reports whether all the scopes are granted.
+func (c Claims) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(c.Scopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// BearerToken returns the token of a bearer Authorization header.
+func BearerToken(authorization string) (string, bool) {
+	scheme, token, _ := strings.Cut(authorization, " ")
+	token = strings.TrimSpace(token)
+
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
+
+// NewGatewayClaims returns the claims validated by an API Gateway v2 JWT authorizer, the
+// gateway flattens every claim value to a string.
+func NewGatewayClaims(claims map[string]string, scopes []string) Claims {
+	raw := make(map[string]interface{}, len(claims))
+	for name, value := range claims {
+		raw[name] = value
+	}
+
+	if len(scopes) == 0 {
+		scopes = strings.Fields(claims["scope"])
+	}
+
+	return Claims{
+		Subject:  claims["sub"],
+		Issuer:   claims["iss"],
+		Audience: strings.Fields(strings.Trim(claims["aud"], "[]")),
+		Scopes:   scopes,
+		Tenant:   claims[TenantClaim],
+		Raw:      raw,
+	}
+}
+
+// VerifierConfig holds the claims checked by the verifier, an empty issuer or audience
+// is not checked. ClockSkew is the leeway applied to the exp and nbf claims.
+type VerifierConfig struct {
+	Issuer    string
+	Audience  string
+	ClockSkew time.Duration
+}
+
+// Verifier verifies HS256, RS256 and ES256 signed JSON Web Tokens.
+type Verifier struct {
+	keys []Key
+	cfg  VerifierConfig
+	now  func() time.Time
+}
+
+func NewVerifier(keys []Key, cfg VerifierConfig) *Verifier {
+	return &Verifier{
+		keys: keys,
+		cfg:  cfg,
+		now:  time.Now,
+	}
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Verify checks the signature and the registered claims of a compact serialized token.
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 { //nolint:gomnd
+		return Claims{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, fmt.Errorf("%w: header: %w", ErrInvalidToken, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: signature: %w", ErrInvalidToken, err)
+	}
+
+	if !v.verifySignature(header, []byte(parts[0]+"."+parts[1]), signature) {
+		return Claims{}, fmt.Errorf("%w: signature verification failed", ErrInvalidToken)
+	}
+
+	var raw map[string]interface{}
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return Claims{}, fmt.Errorf("%w: payload: %w", ErrInvalidToken, err)
+	}
+
+	return v.validateClaims(raw)
+}
+
+// verifySignature tries the keys of the token algorithm, the kid header narrows them down
+// to the keys with that ID and the keys without ID.
+func (v *Verifier) verifySignature(header tokenHeader, signed []byte, signature []byte) bool {
+	for _, key := range v.keys {
+		if key.Algorithm != header.Algorithm || (header.KeyID != "" && key.ID != "" && key.ID != header.KeyID) {
+			continue
+		}
+
+		if verifyKey(key, signed, signature) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func verifyKey(key Key, signed []byte, signature []byte) bool {
+	digest := sha256.Sum256(signed)
+
+	switch material := key.material.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, material)
+		mac.Write(signed)
+
+		return hmac.Equal(mac.Sum(nil), signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(material, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		if len(signature) != es256SignatureSize {
+			return false
+		}
+
+		r := new(big.Int).SetBytes(signature[:es256SignatureSize/2])
+		s := new(big.Int).SetBytes(signature[es256SignatureSize/2:])
+
+		return ecdsa.Verify(material, digest[:], r, s)
+	default:
+		return false
+	}
+}
+
+func (v *Verifier) validateClaims(raw map[string]interface{}) (Claims, error) {
+	claims := Claims{Raw: raw}
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Issuer, _ = raw["iss"].(string)
+	claims.Audience = stringList(raw["aud"])
+	claims.Tenant, _ = raw[TenantClaim].(string)
+
+	if scope, ok := raw["scope"].(string); ok {
+		claims.Scopes = strings.Fields(scope)
+	} else {
+		claims.Scopes = stringList(raw["scp"])
+	}
+
+	now := v.now()
+
+	expiresAt, ok := numericDate(raw["exp"])
+	if !ok {
+		return Claims{}, fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+
+	if !now.Before(expiresAt.Add(v.cfg.ClockSkew)) {
+		return Claims{}, ErrTokenExpired
+	}
+
+	claims.ExpiresAt = expiresAt
+
+	if notBefore, ok := numericDate(raw["nbf"]); ok && now.Add(v.cfg.ClockSkew).Before(notBefore) {
+		return Claims{}, fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+
+	if v.cfg.Issuer != "" && claims.Issuer != v.cfg.Issuer {
+		return Claims{}, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+
+	if v.cfg.Audience != "" && !slices.Contains(claims.Audience, v.cfg.Audience) {
+		return Claims{}, fmt.Errorf("%w: audience %q not allowed", ErrInvalidToken, v.cfg.Audience)
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("failed to decode base64: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	return nil
+}
+
+func numericDate(value interface{}) (time.Time, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+// stringList reads a claim which is either a string or an array of strings.
+func stringList(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		list := make([]string, 0, len(value))
+
+		for _, item := range value {
+			if item, ok := item.(string); ok {
+				list = append(list, item)
+			}
+		}
+
+		return list
+	default:
+		return nil
+	}
+}
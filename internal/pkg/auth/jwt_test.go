@@ -0,0 +1,239 @@
+//go:build unit
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func signToken(t *testing.T, alg string, kid string, signer interface{}, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	assert.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+
+	switch signer := signer.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, signer)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, signer, digest[:])
+		assert.NoError(t, err)
+
+		signature = make([]byte, es256SignatureSize)
+		r.FillBytes(signature[:es256SignatureSize/2])
+		s.FillBytes(signature[es256SignatureSize/2:])
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":       "user-1",
+		"iss":       "https://issuer.example.com",
+		"aud":       []string{"devices-api", "other"},
+		"exp":       testNow.Add(time.Hour).Unix(),
+		"nbf":       testNow.Add(-time.Minute).Unix(),
+		"scope":     "devices:read devices:write",
+		"tenant_id": "acme",
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	secret := []byte("secret")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	rsaPublic, err := newPublicKey("rsa-1", &rsaKey.PublicKey)
+	assert.NoError(t, err)
+
+	ecPublic, err := newPublicKey("ec-1", &ecKey.PublicKey)
+	assert.NoError(t, err)
+
+	verifier := NewVerifier([]Key{NewHMACKey(secret), rsaPublic, ecPublic}, VerifierConfig{
+		Issuer:    "https://issuer.example.com",
+		Audience:  "devices-api",
+		ClockSkew: time.Minute,
+	})
+	verifier.now = func() time.Time { return testNow }
+
+	t.Run("algorithms", func(t *testing.T) {
+		for _, token := range []string{
+			signToken(t, AlgorithmHS256, "", secret, validClaims()),
+			signToken(t, AlgorithmRS256, "rsa-1", rsaKey, validClaims()),
+			signToken(t, AlgorithmES256, "ec-1", ecKey, validClaims()),
+		} {
+			claims, err := verifier.Verify(token)
+			assert.NoError(t, err)
+			assert.Equal(t, "user-1", claims.Subject)
+			assert.Equal(t, []string{"devices-api", "other"}, claims.Audience)
+			assert.True(t, claims.HasScopes("devices:read", "devices:write"))
+			assert.False(t, claims.HasScopes("devicemodels:write"))
+		}
+	})
+
+	t.Run("scp_claim", func(t *testing.T) {
+		claims := validClaims()
+		delete(claims, "scope")
+		claims["scp"] = []string{"devices:read"}
+
+		got, err := verifier.Verify(signToken(t, AlgorithmHS256, "", secret, claims))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"devices:read"}, got.Scopes)
+	})
+
+	t.Run("clock_skew", func(t *testing.T) {
+		claims := validClaims()
+		claims["exp"] = testNow.Add(-30 * time.Second).Unix()
+		claims["nbf"] = testNow.Add(30 * time.Second).Unix()
+
+		_, err := verifier.Verify(signToken(t, AlgorithmHS256, "", secret, claims))
+		assert.NoError(t, err)
+	})
+
+	testCases := []struct {
+		name     string
+		token    func() string
+		expected error
+	}{
+		{
+			name: "expired",
+			token: func() string {
+				claims := validClaims()
+				claims["exp"] = testNow.Add(-2 * time.Minute).Unix()
+
+				return signToken(t, AlgorithmHS256, "", secret, claims)
+			},
+			expected: ErrTokenExpired,
+		},
+		{
+			name: "missing_exp",
+			token: func() string {
+				claims := validClaims()
+				delete(claims, "exp")
+
+				return signToken(t, AlgorithmHS256, "", secret, claims)
+			},
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "not_valid_yet",
+			token: func() string {
+				claims := validClaims()
+				claims["nbf"] = testNow.Add(2 * time.Minute).Unix()
+
+				return signToken(t, AlgorithmHS256, "", secret, claims)
+			},
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "wrong_issuer",
+			token: func() string {
+				claims := validClaims()
+				claims["iss"] = "https://evil.example.com"
+
+				return signToken(t, AlgorithmHS256, "", secret, claims)
+			},
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "wrong_audience",
+			token: func() string {
+				claims := validClaims()
+				claims["aud"] = "other"
+
+				return signToken(t, AlgorithmHS256, "", secret, claims)
+			},
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "wrong_secret",
+			token: func() string {
+				return signToken(t, AlgorithmHS256, "", []byte("other"), validClaims())
+			},
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "unknown_kid",
+			token: func() string {
+				return signToken(t, AlgorithmRS256, "rsa-2", rsaKey, validClaims())
+			},
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "algorithm_confusion",
+			token: func() string {
+				// an RS256 public key must not be usable as HMAC secret
+				return signToken(t, AlgorithmHS256, "rsa-1", rsaKey.PublicKey.N.Bytes(), validClaims())
+			},
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "none_algorithm",
+			token: func() string {
+				return signToken(t, "none", "", nil, validClaims())
+			},
+			expected: ErrInvalidToken,
+		},
+		{
+			name:     "malformed",
+			token:    func() string { return "not-a-token" },
+			expected: ErrInvalidToken,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := verifier.Verify(testCase.token())
+			assert.ErrorIs(t, err, testCase.expected)
+		})
+	}
+}
+
+func TestNewGatewayClaims(t *testing.T) {
+	claims := NewGatewayClaims(map[string]string{
+		"sub":       "user-1",
+		"aud":       "[devices-api other]",
+		"scope":     "devices:read devices:write",
+		"tenant_id": "acme",
+	}, nil)
+
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, "acme", claims.Tenant)
+	assert.Equal(t, []string{"devices-api", "other"}, claims.Audience)
+	assert.True(t, claims.HasScopes("devices:write"))
+
+	claims = NewGatewayClaims(map[string]string{"sub": "user-1"}, []string{"devicemodels:read"})
+	assert.Equal(t, []string{"devicemodels:read"}, claims.Scopes)
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// Signing algorithms supported by the verifier.
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+)
+
+var ErrUnsupportedKey = errors.New("unsupported key")
+
+// Key is a verification key, ID matches the kid header of the tokens signed with it and is
+// empty for keys that are tried regardless of the kid.
+type Key struct {
+	ID        string
+	Algorithm string
+	// material is the HMAC secret, *rsa.PublicKey or *ecdsa.PublicKey.
+	material interface{}
+}
+
+// NewHMACKey returns the HS256 key of a shared secret.
+func NewHMACKey(secret []byte) Key {
+	return Key{Algorithm: AlgorithmHS256, material: secret}
+}
+
+// ParsePublicKeyPEM parses a PEM encoded RSA (RS256) or P-256 (ES256) public key.
+func ParsePublicKeyPEM(data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("%w: no PEM block found", ErrUnsupportedKey)
+	}
+
+	var (
+		publicKey interface{}
+		err       error
+	)
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			publicKey = cert.PublicKey
+		}
+	default:
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	return newPublicKey("", publicKey)
+}
+
+// LoadPublicKeyFile reads a PEM encoded public key file.
+func LoadPublicKeyFile(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to read public key file: %w", err)
+	}
+
+	return ParsePublicKeyPEM(data)
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	K         string `json:"k"`
+}
+
+// ParseJWKS parses a JSON Web Key Set (RFC 7517), keys which are not signature keys are skipped.
+func ParseJWKS(data []byte) ([]Key, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal key set: %w", err)
+	}
+
+	keys := make([]Key, 0, len(set.Keys))
+
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.key()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.KeyID, err)
+		}
+
+		if jwk.Algorithm != "" && jwk.Algorithm != key.Algorithm {
+			return nil, fmt.Errorf("key %q: %w: algorithm %s", jwk.KeyID, ErrUnsupportedKey, jwk.Algorithm)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// LoadJWKSFile reads a JSON Web Key Set file.
+func LoadJWKSFile(path string) ([]Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key set file: %w", err)
+	}
+
+	return ParseJWKS(data)
+}
+
+func (k jsonWebKey) key() (Key, error) {
+	switch k.KeyType {
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return Key{}, fmt.Errorf("failed to decode k: %w", err)
+		}
+
+		return Key{ID: k.KeyID, Algorithm: AlgorithmHS256, material: secret}, nil
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return Key{}, fmt.Errorf("failed to decode n: %w", err)
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return Key{}, fmt.Errorf("failed to decode e: %w", err)
+		}
+
+		return newPublicKey(k.KeyID, &rsa.PublicKey{N: n, E: int(e.Int64())})
+	case "EC":
+		if k.Curve != elliptic.P256().Params().Name {
+			return Key{}, fmt.Errorf("%w: curve %s", ErrUnsupportedKey, k.Curve)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return Key{}, fmt.Errorf("failed to decode x: %w", err)
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return Key{}, fmt.Errorf("failed to decode y: %w", err)
+		}
+
+		return newPublicKey(k.KeyID, &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y})
+	default:
+		return Key{}, fmt.Errorf("%w: key type %s", ErrUnsupportedKey, k.KeyType)
+	}
+}
+
+func newPublicKey(id string, publicKey interface{}) (Key, error) {
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		return Key{ID: id, Algorithm: AlgorithmRS256, material: publicKey}, nil
+	case *ecdsa.PublicKey:
+		if publicKey.Curve != elliptic.P256() {
+			return Key{}, fmt.Errorf("%w: curve %s", ErrUnsupportedKey, publicKey.Curve.Params().Name)
+		}
+
+		return Key{ID: id, Algorithm: AlgorithmES256, material: publicKey}, nil
+	default:
+		return Key{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, publicKey)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64: %w", err)
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
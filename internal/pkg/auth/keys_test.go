@@ -0,0 +1,87 @@
+//go:build unit
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	encode := func(value *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(value.Bytes())
+	}
+
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa-1", "alg": "RS256", "use": "sig", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": %q, "y": %q},
+		{"kty": "oct", "kid": "hmac-1", "k": %q},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": %q, "e": %q}
+	]}`,
+		encode(rsaKey.N), encode(big.NewInt(int64(rsaKey.E))),
+		encode(ecKey.X), encode(ecKey.Y),
+		base64.RawURLEncoding.EncodeToString([]byte("secret")),
+		encode(rsaKey.N), encode(big.NewInt(int64(rsaKey.E))),
+	)
+
+	keys, err := ParseJWKS([]byte(jwks))
+	assert.NoError(t, err)
+	assert.Len(t, keys, 3)
+
+	assert.Equal(t, Key{ID: "rsa-1", Algorithm: AlgorithmRS256, material: &rsaKey.PublicKey}, keys[0])
+	assert.Equal(t, "ec-1", keys[1].ID)
+	assert.Equal(t, AlgorithmES256, keys[1].Algorithm)
+	assert.True(t, ecKey.PublicKey.Equal(keys[1].material))
+	assert.Equal(t, Key{ID: "hmac-1", Algorithm: AlgorithmHS256, material: []byte("secret")}, keys[2])
+
+	t.Run("unsupported_curve", func(t *testing.T) {
+		_, err := ParseJWKS([]byte(`{"keys": [{"kty": "EC", "crv": "P-384", "x": "AA", "y": "AA"}]}`))
+		assert.ErrorIs(t, err, ErrUnsupportedKey)
+	})
+
+	t.Run("algorithm_mismatch", func(t *testing.T) {
+		_, err := ParseJWKS([]byte(`{"keys": [{"kty": "oct", "alg": "RS256", "k": "c2VjcmV0"}]}`))
+		assert.ErrorIs(t, err, ErrUnsupportedKey)
+	})
+}
+
+func TestParsePublicKeyPEM(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	assert.NoError(t, err)
+
+	key, err := ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	assert.NoError(t, err)
+	assert.Equal(t, AlgorithmES256, key.Algorithm)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	key, err = ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey),
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, AlgorithmRS256, key.Algorithm)
+
+	_, err = ParsePublicKeyPEM([]byte("not a key"))
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Secondary indexes of the table, the device model index holds device items keyed by their
+// deviceModel attribute and sorted by PK. Items without deviceModel are not indexed.
+const (
+	DeviceModelIndex    = "deviceModel-index"
+	DeviceModelIndexKey = "deviceModel"
+)
+
+const (
+	bootstrapCapacity    = 5
+	bootstrapWaitTimeout = time.Minute
+	bootstrapWaitDelay   = time.Second
+)
+
+// TableAPI is the subset of the DynamoDB client used to bootstrap the table.
+type TableAPI interface {
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error)
+}
+
+// EnsureTable creates the table with its secondary indexes when it does not exist and
+// adds the indexes missing on an existing table. It mirrors the terraform dynamodb module
+// and is meant for DynamoDB Local, where terraform does not update existing tables.
+func EnsureTable(ctx context.Context, client TableAPI, tableName string) error {
+	out, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: &tableName})
+
+	var notFound *types.ResourceNotFoundException
+
+	switch {
+	case errors.As(err, &notFound):
+		return createTable(ctx, client, tableName)
+	case err != nil:
+		return fmt.Errorf("failed to describe table: %w", err)
+	}
+
+	for _, index := range out.Table.GlobalSecondaryIndexes {
+		if aws.ToString(index.IndexName) == DeviceModelIndex {
+			return nil
+		}
+	}
+
+	slog.Info("adding missing index", slog.String("table", tableName), slog.String("index", DeviceModelIndex))
+
+	index := deviceModelIndex()
+
+	if out.Table.BillingModeSummary != nil &&
+		out.Table.BillingModeSummary.BillingMode == types.BillingModePayPerRequest {
+		index.ProvisionedThroughput = nil
+	}
+
+	_, err = client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName:            &tableName,
+		AttributeDefinitions: attributeDefinitions(),
+		GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+			{
+				Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName:             index.IndexName,
+					KeySchema:             index.KeySchema,
+					Projection:            index.Projection,
+					ProvisionedThroughput: index.ProvisionedThroughput,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add index %s: %w", DeviceModelIndex, err)
+	}
+
+	return nil
+}
+
+func createTable(ctx context.Context, client TableAPI, tableName string) error {
+	slog.Info("creating table", slog.String("table", tableName))
+
+	_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:            &tableName,
+		AttributeDefinitions: attributeDefinitions(),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("PK"), KeyType: types.KeyTypeHash},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{deviceModelIndex()},
+		ProvisionedThroughput:  throughput(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(client, func(opts *dynamodb.TableExistsWaiterOptions) {
+		opts.MinDelay = bootstrapWaitDelay
+		opts.MaxDelay = bootstrapWaitDelay
+	})
+
+	err = waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: &tableName}, bootstrapWaitTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to wait for table: %w", err)
+	}
+
+	return nil
+}
+
+func attributeDefinitions() []types.AttributeDefinition {
+	return []types.AttributeDefinition{
+		{AttributeName: aws.String("PK"), AttributeType: types.ScalarAttributeTypeS},
+		{AttributeName: aws.String(DeviceModelIndexKey), AttributeType: types.ScalarAttributeTypeS},
+	}
+}
+
+func deviceModelIndex() types.GlobalSecondaryIndex {
+	return types.GlobalSecondaryIndex{
+		IndexName: aws.String(DeviceModelIndex),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(DeviceModelIndexKey), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("PK"), KeyType: types.KeyTypeRange},
+		},
+		Projection:            &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		ProvisionedThroughput: throughput(),
+	}
+}
+
+func throughput() *types.ProvisionedThroughput {
+	return &types.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(bootstrapCapacity),
+		WriteCapacityUnits: aws.Int64(bootstrapCapacity),
+	}
+}
@@ -0,0 +1,45 @@
+//go:build unit
+
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureTable(t *testing.T) {
+	t.Run("create_missing_table", func(t *testing.T) {
+		client := &MockTableAPI{}
+
+		assert.NoError(t, EnsureTable(context.Background(), client, "devices"))
+		assert.Len(t, client.creates, 1)
+		assert.Equal(t, DeviceModelIndex, aws.ToString(client.creates[0].GlobalSecondaryIndexes[0].IndexName))
+
+		// a second run finds everything in place
+		assert.NoError(t, EnsureTable(context.Background(), client, "devices"))
+		assert.Len(t, client.creates, 1)
+		assert.Empty(t, client.updates)
+	})
+
+	t.Run("add_missing_index", func(t *testing.T) {
+		client := &MockTableAPI{
+			table: &types.TableDescription{
+				TableName:          aws.String("devices"),
+				TableStatus:        types.TableStatusActive,
+				BillingModeSummary: &types.BillingModeSummary{BillingMode: types.BillingModePayPerRequest},
+			},
+		}
+
+		assert.NoError(t, EnsureTable(context.Background(), client, "devices"))
+		assert.Empty(t, client.creates)
+		assert.Len(t, client.updates, 1)
+
+		create := client.updates[0].GlobalSecondaryIndexUpdates[0].Create
+		assert.Equal(t, DeviceModelIndex, aws.ToString(create.IndexName))
+		assert.Nil(t, create.ProvisionedThroughput)
+	})
+}
@@ -10,7 +10,8 @@ import (
 	cfg "github.com/ijalalfrz/go-serverless/internal/app/config"
 )
 
-func InitDynamoDB(appConfig cfg.Config) *dynamodb.Client {
+// InitDynamoDB creates the DynamoDB client, optFns customize the client, e.g. WithMetrics.
+func InitDynamoDB(appConfig cfg.Config, optFns ...func(*dynamodb.Options)) *dynamodb.Client {
 	opts := []func(*config.LoadOptions) error{
 		config.WithRegion(appConfig.DynamoDB.Region),
 	}
@@ -29,5 +30,5 @@ func InitDynamoDB(appConfig cfg.Config) *dynamodb.Client {
 		panic(err)
 	}
 
-	return dynamodb.NewFromConfig(awsCfg)
+	return dynamodb.NewFromConfig(awsCfg, optFns...)
 }
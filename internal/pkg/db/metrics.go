@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// MetricsRecorder records the DynamoDB operations.
+type MetricsRecorder interface {
+	RecordDynamoDB(operation string, latency time.Duration, consumedCapacity float64, err error)
+}
+
+// WithMetrics records the latency, including retries, and the consumed capacity of every
+// operation of the client. The operations return their total consumed capacity unless the
+// caller asks for another level.
+func WithMetrics(recorder MetricsRecorder) func(*dynamodb.Options) {
+	return func(options *dynamodb.Options) {
+		options.APIOptions = append(options.APIOptions, func(stack *middleware.Stack) error {
+			//nolint:wrapcheck
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("Metrics", func(
+				ctx context.Context,
+				in middleware.InitializeInput,
+				next middleware.InitializeHandler,
+			) (middleware.InitializeOutput, middleware.Metadata, error) {
+				returnConsumedCapacity(in.Parameters)
+
+				start := time.Now()
+				out, metadata, err := next.HandleInitialize(ctx, in)
+
+				recorder.RecordDynamoDB(awsmiddleware.GetOperationName(ctx), time.Since(start),
+					consumedCapacity(out.Result), err)
+
+				return out, metadata, err //nolint:wrapcheck
+			}), middleware.After)
+		})
+	}
+}
+
+// returnConsumedCapacity sets ReturnConsumedCapacity on the inputs of the operations reporting
+// their consumed capacity.
+func returnConsumedCapacity(params interface{}) {
+	input := reflect.ValueOf(params)
+	if input.Kind() != reflect.Pointer || input.IsNil() || input.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	field := input.Elem().FieldByName("ReturnConsumedCapacity")
+	if field.IsValid() && field.CanSet() && field.String() == "" {
+		field.Set(reflect.ValueOf(types.ReturnConsumedCapacityTotal))
+	}
+}
+
+// consumedCapacity sums the capacity units consumed by an operation output.
+func consumedCapacity(result interface{}) float64 {
+	output := reflect.ValueOf(result)
+	if output.Kind() != reflect.Pointer || output.IsNil() || output.Elem().Kind() != reflect.Struct {
+		return 0
+	}
+
+	field := output.Elem().FieldByName("ConsumedCapacity")
+	if !field.IsValid() {
+		return 0
+	}
+
+	var total float64
+
+	switch capacity := field.Interface().(type) {
+	case *types.ConsumedCapacity:
+		if capacity != nil && capacity.CapacityUnits != nil {
+			total = *capacity.CapacityUnits
+		}
+	case []types.ConsumedCapacity:
+		for _, tableCapacity := range capacity {
+			if tableCapacity.CapacityUnits != nil {
+				total += *tableCapacity.CapacityUnits
+			}
+		}
+	}
+
+	return total
+}
@@ -0,0 +1,94 @@
+//go:build unit
+
+package db
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedOperation struct {
+	operation        string
+	consumedCapacity float64
+	err              error
+}
+
+type stubMetricsRecorder struct {
+	operations []recordedOperation
+}
+
+func (s *stubMetricsRecorder) RecordDynamoDB(operation string, _ time.Duration, consumedCapacity float64, err error) {
+	s.operations = append(s.operations, recordedOperation{operation, consumedCapacity, err})
+}
+
+func TestWithMetrics(t *testing.T) {
+	var requestBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		requestBody = string(body)
+
+		respWriter.Header().Set("Content-Type", "application/x-amz-json-1.0")
+
+		if req.Header.Get("X-Amz-Target") == "DynamoDB_20120810.PutItem" {
+			respWriter.WriteHeader(http.StatusBadRequest)
+			_, _ = respWriter.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ValidationException","message":"bad"}`))
+
+			return
+		}
+
+		_, _ = respWriter.Write([]byte(`{"Item":{},"ConsumedCapacity":{"TableName":"devices","CapacityUnits":0.5}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	recorder := &stubMetricsRecorder{}
+	client := dynamodb.New(dynamodb.Options{
+		Region:       "ap-southeast-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  aws.AnonymousCredentials{},
+	}, WithMetrics(recorder))
+
+	key := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "DEVICE#1"}}
+
+	_, err := client.GetItem(context.Background(), &dynamodb.GetItemInput{TableName: aws.String("devices"), Key: key})
+	assert.NoError(t, err)
+	assert.Contains(t, requestBody, `"ReturnConsumedCapacity":"TOTAL"`)
+
+	_, err = client.PutItem(context.Background(), &dynamodb.PutItemInput{TableName: aws.String("devices"), Item: key})
+	assert.Error(t, err)
+
+	if !assert.Len(t, recorder.operations, 2) {
+		return
+	}
+
+	assert.Equal(t, "GetItem", recorder.operations[0].operation)
+	assert.Equal(t, 0.5, recorder.operations[0].consumedCapacity)
+	assert.NoError(t, recorder.operations[0].err)
+	assert.Equal(t, "PutItem", recorder.operations[1].operation)
+	assert.Error(t, recorder.operations[1].err)
+}
+
+func TestConsumedCapacity(t *testing.T) {
+	assert.Equal(t, 1.5, consumedCapacity(&dynamodb.GetItemOutput{
+		ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(1.5)},
+	}))
+	assert.Equal(t, 3.0, consumedCapacity(&dynamodb.TransactWriteItemsOutput{
+		ConsumedCapacity: []types.ConsumedCapacity{{CapacityUnits: aws.Float64(1)}, {CapacityUnits: aws.Float64(2)}},
+	}))
+	assert.Zero(t, consumedCapacity(&dynamodb.GetItemOutput{}))
+	assert.Zero(t, consumedCapacity(&dynamodb.DescribeTableOutput{}))
+	assert.Zero(t, consumedCapacity(nil))
+
+	input := &dynamodb.QueryInput{ReturnConsumedCapacity: types.ReturnConsumedCapacityIndexes}
+	returnConsumedCapacity(input)
+	assert.Equal(t, types.ReturnConsumedCapacityIndexes, input.ReturnConsumedCapacity)
+}
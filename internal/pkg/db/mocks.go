@@ -0,0 +1,62 @@
+//go:build unit
+
+package db
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MockTableAPI records the table operations and keeps the resulting table description.
+type MockTableAPI struct {
+	table   *types.TableDescription
+	creates []*dynamodb.CreateTableInput
+	updates []*dynamodb.UpdateTableInput
+}
+
+func (m *MockTableAPI) DescribeTable(_ context.Context, _ *dynamodb.DescribeTableInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.DescribeTableOutput, error) {
+	if m.table == nil {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("table not found")}
+	}
+
+	return &dynamodb.DescribeTableOutput{Table: m.table}, nil
+}
+
+func (m *MockTableAPI) CreateTable(_ context.Context, params *dynamodb.CreateTableInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.CreateTableOutput, error) {
+	m.creates = append(m.creates, params)
+
+	indexes := make([]types.GlobalSecondaryIndexDescription, 0, len(params.GlobalSecondaryIndexes))
+	for _, index := range params.GlobalSecondaryIndexes {
+		indexes = append(indexes, types.GlobalSecondaryIndexDescription{IndexName: index.IndexName})
+	}
+
+	m.table = &types.TableDescription{
+		TableName:              params.TableName,
+		TableStatus:            types.TableStatusActive,
+		GlobalSecondaryIndexes: indexes,
+	}
+
+	return &dynamodb.CreateTableOutput{TableDescription: m.table}, nil
+}
+
+func (m *MockTableAPI) UpdateTable(_ context.Context, params *dynamodb.UpdateTableInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.UpdateTableOutput, error) {
+	m.updates = append(m.updates, params)
+
+	for _, update := range params.GlobalSecondaryIndexUpdates {
+		if update.Create != nil {
+			m.table.GlobalSecondaryIndexes = append(m.table.GlobalSecondaryIndexes,
+				types.GlobalSecondaryIndexDescription{IndexName: update.Create.IndexName})
+		}
+	}
+
+	return &dynamodb.UpdateTableOutput{TableDescription: m.table}, nil
+}
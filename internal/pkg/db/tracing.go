@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"reflect"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tracing"
+)
+
+// WithTracing starts a client span for every operation of the client, retries included, and
+// propagates it to DynamoDB in the traceparent and X-Amzn-Trace-Id headers. The span is a child
+// of the span of the operation context, usually the span of the HTTP request.
+func WithTracing(tracer *tracing.Tracer) func(*dynamodb.Options) {
+	return func(options *dynamodb.Options) {
+		options.APIOptions = append(options.APIOptions, func(stack *middleware.Stack) error {
+			err := stack.Initialize.Add(middleware.InitializeMiddlewareFunc("Tracing", func(
+				ctx context.Context,
+				in middleware.InitializeInput,
+				next middleware.InitializeHandler,
+			) (middleware.InitializeOutput, middleware.Metadata, error) {
+				operation := awsmiddleware.GetOperationName(ctx)
+
+				ctx, span := tracer.Start(ctx, "DynamoDB."+operation, tracing.SpanKindClient)
+				defer span.End()
+
+				span.SetAttribute("db.system", "dynamodb")
+				span.SetAttribute("db.operation", operation)
+
+				if tableName := inputTableName(in.Parameters); tableName != "" {
+					span.SetAttribute("aws.dynamodb.table_name", tableName)
+				}
+
+				out, metadata, err := next.HandleInitialize(ctx, in)
+
+				if requestID, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+					span.SetAttribute("aws.request_id", requestID)
+				}
+
+				if err != nil {
+					span.RecordError(err)
+				}
+
+				return out, metadata, err //nolint:wrapcheck
+			}), middleware.After)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			// before the recursion detection of the SDK, which only sets X-Amzn-Trace-Id when absent
+			//nolint:wrapcheck
+			return stack.Build.Add(middleware.BuildMiddlewareFunc("TracingPropagation", func(
+				ctx context.Context,
+				in middleware.BuildInput,
+				next middleware.BuildHandler,
+			) (middleware.BuildOutput, middleware.Metadata, error) {
+				if req, ok := in.Request.(*smithyhttp.Request); ok {
+					if span := tracing.SpanFromContext(ctx); span != nil {
+						req.Header.Set(tracing.TraceparentHeader, tracing.FormatTraceparent(span.SpanContext()))
+						req.Header.Set(tracing.XRayHeader, tracing.FormatXRay(span.SpanContext()))
+					}
+				}
+
+				return next.HandleBuild(ctx, in) //nolint:wrapcheck
+			}), middleware.Before)
+		})
+	}
+}
+
+// inputTableName returns the table name of the operation input, empty for the operations on
+// several tables.
+func inputTableName(params interface{}) string {
+	input := reflect.ValueOf(params)
+	if input.Kind() != reflect.Pointer || input.IsNil() || input.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+
+	field := input.Elem().FieldByName("TableName")
+	if !field.IsValid() {
+		return ""
+	}
+
+	tableName, ok := field.Interface().(*string)
+	if !ok || tableName == nil {
+		return ""
+	}
+
+	return *tableName
+}
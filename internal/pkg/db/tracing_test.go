@@ -0,0 +1,94 @@
+//go:build unit
+
+package db
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubExporter struct {
+	spans []*tracing.Span
+}
+
+func (s *stubExporter) Export(_ context.Context, span *tracing.Span) {
+	s.spans = append(s.spans, span)
+}
+
+func TestWithTracing(t *testing.T) {
+	var header http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+		header = req.Header.Clone()
+
+		respWriter.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		respWriter.Header().Set("X-Amzn-Requestid", "ddb-request-1")
+
+		if req.Header.Get("X-Amz-Target") == "DynamoDB_20120810.PutItem" {
+			respWriter.WriteHeader(http.StatusBadRequest)
+			_, _ = respWriter.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ValidationException","message":"bad"}`))
+
+			return
+		}
+
+		_, _ = respWriter.Write([]byte(`{"Item":{}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	exporter := &stubExporter{}
+	tracer := tracing.NewTracer("test", exporter)
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "ap-southeast-1",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	}, WithTracing(tracer))
+
+	ctx, parent := tracer.Start(context.Background(), "GET /api/devices/{id}", tracing.SpanKindServer)
+	key := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "DEVICE#1"}}
+
+	_, err := client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String("devices"), Key: key})
+	assert.NoError(t, err)
+
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("devices"), Item: key})
+	assert.Error(t, err)
+
+	if !assert.Len(t, exporter.spans, 2) {
+		return
+	}
+
+	span := exporter.spans[0]
+	assert.Equal(t, "DynamoDB.GetItem", span.Name())
+	assert.Equal(t, tracing.SpanKindClient, span.Kind())
+	assert.Equal(t, parent.SpanContext().TraceID, span.SpanContext().TraceID)
+	assert.Equal(t, parent.SpanContext().SpanID, span.ParentSpanID())
+	assert.Equal(t, map[string]any{
+		"db.system":               "dynamodb",
+		"db.operation":            "GetItem",
+		"aws.dynamodb.table_name": "devices",
+		"aws.request_id":          "ddb-request-1",
+	}, span.Attributes())
+	assert.NoError(t, span.Err())
+
+	assert.Equal(t, "DynamoDB.PutItem", exporter.spans[1].Name())
+	assert.Error(t, exporter.spans[1].Err())
+
+	// the headers of the last request, PutItem
+	assert.Equal(t, tracing.FormatTraceparent(exporter.spans[1].SpanContext()), header.Get("Traceparent"))
+	assert.Equal(t, tracing.FormatXRay(exporter.spans[1].SpanContext()), header.Get("X-Amzn-Trace-Id"))
+}
+
+func TestInputTableName(t *testing.T) {
+	assert.Equal(t, "devices", inputTableName(&dynamodb.QueryInput{TableName: aws.String("devices")}))
+	assert.Empty(t, inputTableName(&dynamodb.QueryInput{}))
+	assert.Empty(t, inputTableName(&dynamodb.BatchGetItemInput{}))
+	assert.Empty(t, inputTableName(nil))
+}
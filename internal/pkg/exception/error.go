@@ -16,14 +16,44 @@ const (
 	CodeUnauthorized  = http.StatusUnauthorized
 	CodeForbidden     = http.StatusForbidden
 	CodeConflict      = http.StatusConflict
+	CodePrecondition  = http.StatusPreconditionFailed
+	CodeTimeout       = http.StatusGatewayTimeout
+	CodeTooLarge      = http.StatusRequestEntityTooLarge
+	CodeUnsupported   = http.StatusUnsupportedMediaType
 )
 
 // Error codes for ui application errors.
 const (
-	DeviceAlreadyExist  = "DEVICE_ALREADY_EXIST"
-	DeviceNotFound      = "DEVICE_NOT_FOUND"
-	InternalServerError = "INTERNAL_SERVER_ERROR"
-	InvalidRequest      = "INVALID_REQUEST"
+	DeviceAlreadyExist       = "DEVICE_ALREADY_EXIST"
+	DeviceNotFound           = "DEVICE_NOT_FOUND"
+	DeviceVersionStale       = "DEVICE_VERSION_STALE"
+	DeviceSerialAlreadyExist = "DEVICE_SERIAL_ALREADY_EXIST"
+	DeviceModelAlreadyExist  = "DEVICE_MODEL_ALREADY_EXIST"
+	DeviceModelNotFound      = "DEVICE_MODEL_NOT_FOUND"
+	DeviceModelVersionStale  = "DEVICE_MODEL_VERSION_STALE"
+	DeviceModelInUse         = "DEVICE_MODEL_IN_USE"
+	DeviceModelUnknown       = "DEVICE_MODEL_UNKNOWN"
+	InternalServerError      = "INTERNAL_SERVER_ERROR"
+	InvalidRequest           = "INVALID_REQUEST"
+	InvalidCursor            = "INVALID_CURSOR"
+	Unauthorized             = "UNAUTHORIZED"
+	InvalidToken             = "INVALID_TOKEN"
+	TokenExpired             = "TOKEN_EXPIRED"
+	InsufficientScope        = "INSUFFICIENT_SCOPE"
+	APIKeyNotFound           = "API_KEY_NOT_FOUND"
+	InvalidAPIKey            = "INVALID_API_KEY"
+	TenantRequired           = "TENANT_REQUIRED"
+	InvalidTenant            = "INVALID_TENANT"
+	TenantMismatch           = "TENANT_MISMATCH"
+	TenantNotGranted         = "TENANT_NOT_GRANTED"
+	InvalidIdempotencyKey    = "INVALID_IDEMPOTENCY_KEY"
+	IdempotencyKeyReused     = "IDEMPOTENCY_KEY_REUSED"
+	IdempotencyInProgress    = "IDEMPOTENCY_IN_PROGRESS"
+	RequestTimeout           = "REQUEST_TIMEOUT"
+	InvalidRequestBody       = "INVALID_REQUEST_BODY"
+	RequestBodyRequired      = "REQUEST_BODY_REQUIRED"
+	RequestBodyTooLarge      = "REQUEST_BODY_TOO_LARGE"
+	UnsupportedMediaType     = "UNSUPPORTED_MEDIA_TYPE"
 )
 
 var (
@@ -51,6 +81,14 @@ var (
 		StatusCode: CodeUnauthorized,
 	}
 
+	ErrForbidden = ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID: "errors.request_forbidden",
+			Message:   "request forbidden",
+		},
+		StatusCode: CodeForbidden,
+	}
+
 	ErrConflict = ApplicationError{
 		Localizable: lang.Localizable{
 			MessageID: "errors.record_already_exist",
@@ -58,14 +96,78 @@ var (
 		},
 		StatusCode: CodeConflict,
 	}
+
+	ErrPreconditionFailed = ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID: "errors.precondition_failed",
+			Message:   "record has been modified",
+		},
+		StatusCode: CodePrecondition,
+	}
+
+	ErrRecordInUse = ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID: "errors.record_in_use",
+			Message:   "record is still referenced",
+		},
+		StatusCode: CodeConflict,
+	}
+
+	ErrInternal = ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID: "errors.internal_server_error",
+			Message:   "internal server error",
+		},
+		StatusCode: CodeInternal,
+	}
+
+	ErrReferenceNotFound = ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID: "errors.reference_not_found",
+			Message:   "referenced record not found",
+		},
+		StatusCode: CodeUnprocessable,
+	}
 )
 
+// DeviceVersionStaleError is returned when a device was modified after it has been read.
+func DeviceVersionStaleError() ApplicationError {
+	err := ErrPreconditionFailed
+	err.MessageVars = map[string]interface{}{
+		"name": "device",
+	}
+	err.UICode = DeviceVersionStale
+
+	return err
+}
+
+// DeviceModelVersionStaleError is returned when a device model was modified after it has been read.
+func DeviceModelVersionStaleError() ApplicationError {
+	err := ErrPreconditionFailed
+	err.MessageVars = map[string]interface{}{
+		"name": "device model",
+	}
+	err.UICode = DeviceModelVersionStale
+
+	return err
+}
+
 // ApplicationError handles application level errors.
 type ApplicationError struct {
 	lang.Localizable
 	StatusCode int
 	UICode     string
 	Cause      error
+	// Details lists the request fields failing validation, it is empty for other errors.
+	Details []FieldViolation
+}
+
+// FieldViolation describes a request field failing a validation rule.
+type FieldViolation struct {
+	lang.Localizable
+	Field string
+	Rule  string
+	Param string
 }
 
 // Error interface implementation.
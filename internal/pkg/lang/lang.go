@@ -20,6 +20,7 @@ var (
 	initBundle sync.Once
 	languages  = []string{"en"}
 	basePath   = "./resources/locales"
+	matcher    = newMatcher(languages)
 )
 
 func SetBasePath(path string) {
@@ -31,6 +32,48 @@ func SetSupportedLanguages(langs string) {
 	replaceLangs := strings.ReplaceAll(langs, " ", "")
 
 	languages = strings.Split(replaceLangs, ",")
+	matcher = newMatcher(languages)
+}
+
+// Match negotiates the response language from an Accept-Language header value. Requested
+// languages are tried by descending q-value, a regional variant falls back to its base
+// language (es-MX -> es) and anything unsupported or malformed falls back to the default.
+func Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return defaultLanguage
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return defaultLanguage
+	}
+
+	return matcher.supported[index]
+}
+
+type languageMatcher struct {
+	language.Matcher
+	supported []string
+}
+
+// newMatcher builds the matcher with the default language first, the matcher returns the
+// first supported language when nothing matches.
+func newMatcher(langs []string) languageMatcher {
+	supported := []string{defaultLanguage}
+	tags := []language.Tag{language.Make(defaultLanguage)}
+
+	for _, lang := range langs {
+		tag, err := language.Parse(lang)
+		if err != nil || lang == defaultLanguage {
+			continue
+		}
+
+		supported = append(supported, lang)
+		tags = append(tags, tag)
+	}
+
+	return languageMatcher{Matcher: language.NewMatcher(tags), supported: supported}
 }
 
 func GetLocalizer(lang string) *i18n.Localizer {
@@ -113,3 +113,31 @@ func TestLocalize(t *testing.T) {
 		})
 	}
 }
+
+func TestMatch(t *testing.T) {
+	SetSupportedLanguages("en, es,id")
+
+	t.Cleanup(func() { SetSupportedLanguages("en") })
+
+	testCases := []struct {
+		name           string
+		acceptLanguage string
+		expected       string
+	}{
+		{name: "exact match", acceptLanguage: "id", expected: "id"},
+		{name: "region variant falls back to base language", acceptLanguage: "es-MX", expected: "es"},
+		{name: "highest q-value wins", acceptLanguage: "en;q=0.4, id;q=0.9, es;q=0.7", expected: "id"},
+		{name: "unsupported languages are skipped", acceptLanguage: "fr-FR, fr;q=0.9, es;q=0.5", expected: "es"},
+		{name: "zero q-value is not acceptable", acceptLanguage: "es;q=0, fr", expected: "en"},
+		{name: "unsupported language falls back to default", acceptLanguage: "ar", expected: "en"},
+		{name: "wildcard falls back to default", acceptLanguage: "*", expected: "en"},
+		{name: "malformed header falls back to default", acceptLanguage: "es;q=abc", expected: "en"},
+		{name: "missing header falls back to default", acceptLanguage: "", expected: "en"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, Match(testCase.acceptLanguage))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/requestid"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tracing"
+)
+
+// ContextHandler adds the request IDs carried by the context, or the Lambda request ID of the
+// invocation, and the current trace and span IDs to the records of the wrapped handler, so the
+// lines logged while serving a request can be correlated. They are kept at the top level of the
+// records of the loggers with groups too.
+type ContextHandler struct {
+	slog.Handler
+
+	// groups holds the groups, and the attributes added within them, of WithGroup and WithAttrs,
+	// which are applied after the correlation attributes.
+	groups []groupOrAttrs
+}
+
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewContextHandler wraps handler with a ContextHandler.
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler}
+}
+
+//nolint:gocritic // the record is passed by value by the slog.Handler interface
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := correlationAttrs(ctx)
+	if len(h.groups) == 0 {
+		record.AddAttrs(attrs...)
+
+		return h.Handler.Handle(ctx, record) //nolint:wrapcheck
+	}
+
+	handler := h.Handler.WithAttrs(attrs)
+
+	for _, group := range h.groups {
+		if group.group != "" {
+			handler = handler.WithGroup(group.group)
+		} else {
+			handler = handler.WithAttrs(group.attrs)
+		}
+	}
+
+	return handler.Handle(ctx, record) //nolint:wrapcheck
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	if len(h.groups) == 0 {
+		return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+	}
+
+	return &ContextHandler{Handler: h.Handler, groups: h.with(groupOrAttrs{attrs: attrs})}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &ContextHandler{Handler: h.Handler, groups: h.with(groupOrAttrs{group: name})}
+}
+
+func (h *ContextHandler) with(group groupOrAttrs) []groupOrAttrs {
+	return append(slices.Clip(h.groups), group)
+}
+
+// correlationAttrs returns the request, trace and span IDs carried by ctx.
+func correlationAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+
+	if ids, ok := requestid.FromContext(ctx); ok {
+		attrs = append(attrs, slog.String("request_id", ids.RequestID))
+
+		if ids.AWSRequestID != "" {
+			attrs = append(attrs, slog.String("aws_request_id", ids.AWSRequestID))
+		}
+
+		if ids.GatewayRequestID != "" {
+			attrs = append(attrs, slog.String("apigw_request_id", ids.GatewayRequestID))
+		}
+	} else if lambdaContext, ok := lambdacontext.FromContext(ctx); ok {
+		// invocations which are not HTTP requests, such as the authorizer
+		attrs = append(attrs, slog.String("aws_request_id", lambdaContext.AwsRequestID))
+	}
+
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		attrs = append(attrs,
+			slog.String("trace_id", span.SpanContext().TraceID.String()),
+			slog.String("span_id", span.SpanContext().SpanID.String()),
+		)
+	}
+
+	return attrs
+}
@@ -0,0 +1,89 @@
+//go:build unit
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/requestid"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+func logLine(t *testing.T, ctx context.Context) map[string]any {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil))).With(slog.String("component", "test"))
+	logger.InfoContext(ctx, "message")
+
+	line := map[string]any{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+
+	return line
+}
+
+func TestContextHandler(t *testing.T) {
+	t.Run("request", func(t *testing.T) {
+		ctx := requestid.NewContext(context.Background(), requestid.IDs{
+			RequestID:        "req-1",
+			AWSRequestID:     "aws-1",
+			GatewayRequestID: "apigw-1",
+		})
+
+		line := logLine(t, ctx)
+		assert.Equal(t, "req-1", line["request_id"])
+		assert.Equal(t, "aws-1", line["aws_request_id"])
+		assert.Equal(t, "apigw-1", line["apigw_request_id"])
+		assert.Equal(t, "test", line["component"])
+	})
+
+	t.Run("lambda_invocation", func(t *testing.T) {
+		ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "aws-1"})
+
+		line := logLine(t, ctx)
+		assert.Equal(t, "aws-1", line["aws_request_id"])
+		assert.NotContains(t, line, "request_id")
+	})
+
+	t.Run("span", func(t *testing.T) {
+		tracer := tracing.NewTracer("test", tracing.NewLogExporter(slog.New(slog.NewJSONHandler(io.Discard, nil))))
+		ctx, span := tracer.Start(context.Background(), "operation", tracing.SpanKindInternal)
+
+		line := logLine(t, ctx)
+		assert.Equal(t, span.SpanContext().TraceID.String(), line["trace_id"])
+		assert.Equal(t, span.SpanContext().SpanID.String(), line["span_id"])
+	})
+
+	t.Run("group", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		ctx := requestid.NewContext(context.Background(), requestid.IDs{RequestID: "req-1", AWSRequestID: "aws-1"})
+		logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil))).
+			With(slog.String("component", "test")).
+			WithGroup("http").
+			With(slog.String("method", "GET"))
+		logger.InfoContext(ctx, "message", slog.Int("status", 200))
+
+		line := map[string]any{}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		assert.Equal(t, "req-1", line["request_id"])
+		assert.Equal(t, "aws-1", line["aws_request_id"])
+		assert.Equal(t, "test", line["component"])
+		assert.Equal(t, map[string]any{"method": "GET", "status": float64(200)}, line["http"])
+	})
+
+	t.Run("without_ids", func(t *testing.T) {
+		line := logLine(t, context.Background())
+		assert.NotContains(t, line, "request_id")
+		assert.NotContains(t, line, "aws_request_id")
+		assert.NotContains(t, line, "trace_id")
+	})
+}
@@ -10,5 +10,5 @@ func InitStructuredLogger(level slog.Leveler) {
 		Level: level,
 	})
 
-	slog.SetDefault(slog.New(jsonHandler))
+	slog.SetDefault(slog.New(NewContextHandler(jsonHandler)))
 }
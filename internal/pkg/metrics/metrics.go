@@ -0,0 +1,424 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Unit is the CloudWatch unit of a metric. Count metrics are exposed as Prometheus counters
+// and the other units as histograms.
+type Unit string
+
+const (
+	UnitCount        Unit = "Count"
+	UnitMilliseconds Unit = "Milliseconds"
+)
+
+// Metric names.
+const (
+	RequestCount             = "RequestCount"
+	RequestLatency           = "RequestLatency"
+	DynamoDBLatency          = "DynamoDBLatency"
+	DynamoDBConsumedCapacity = "DynamoDBConsumedCapacity"
+	DynamoDBErrorCount       = "DynamoDBErrorCount"
+	ColdStart                = "ColdStart"
+	PanicCount               = "PanicCount"
+)
+
+// DefaultNamespace is the namespace of the metrics when none is configured.
+const DefaultNamespace = "GoServerless"
+
+// maxEMFValues is the maximum number of values of a metric in one EMF document.
+const maxEMFValues = 100
+
+// latencyBuckets are the upper bounds, in milliseconds, of the Prometheus histogram buckets.
+var latencyBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// Dimension qualifies a metric, e.g. the route of a request.
+type Dimension struct {
+	Name  string
+	Value string
+}
+
+// Metrics records the metrics of the application. They are written as CloudWatch Embedded
+// Metric Format (EMF) documents on Flush, which batches the values recorded since the previous
+// flush, and they are aggregated for the Prometheus text exposition served by Handler.
+type Metrics struct {
+	namespace string
+	emf       io.Writer
+	coldStart atomic.Bool
+	now       func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*emfDocument
+	series  map[string]*series
+}
+
+// New creates the metrics of namespace, DefaultNamespace when empty. EMF documents are written
+// to emf, nil only aggregates the metrics for Prometheus.
+func New(namespace string, emf io.Writer) *Metrics {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	metrics := &Metrics{
+		namespace: namespace,
+		emf:       emf,
+		now:       time.Now,
+		pending:   make(map[string]*emfDocument),
+		series:    make(map[string]*series),
+	}
+
+	metrics.coldStart.Store(true)
+
+	return metrics
+}
+
+// Record records a value of the metric name.
+func (m *Metrics) Record(name string, unit Unit, value float64, dims ...Dimension) {
+	key := dimensionsKey(dims)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.emf != nil {
+		doc, ok := m.pending[key]
+		if !ok {
+			doc = &emfDocument{dims: dims, values: make(map[string][]float64), units: make(map[string]Unit)}
+			m.pending[key] = doc
+		}
+
+		doc.values[name] = append(doc.values[name], value)
+		doc.units[name] = unit
+	}
+
+	seriesKey := name + "\x00" + key
+
+	aggregate, ok := m.series[seriesKey]
+	if !ok {
+		aggregate = &series{name: name, unit: unit, dims: dims}
+		if unit != UnitCount {
+			aggregate.buckets = make([]uint64, len(latencyBuckets))
+		}
+
+		m.series[seriesKey] = aggregate
+	}
+
+	aggregate.observe(value)
+}
+
+// RecordRequest records the count, by status class, and the latency of a request to route.
+func (m *Metrics) RecordRequest(route string, method string, statusCode int, latency time.Duration) {
+	dims := []Dimension{{Name: "Route", Value: route}, {Name: "Method", Value: method}}
+
+	m.Record(RequestCount, UnitCount, 1, append(dims, Dimension{Name: "StatusClass", Value: statusClass(statusCode)})...)
+	m.Record(RequestLatency, UnitMilliseconds, milliseconds(latency), dims...)
+}
+
+// RecordPanic records a request to route whose handler panicked.
+func (m *Metrics) RecordPanic(route string, method string) {
+	m.Record(PanicCount, UnitCount, 1, Dimension{Name: "Route", Value: route}, Dimension{Name: "Method", Value: method})
+}
+
+// RecordDynamoDB records the latency, the consumed capacity units and the failure of a DynamoDB
+// operation.
+func (m *Metrics) RecordDynamoDB(operation string, latency time.Duration, consumedCapacity float64, err error) {
+	dims := []Dimension{{Name: "Operation", Value: operation}}
+
+	m.Record(DynamoDBLatency, UnitMilliseconds, milliseconds(latency), dims...)
+	m.Record(DynamoDBConsumedCapacity, UnitCount, consumedCapacity, dims...)
+
+	if err != nil {
+		m.Record(DynamoDBErrorCount, UnitCount, 1, dims...)
+	}
+}
+
+// RecordColdStart records a cold start on the first call only.
+func (m *Metrics) RecordColdStart() {
+	if m.coldStart.Swap(false) {
+		m.Record(ColdStart, UnitCount, 1)
+	}
+}
+
+// Flush writes the values recorded since the previous flush as EMF documents, one per set of
+// dimensions.
+func (m *Metrics) Flush() error {
+	if m.emf == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[string]*emfDocument)
+	m.mu.Unlock()
+
+	timestamp := m.now().UnixMilli()
+
+	for _, key := range sortedKeys(pending) {
+		for _, line := range pending[key].lines(m.namespace, timestamp) {
+			data, err := json.Marshal(line)
+			if err != nil {
+				return fmt.Errorf("failed to encode emf document: %w", err)
+			}
+
+			if _, err := m.emf.Write(append(data, '\n')); err != nil {
+				return fmt.Errorf("failed to write emf document: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// WrapInvoke wraps a Lambda handler function to record the cold start of the function and to
+// flush the metrics before every invocation returns. A nil m returns invoke.
+func WrapInvoke[In, Out any](
+	m *Metrics,
+	invoke func(context.Context, In) (Out, error),
+) func(context.Context, In) (Out, error) {
+	if m == nil {
+		return invoke
+	}
+
+	return func(ctx context.Context, in In) (Out, error) {
+		m.RecordColdStart()
+
+		defer func() {
+			// metrics never fail an invocation
+			if err := m.Flush(); err != nil {
+				slog.WarnContext(ctx, "failed to flush metrics", slog.String("error", err.Error()))
+			}
+		}()
+
+		return invoke(ctx, in)
+	}
+}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(respWriter http.ResponseWriter, _ *http.Request) {
+		respWriter.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WritePrometheus(respWriter)
+	})
+}
+
+// WritePrometheus writes the aggregated metrics in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(out io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prefix := snakeCase(m.namespace)
+	typed := make(map[string]bool)
+
+	for _, key := range sortedKeys(m.series) {
+		aggregate := m.series[key]
+		name := prefix + "_" + snakeCase(aggregate.name)
+
+		if aggregate.unit == UnitCount {
+			name += "_total"
+		} else {
+			name += "_" + strings.ToLower(string(aggregate.unit))
+		}
+
+		if !typed[name] {
+			metricType := "histogram"
+			if aggregate.unit == UnitCount {
+				metricType = "counter"
+			}
+
+			fmt.Fprintf(out, "# TYPE %s %s\n", name, metricType)
+
+			typed[name] = true
+		}
+
+		aggregate.writePrometheus(out, name)
+	}
+}
+
+type emfDocument struct {
+	dims   []Dimension
+	values map[string][]float64
+	units  map[string]Unit
+}
+
+// lines returns the EMF documents of the values, several when a metric has more values than
+// one document can hold.
+func (d *emfDocument) lines(namespace string, timestamp int64) []map[string]any {
+	var lines []map[string]any
+
+	for offset := 0; ; offset += maxEMFValues {
+		line := make(map[string]any)
+		definitions := make([]map[string]string, 0, len(d.values))
+
+		for _, name := range sortedKeys(d.values) {
+			values := d.values[name]
+			if offset >= len(values) {
+				continue
+			}
+
+			values = values[offset:min(offset+maxEMFValues, len(values))]
+			definitions = append(definitions, map[string]string{"Name": name, "Unit": string(d.units[name])})
+
+			if len(values) == 1 {
+				line[name] = values[0]
+			} else {
+				line[name] = values
+			}
+		}
+
+		if len(definitions) == 0 {
+			return lines
+		}
+
+		dimensionNames := make([]string, 0, len(d.dims))
+		for _, dim := range d.dims {
+			line[dim.Name] = dim.Value
+			dimensionNames = append(dimensionNames, dim.Name)
+		}
+
+		line["_aws"] = map[string]any{
+			"Timestamp": timestamp,
+			"CloudWatchMetrics": []map[string]any{{
+				"Namespace":  namespace,
+				"Dimensions": [][]string{dimensionNames},
+				"Metrics":    definitions,
+			}},
+		}
+
+		lines = append(lines, line)
+	}
+}
+
+type series struct {
+	name    string
+	unit    Unit
+	dims    []Dimension
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+func (s *series) observe(value float64) {
+	s.sum += value
+	s.count++
+
+	for i, bound := range latencyBuckets {
+		if s.buckets != nil && value <= bound {
+			s.buckets[i]++
+		}
+	}
+}
+
+func (s *series) writePrometheus(out io.Writer, name string) {
+	if s.buckets == nil {
+		fmt.Fprintf(out, "%s%s %s\n", name, labels(s.dims), formatFloat(s.sum))
+
+		return
+	}
+
+	for i, bound := range latencyBuckets {
+		le := Dimension{Name: "le", Value: formatFloat(bound)}
+		fmt.Fprintf(out, "%s_bucket%s %d\n", name, labels(append(slices.Clone(s.dims), le)), s.buckets[i])
+	}
+
+	inf := Dimension{Name: "le", Value: "+Inf"}
+	fmt.Fprintf(out, "%s_bucket%s %d\n", name, labels(append(slices.Clone(s.dims), inf)), s.count)
+	fmt.Fprintf(out, "%s_sum%s %s\n", name, labels(s.dims), formatFloat(s.sum))
+	fmt.Fprintf(out, "%s_count%s %d\n", name, labels(s.dims), s.count)
+}
+
+func labels(dims []Dimension) string {
+	if len(dims) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(dims))
+	for _, dim := range dims {
+		name := dim.Name
+		if name != "le" {
+			name = snakeCase(name)
+		}
+
+		pairs = append(pairs, name+"="+strconv.Quote(dim.Value))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func dimensionsKey(dims []Dimension) string {
+	var key strings.Builder
+
+	for _, dim := range dims {
+		key.WriteString(dim.Name)
+		key.WriteByte('=')
+		key.WriteString(dim.Value)
+		key.WriteByte(0)
+	}
+
+	return key.String()
+}
+
+// snakeCase converts a CamelCase name to snake_case, acronyms are kept together, e.g.
+// DynamoDBLatency becomes dynamo_db_latency.
+func snakeCase(name string) string {
+	var out strings.Builder
+
+	runes := []rune(name)
+	for i, r := range runes {
+		upper := r >= 'A' && r <= 'Z'
+
+		if upper && i > 0 {
+			prevLower := runes[i-1] >= 'a' && runes[i-1] <= 'z'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			prevUpper := runes[i-1] >= 'A' && runes[i-1] <= 'Z'
+
+			if prevLower || (prevUpper && nextLower) {
+				out.WriteByte('_')
+			}
+		}
+
+		switch {
+		case upper:
+			out.WriteRune(r + 'a' - 'A')
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			out.WriteRune(r)
+		default:
+			out.WriteByte('_')
+		}
+	}
+
+	return out.String()
+}
+
+func statusClass(statusCode int) string {
+	return strconv.Itoa(statusCode/100) + "xx" //nolint:mnd
+}
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
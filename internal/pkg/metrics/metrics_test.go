@@ -0,0 +1,166 @@
+//go:build unit
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMetrics(emf *bytes.Buffer) *Metrics {
+	metrics := New("Test", emf)
+	metrics.now = func() time.Time { return time.UnixMilli(1714564800000) }
+
+	return metrics
+}
+
+func emfLines(t *testing.T, emf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+
+	for _, raw := range strings.Split(strings.TrimSpace(emf.String()), "\n") {
+		line := map[string]any{}
+		assert.NoError(t, json.Unmarshal([]byte(raw), &line))
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func TestMetrics_Flush(t *testing.T) {
+	var emf bytes.Buffer
+
+	metrics := newTestMetrics(&emf)
+	metrics.RecordDynamoDB("GetItem", 12*time.Millisecond, 0.5, nil)
+	metrics.RecordDynamoDB("GetItem", 8*time.Millisecond, 0.5, nil)
+	assert.Empty(t, emf.String())
+
+	assert.NoError(t, metrics.Flush())
+
+	lines := emfLines(t, &emf)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, "GetItem", lines[0]["Operation"])
+	assert.Equal(t, []any{12.0, 8.0}, lines[0][DynamoDBLatency])
+	assert.Equal(t, []any{0.5, 0.5}, lines[0][DynamoDBConsumedCapacity])
+	assert.Equal(t, map[string]any{
+		"Timestamp": 1714564800000.0,
+		"CloudWatchMetrics": []any{map[string]any{
+			"Namespace":  "Test",
+			"Dimensions": []any{[]any{"Operation"}},
+			"Metrics": []any{
+				map[string]any{"Name": DynamoDBConsumedCapacity, "Unit": "Count"},
+				map[string]any{"Name": DynamoDBLatency, "Unit": "Milliseconds"},
+			},
+		}},
+	}, lines[0]["_aws"])
+
+	// the values are only written once
+	emf.Reset()
+	assert.NoError(t, metrics.Flush())
+	assert.Empty(t, emf.String())
+}
+
+func TestMetrics_FlushSplitsLargeBatches(t *testing.T) {
+	var emf bytes.Buffer
+
+	metrics := newTestMetrics(&emf)
+	for range maxEMFValues + 1 {
+		metrics.Record("Items", UnitCount, 1)
+	}
+
+	assert.NoError(t, metrics.Flush())
+
+	lines := emfLines(t, &emf)
+	assert.Len(t, lines, 2)
+	assert.Len(t, lines[0]["Items"], maxEMFValues)
+	assert.Equal(t, 1.0, lines[1]["Items"])
+}
+
+func TestMetrics_RecordColdStart(t *testing.T) {
+	var emf bytes.Buffer
+
+	metrics := newTestMetrics(&emf)
+	metrics.RecordColdStart()
+	metrics.RecordColdStart()
+
+	assert.NoError(t, metrics.Flush())
+
+	lines := emfLines(t, &emf)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, 1.0, lines[0][ColdStart])
+}
+
+func TestMetrics_RecordPanic(t *testing.T) {
+	var emf bytes.Buffer
+
+	metrics := newTestMetrics(&emf)
+	metrics.RecordPanic("/api/devices/{id}", http.MethodGet)
+
+	assert.NoError(t, metrics.Flush())
+
+	lines := emfLines(t, &emf)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, 1.0, lines[0][PanicCount])
+	assert.Equal(t, "/api/devices/{id}", lines[0]["Route"])
+	assert.Equal(t, http.MethodGet, lines[0]["Method"])
+}
+
+func TestWrapInvoke(t *testing.T) {
+	var emf bytes.Buffer
+
+	metrics := newTestMetrics(&emf)
+	invoke := WrapInvoke(metrics, func(_ context.Context, in string) (string, error) {
+		metrics.Record("Invocations", UnitCount, 1)
+
+		return in, nil
+	})
+
+	out, err := invoke(context.Background(), "payload")
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", out)
+
+	lines := emfLines(t, &emf)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, 1.0, lines[0][ColdStart])
+	assert.Equal(t, 1.0, lines[0]["Invocations"])
+
+	assert.NotNil(t, WrapInvoke[string, string](nil, invoke))
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	metrics := New("", nil)
+	metrics.RecordRequest("/api/devices/{id}", http.MethodGet, http.StatusOK, 20*time.Millisecond)
+	metrics.RecordRequest("/api/devices/{id}", http.MethodGet, http.StatusNotFound, 3*time.Millisecond)
+	metrics.RecordDynamoDB("GetItem", 7*time.Millisecond, 0.5, nil)
+	assert.NoError(t, metrics.Flush())
+
+	resp := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := resp.Body.String()
+	assert.Contains(t, resp.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, body, "# TYPE go_serverless_request_count_total counter\n")
+	assert.Contains(t, body,
+		`go_serverless_request_count_total{route="/api/devices/{id}",method="GET",status_class="2xx"} 1`)
+	assert.Contains(t, body,
+		`go_serverless_request_count_total{route="/api/devices/{id}",method="GET",status_class="4xx"} 1`)
+	assert.Contains(t, body, "# TYPE go_serverless_request_latency_milliseconds histogram\n")
+	assert.Contains(t, body,
+		`go_serverless_request_latency_milliseconds_bucket{route="/api/devices/{id}",method="GET",le="5"} 1`)
+	assert.Contains(t, body,
+		`go_serverless_request_latency_milliseconds_bucket{route="/api/devices/{id}",method="GET",le="+Inf"} 2`)
+	assert.Contains(t, body, `go_serverless_request_latency_milliseconds_sum{route="/api/devices/{id}",method="GET"} 23`)
+	assert.Contains(t, body, `go_serverless_request_latency_milliseconds_count{route="/api/devices/{id}",method="GET"} 2`)
+	assert.Contains(t, body, `go_serverless_dynamo_db_consumed_capacity_total{operation="GetItem"} 0.5`)
+	assert.Equal(t, 1, strings.Count(body, "# TYPE go_serverless_request_count_total"))
+}
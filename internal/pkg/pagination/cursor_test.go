@@ -0,0 +1,72 @@
+//go:build unit
+
+package pagination
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	key := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "DEVICE#id1"},
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		cursor, err := codec.Encode(key)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, cursor)
+
+		decoded, err := codec.Decode(cursor)
+		assert.NoError(t, err)
+		assert.Equal(t, key, decoded)
+	})
+
+	t.Run("empty key and cursor", func(t *testing.T) {
+		cursor, err := codec.Encode(nil)
+		assert.NoError(t, err)
+		assert.Empty(t, cursor)
+
+		decoded, err := codec.Decode("")
+		assert.NoError(t, err)
+		assert.Nil(t, decoded)
+	})
+
+	t.Run("unsupported attribute type", func(t *testing.T) {
+		_, err := codec.Encode(map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberN{Value: "1"},
+		})
+		assert.ErrorIs(t, err, errUnsupportedKey)
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		cursor, _ := codec.Encode(key)
+		forged, _ := codec.Encode(map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "DEVICE#id2"},
+		})
+
+		forgedPayload, _, _ := strings.Cut(forged, ".")
+		_, sig, _ := strings.Cut(cursor, ".")
+
+		_, err := codec.Decode(forgedPayload + "." + sig)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("signed with another secret", func(t *testing.T) {
+		cursor, _ := NewCursorCodec("other").Encode(key)
+
+		_, err := codec.Decode(cursor)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("malformed cursor", func(t *testing.T) {
+		for _, cursor := range []string{"garbage", "a.b", "!!!.###"} {
+			_, err := codec.Decode(cursor)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		}
+	})
+}
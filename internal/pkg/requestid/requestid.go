@@ -0,0 +1,63 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+// Headers echoing the identifiers of a request in its response.
+const (
+	// Header carries the request ID, it is accepted from the client or generated.
+	Header = "X-Request-Id"
+	// AWSHeader carries the request ID of the Lambda invocation serving the request.
+	AWSHeader = "X-Aws-Request-Id"
+	// GatewayHeader carries the request ID of the API Gateway stage forwarding the request.
+	GatewayHeader = "X-Apigw-Request-Id"
+)
+
+const idSize = 16
+
+// idPattern limits the client request IDs to what can be logged and echoed safely.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,128}$`)
+
+// IDs identifies a request across the client, API Gateway, Lambda and the logs.
+type IDs struct {
+	// RequestID is the ID of the request, echoed in every response and error body.
+	RequestID string
+	// AWSRequestID is the ID of the Lambda invocation, empty outside Lambda.
+	AWSRequestID string
+	// GatewayRequestID is the requestContext.requestId of API Gateway events, empty for other
+	// event sources.
+	GatewayRequestID string
+}
+
+type contextKey struct{}
+
+// New generates a request ID.
+func New() string {
+	id := make([]byte, idSize)
+
+	// crypto/rand does not fail on supported platforms
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// Valid reports whether a request ID sent by a client can be used.
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// NewContext returns a copy of ctx carrying the request IDs.
+func NewContext(ctx context.Context, ids IDs) context.Context {
+	return context.WithValue(ctx, contextKey{}, ids)
+}
+
+// FromContext returns the request IDs of ctx.
+func FromContext(ctx context.Context) (IDs, bool) {
+	ids, ok := ctx.Value(contextKey{}).(IDs)
+
+	return ids, ok
+}
@@ -0,0 +1,37 @@
+//go:build unit
+
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	for _, id := range []string{"req-1", "1-5f84c7a9:abc_def.1", strings.Repeat("a", 128), New()} {
+		assert.True(t, Valid(id), id)
+	}
+
+	for _, id := range []string{"", "req 1", "req\n1", "<script>", strings.Repeat("a", 129)} {
+		assert.False(t, Valid(id), id)
+	}
+}
+
+func TestNew(t *testing.T) {
+	assert.Len(t, New(), 2*idSize)
+	assert.NotEqual(t, New(), New())
+}
+
+func TestFromContext(t *testing.T) {
+	ids := IDs{RequestID: "req-1", AWSRequestID: "aws-1", GatewayRequestID: "apigw-1"}
+
+	got, ok := FromContext(NewContext(context.Background(), ids))
+	assert.True(t, ok)
+	assert.Equal(t, ids, got)
+
+	_, ok = FromContext(context.Background())
+	assert.False(t, ok)
+}
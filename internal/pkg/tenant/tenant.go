@@ -0,0 +1,72 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
+)
+
+// Header is the header selecting the tenant of the unauthenticated callers and of the callers
+// granted CrossTenantScope.
+const Header = "X-Tenant-Id"
+
+// CrossTenantScope grants the callers whose claims carry no tenant access to the tenant named by
+// the X-Tenant-Id header.
+const CrossTenantScope = "tenants:cross"
+
+// ErrTenantRequired is returned when a request or a repository call has no tenant.
+var ErrTenantRequired = exception.ApplicationError{
+	Localizable: lang.Localizable{
+		MessageID: "errors.tenant_required",
+		Message:   "tenant required",
+	},
+	StatusCode: exception.CodeBadRequest,
+	UICode:     exception.TenantRequired,
+}
+
+// ErrInvalidTenant is returned for tenant IDs which cannot be used in keys.
+var ErrInvalidTenant = exception.ApplicationError{
+	Localizable: lang.Localizable{
+		MessageID: "errors.invalid_tenant",
+		Message:   "invalid tenant",
+	},
+	StatusCode: exception.CodeBadRequest,
+	UICode:     exception.InvalidTenant,
+}
+
+// idPattern excludes the # separator of the item keys, so a tenant ID can never reach into
+// the keys of another tenant.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+type contextKey struct{}
+
+// Validate checks that id can be used as tenant ID.
+func Validate(id string) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("%w: %q", ErrInvalidTenant, id)
+	}
+
+	return nil
+}
+
+// NewContext returns a copy of ctx carrying the tenant ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant ID of ctx, it fails when ctx carries no valid tenant ID.
+func FromContext(ctx context.Context) (string, error) {
+	id, _ := ctx.Value(contextKey{}).(string)
+	if id == "" {
+		return "", ErrTenantRequired
+	}
+
+	if err := Validate(id); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,33 @@
+//go:build unit
+
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	for _, id := range []string{"acme", "acme-eu_1.prod", strings.Repeat("a", 64)} {
+		assert.NoError(t, Validate(id), id)
+	}
+
+	for _, id := range []string{"", "acme#DEVICE#1", "acme corp", strings.Repeat("a", 65)} {
+		assert.ErrorIs(t, Validate(id), ErrInvalidTenant, id)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	id, err := FromContext(NewContext(context.Background(), "acme"))
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", id)
+
+	_, err = FromContext(context.Background())
+	assert.ErrorIs(t, err, ErrTenantRequired)
+
+	_, err = FromContext(NewContext(context.Background(), "acme#"))
+	assert.ErrorIs(t, err, ErrInvalidTenant)
+}
@@ -0,0 +1,200 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// scopeName is the instrumentation scope of the exported spans.
+const scopeName = "github.com/ijalalfrz/go-serverless/internal/pkg/tracing"
+
+// OTLP status codes.
+const (
+	statusCodeUnset = 0
+	statusCodeError = 2
+)
+
+// LogExporter logs the finished spans as structured log lines.
+type LogExporter struct {
+	logger *slog.Logger
+}
+
+// NewLogExporter creates an exporter logging to logger, the default logger when nil.
+func NewLogExporter(logger *slog.Logger) *LogExporter {
+	return &LogExporter{logger: logger}
+}
+
+func (e *LogExporter) Export(ctx context.Context, span *Span) {
+	logger := e.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	attrs := []any{
+		slog.String("trace_id", span.SpanContext().TraceID.String()),
+		slog.String("span_id", span.SpanContext().SpanID.String()),
+		slog.String("name", span.Name()),
+		slog.String("kind", span.Kind().String()),
+		slog.Duration("duration", span.EndTime().Sub(span.StartTime())),
+	}
+
+	if span.ParentSpanID().IsValid() {
+		attrs = append(attrs, slog.String("parent_span_id", span.ParentSpanID().String()))
+	}
+
+	if err := span.Err(); err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	attributes := span.Attributes()
+	keys := sortedKeys(attributes)
+
+	spanAttrs := make([]any, 0, len(keys))
+	for _, key := range keys {
+		spanAttrs = append(spanAttrs, slog.Any(key, attributes[key]))
+	}
+
+	attrs = append(attrs, slog.Group("attributes", spanAttrs...))
+
+	logger.InfoContext(ctx, "span", slog.Group("span", attrs...))
+}
+
+// FileExporter appends the finished spans to a file as OTLP/JSON lines, one ExportTraceServiceRequest
+// per span, which the OpenTelemetry collector file receiver and most trace viewers can load.
+type FileExporter struct {
+	service string
+
+	mu  sync.Mutex
+	out io.WriteCloser
+}
+
+// NewFileExporter creates an exporter appending the spans of service to the file at path.
+func NewFileExporter(path string, service string) (*FileExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644) //nolint:gosec,mnd
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+
+	return &FileExporter{service: service, out: file}, nil
+}
+
+func (e *FileExporter) Export(ctx context.Context, span *Span) {
+	data, err := json.Marshal(otlpRequest(e.service, span))
+	if err != nil {
+		slog.WarnContext(ctx, "failed to encode span", slog.String("error", err.Error()))
+
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// tracing never fails a request
+	if _, err := e.out.Write(append(data, '\n')); err != nil {
+		slog.WarnContext(ctx, "failed to write span", slog.String("error", err.Error()))
+	}
+}
+
+// Close closes the trace file.
+func (e *FileExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.out.Close() //nolint:wrapcheck
+}
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+func otlpRequest(service string, span *Span) map[string]any {
+	exported := otlpSpan{
+		TraceID:           span.SpanContext().TraceID.String(),
+		SpanID:            span.SpanContext().SpanID.String(),
+		Name:              span.Name(),
+		Kind:              int(span.Kind()),
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime().UnixNano(), 10),
+		Status:            otlpStatus{Code: statusCodeUnset},
+	}
+
+	if span.ParentSpanID().IsValid() {
+		exported.ParentSpanID = span.ParentSpanID().String()
+	}
+
+	attributes := span.Attributes()
+	for _, key := range sortedKeys(attributes) {
+		exported.Attributes = append(exported.Attributes, otlpAttribute{Key: key, Value: otlpValue(attributes[key])})
+	}
+
+	if err := span.Err(); err != nil {
+		exported.Status = otlpStatus{Code: statusCodeError, Message: err.Error()}
+	}
+
+	return map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource": map[string]any{
+				"attributes": []otlpAttribute{{Key: "service.name", Value: otlpValue(service)}},
+			},
+			"scopeSpans": []map[string]any{{
+				"scope": map[string]string{"name": scopeName},
+				"spans": []otlpSpan{exported},
+			}},
+		}},
+	}
+}
+
+// otlpValue converts an attribute value to an OTLP AnyValue, 64 bit integers are strings in
+// OTLP/JSON.
+func otlpValue(value any) map[string]any {
+	switch typed := value.(type) {
+	case string:
+		return map[string]any{"stringValue": typed}
+	case bool:
+		return map[string]any{"boolValue": typed}
+	case int:
+		return map[string]any{"intValue": strconv.Itoa(typed)}
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(typed, 10)}
+	case float64:
+		return map[string]any{"doubleValue": typed}
+	default:
+		return map[string]any{"stringValue": fmt.Sprint(typed)}
+	}
+}
+
+func sortedKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Propagation headers.
+const (
+	// TraceparentHeader is the W3C Trace Context header.
+	TraceparentHeader = "Traceparent"
+	// XRayHeader is the AWS X-Ray header, set by API Gateway and Lambda.
+	XRayHeader = "X-Amzn-Trace-Id"
+)
+
+const (
+	traceparentVersion = "00"
+	traceparentLength  = 55
+	sampledFlag        = 0x01
+	xrayVersion        = "1"
+	xrayEpochLength    = 8
+)
+
+var (
+	errInvalidTraceparent = errors.New("invalid traceparent")
+	errInvalidXRay        = errors.New("invalid x-ray trace header")
+)
+
+// ParseTraceparent parses a W3C traceparent header, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func ParseTraceparent(header string) (SpanContext, error) {
+	header = strings.TrimSpace(header)
+
+	parts := strings.Split(header, "-")
+	if len(parts) < 4 || parts[0] == "ff" {
+		return SpanContext{}, errInvalidTraceparent
+	}
+
+	// the later versions may append fields, version 00 has exactly four
+	if parts[0] == traceparentVersion && len(header) != traceparentLength {
+		return SpanContext{}, errInvalidTraceparent
+	}
+
+	var (
+		sc             SpanContext
+		version, flags [1]byte
+	)
+
+	if !decodeHex(version[:], parts[0]) || !decodeHex(sc.TraceID[:], parts[1]) ||
+		!decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, errInvalidTraceparent
+	}
+
+	if !sc.TraceID.IsValid() || !sc.SpanID.IsValid() {
+		return SpanContext{}, errInvalidTraceparent
+	}
+
+	sc.Sampled = flags[0]&sampledFlag != 0
+
+	return sc, nil
+}
+
+// FormatTraceparent formats sc as a W3C traceparent header.
+func FormatTraceparent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return traceparentVersion + "-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseXRay parses an X-Ray trace header, e.g.
+// Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1. The span ID is
+// not valid when the header has no parent, and the trace is sampled unless Sampled=0.
+func ParseXRay(header string) (SpanContext, error) {
+	sc := SpanContext{Sampled: true}
+
+	var hasRoot bool
+
+	for _, field := range strings.Split(header, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+
+		switch key {
+		case "Root":
+			version, traceID, ok := strings.Cut(value, "-")
+			if !ok || version != xrayVersion || len(traceID) != len(sc.TraceID)*2+1 ||
+				traceID[xrayEpochLength] != '-' {
+				return SpanContext{}, errInvalidXRay
+			}
+
+			if !decodeHex(sc.TraceID[:], traceID[:xrayEpochLength]+traceID[xrayEpochLength+1:]) {
+				return SpanContext{}, errInvalidXRay
+			}
+
+			hasRoot = true
+		case "Parent":
+			if !decodeHex(sc.SpanID[:], value) {
+				return SpanContext{}, errInvalidXRay
+			}
+		case "Sampled":
+			sc.Sampled = value != "0"
+		}
+	}
+
+	if !hasRoot || !sc.TraceID.IsValid() {
+		return SpanContext{}, errInvalidXRay
+	}
+
+	return sc, nil
+}
+
+// FormatXRay formats sc as an X-Ray trace header.
+func FormatXRay(sc SpanContext) string {
+	traceID := sc.TraceID.String()
+
+	sampled := "0"
+	if sc.Sampled {
+		sampled = "1"
+	}
+
+	return "Root=" + xrayVersion + "-" + traceID[:xrayEpochLength] + "-" + traceID[xrayEpochLength:] +
+		";Parent=" + sc.SpanID.String() + ";Sampled=" + sampled
+}
+
+// decodeHex decodes the lowercase hex string src to dst, which it must fill exactly.
+func decodeHex(dst []byte, src string) bool {
+	if len(src) != len(dst)*2 || strings.ToLower(src) != src {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(src))
+
+	return err == nil
+}
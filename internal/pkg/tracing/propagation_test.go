@@ -0,0 +1,87 @@
+//go:build unit
+
+package tracing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		assert.NoError(t, err)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+		assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+		assert.True(t, sc.Sampled)
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", FormatTraceparent(sc))
+	})
+
+	t.Run("not_sampled", func(t *testing.T) {
+		sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		assert.NoError(t, err)
+		assert.False(t, sc.Sampled)
+	})
+
+	t.Run("future_version", func(t *testing.T) {
+		sc, err := ParseTraceparent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+		assert.NoError(t, err)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	})
+
+	for name, header := range map[string]string{
+		"empty":           "",
+		"invalid_version": "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"extra_field":     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"zero_trace_id":   "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"zero_span_id":    "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"uppercase":       "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"short_trace_id":  "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTraceparent(header)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseXRay(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		header := "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+
+		sc, err := ParseXRay(header)
+		assert.NoError(t, err)
+		assert.Equal(t, "5759e988bd862e3fe1be46a994272793", sc.TraceID.String())
+		assert.Equal(t, "53995c3f42cd8ad8", sc.SpanID.String())
+		assert.True(t, sc.Sampled)
+		assert.Equal(t, header, FormatXRay(sc))
+	})
+
+	t.Run("root_only", func(t *testing.T) {
+		sc, err := ParseXRay("Root=1-5759e988-bd862e3fe1be46a994272793;Lineage=a87bd80c:0")
+		assert.NoError(t, err)
+		assert.True(t, sc.IsValid())
+		assert.False(t, sc.SpanID.IsValid())
+		assert.True(t, sc.Sampled)
+	})
+
+	t.Run("not_sampled", func(t *testing.T) {
+		sc, err := ParseXRay("Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=0")
+		assert.NoError(t, err)
+		assert.False(t, sc.Sampled)
+	})
+
+	for name, header := range map[string]string{
+		"empty":          "",
+		"without_root":   "Parent=53995c3f42cd8ad8;Sampled=1",
+		"invalid_root":   "Root=2-5759e988-bd862e3fe1be46a994272793",
+		"short_root":     "Root=1-5759e988-bd862e3fe1be46a9942727",
+		"invalid_parent": "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=xyz",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseXRay(header)
+			assert.Error(t, err)
+		})
+	}
+}
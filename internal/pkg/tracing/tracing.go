@@ -0,0 +1,260 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether id is not all zeros.
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// SpanID identifies a span of a trace.
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether id is not all zeros.
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// SpanContext is the part of a span propagated to the other services.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether the span context has a trace ID, the span ID of a remote parent may
+// be missing.
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() }
+
+// SpanKind tells the role of a span, the values are the ones of OTLP.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// Exporter exports the finished sampled spans, ctx carries the span.
+type Exporter interface {
+	Export(ctx context.Context, span *Span)
+}
+
+// Tracer starts the spans of a service and exports them when they end.
+type Tracer struct {
+	service  string
+	exporter Exporter
+	now      func() time.Time
+}
+
+// NewTracer creates a tracer of service exporting its spans to exporter.
+func NewTracer(service string, exporter Exporter) *Tracer {
+	return &Tracer{service: service, exporter: exporter, now: time.Now}
+}
+
+// Service is the name of the traced service.
+func (t *Tracer) Service() string { return t.service }
+
+// Start starts a span child of the span of ctx, or of the remote span context of ctx, or a new
+// trace. The returned context carries the span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{
+		tracer:     t,
+		ctx:        ctx,
+		name:       name,
+		kind:       kind,
+		start:      t.now(),
+		attributes: make(map[string]any),
+	}
+
+	if parent, ok := SpanContextFromContext(ctx); ok && parent.IsValid() {
+		span.spanContext.TraceID = parent.TraceID
+		span.spanContext.Sampled = parent.Sampled
+		span.parentSpanID = parent.SpanID
+	} else {
+		// new traces are sampled, the callers decide for the traces they propagate
+		span.spanContext.TraceID = newTraceID()
+		span.spanContext.Sampled = true
+	}
+
+	span.spanContext.SpanID = newSpanID()
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// Span is an operation of a trace.
+type Span struct {
+	tracer       *Tracer
+	ctx          context.Context //nolint:containedctx // exported with the span
+	spanContext  SpanContext
+	parentSpanID SpanID
+	kind         SpanKind
+	start        time.Time
+
+	mu         sync.Mutex
+	name       string
+	end        time.Time
+	attributes map[string]any
+	err        error
+	ended      bool
+}
+
+// SpanContext returns the span context propagated to the children of the span.
+func (s *Span) SpanContext() SpanContext { return s.spanContext }
+
+// ParentSpanID returns the ID of the parent span, it is not valid for root spans.
+func (s *Span) ParentSpanID() SpanID { return s.parentSpanID }
+
+func (s *Span) Kind() SpanKind { return s.kind }
+
+func (s *Span) StartTime() time.Time { return s.start }
+
+func (s *Span) Name() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.name
+}
+
+// SetName renames the span, e.g. once the route of a request is known.
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.name = name
+}
+
+// SetAttribute sets an attribute of the span, values are strings, integers, floats or booleans.
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attributes[key] = value
+}
+
+// Attributes returns a copy of the span attributes.
+func (s *Span) Attributes() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attributes := make(map[string]any, len(s.attributes))
+	for key, value := range s.attributes {
+		attributes[key] = value
+	}
+
+	return attributes
+}
+
+// RecordError marks the span as failed with err.
+func (s *Span) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err
+}
+
+// Err returns the error recorded by the span.
+func (s *Span) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+func (s *Span) EndTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.end
+}
+
+// End ends the span and exports it when it is sampled, later calls are ignored.
+func (s *Span) End() {
+	s.mu.Lock()
+
+	if s.ended {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.ended = true
+	s.end = s.tracer.now()
+	s.mu.Unlock()
+
+	if s.spanContext.Sampled {
+		s.tracer.exporter.Export(ContextWithSpan(s.ctx, s), s)
+	}
+}
+
+type (
+	spanKey          struct{}
+	remoteContextKey struct{}
+)
+
+// ContextWithSpan returns a copy of ctx carrying span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span of ctx, nil when ctx carries none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a copy of ctx carrying the span context received from
+// another service, the spans started with ctx are its children.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the span of ctx, or the remote span context
+// of ctx.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext(), true
+	}
+
+	sc, ok := ctx.Value(remoteContextKey{}).(SpanContext)
+
+	return sc, ok
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+
+	// crypto/rand does not fail on supported platforms
+	_, _ = rand.Read(id[:])
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+
+	_, _ = rand.Read(id[:])
+
+	return id
+}
@@ -0,0 +1,176 @@
+//go:build unit
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubExporter struct {
+	spans []*Span
+}
+
+func (s *stubExporter) Export(_ context.Context, span *Span) {
+	s.spans = append(s.spans, span)
+}
+
+func newTestTracer(exporter Exporter) *Tracer {
+	tracer := NewTracer("devices-api", exporter)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	tracer.now = func() time.Time {
+		calls++
+
+		return start.Add(time.Duration(calls) * time.Millisecond)
+	}
+
+	return tracer
+}
+
+func TestTracerStart(t *testing.T) {
+	t.Run("new_trace", func(t *testing.T) {
+		exporter := &stubExporter{}
+		tracer := newTestTracer(exporter)
+
+		ctx, root := tracer.Start(context.Background(), "root", SpanKindServer)
+		_, child := tracer.Start(ctx, "child", SpanKindClient)
+		child.End()
+		root.End()
+		root.End()
+
+		assert.True(t, root.SpanContext().IsValid())
+		assert.True(t, root.SpanContext().Sampled)
+		assert.False(t, root.ParentSpanID().IsValid())
+		assert.Equal(t, root.SpanContext().TraceID, child.SpanContext().TraceID)
+		assert.Equal(t, root.SpanContext().SpanID, child.ParentSpanID())
+		assert.NotEqual(t, root.SpanContext().SpanID, child.SpanContext().SpanID)
+		assert.Equal(t, []*Span{child, root}, exporter.spans)
+		assert.Same(t, root, SpanFromContext(ctx))
+	})
+
+	t.Run("remote_parent", func(t *testing.T) {
+		exporter := &stubExporter{}
+		tracer := newTestTracer(exporter)
+
+		remote, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		assert.NoError(t, err)
+
+		_, span := tracer.Start(ContextWithRemoteSpanContext(context.Background(), remote), "root", SpanKindServer)
+		span.End()
+
+		assert.Equal(t, remote.TraceID, span.SpanContext().TraceID)
+		assert.Equal(t, remote.SpanID, span.ParentSpanID())
+		assert.Len(t, exporter.spans, 1)
+	})
+
+	t.Run("not_sampled", func(t *testing.T) {
+		exporter := &stubExporter{}
+		tracer := newTestTracer(exporter)
+
+		remote := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{1}}
+
+		_, span := tracer.Start(ContextWithRemoteSpanContext(context.Background(), remote), "root", SpanKindServer)
+		span.End()
+
+		assert.Empty(t, exporter.spans)
+	})
+}
+
+func TestLogExporter(t *testing.T) {
+	var buf bytes.Buffer
+
+	tracer := newTestTracer(NewLogExporter(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	ctx, root := tracer.Start(context.Background(), "root", SpanKindServer)
+	_, span := tracer.Start(ctx, "DynamoDB.GetItem", SpanKindClient)
+	span.SetAttribute("db.system", "dynamodb")
+	span.RecordError(errors.New("throttled"))
+	span.End()
+
+	line := map[string]any{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "span", line["msg"])
+	assert.Equal(t, map[string]any{
+		"trace_id":       root.SpanContext().TraceID.String(),
+		"span_id":        span.SpanContext().SpanID.String(),
+		"parent_span_id": root.SpanContext().SpanID.String(),
+		"name":           "DynamoDB.GetItem",
+		"kind":           "client",
+		"duration":       float64(time.Millisecond),
+		"error":          "throttled",
+		"attributes":     map[string]any{"db.system": "dynamodb"},
+	}, line["span"])
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	exporter, err := NewFileExporter(path, "devices-api")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tracer := newTestTracer(exporter)
+
+	_, span := tracer.Start(context.Background(), "GET /api/devices", SpanKindServer)
+	span.SetAttribute("http.response.status_code", 500)
+	span.SetAttribute("http.route", "/api/devices")
+	span.RecordError(errors.New("internal server error"))
+	span.End()
+
+	_, span = tracer.Start(context.Background(), "GET /api/devices", SpanKindServer)
+	span.End()
+
+	assert.NoError(t, exporter.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	if !assert.Len(t, lines, 2) {
+		return
+	}
+
+	var request struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []otlpAttribute `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Scope struct {
+					Name string `json:"name"`
+				} `json:"scope"`
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+
+	assert.NoError(t, json.Unmarshal(lines[0], &request))
+	assert.Equal(t, []otlpAttribute{{Key: "service.name", Value: map[string]any{"stringValue": "devices-api"}}},
+		request.ResourceSpans[0].Resource.Attributes)
+	assert.Equal(t, scopeName, request.ResourceSpans[0].ScopeSpans[0].Scope.Name)
+
+	exported := request.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	assert.Len(t, exported.TraceID, 32)
+	assert.Len(t, exported.SpanID, 16)
+	assert.Empty(t, exported.ParentSpanID)
+	assert.Equal(t, "GET /api/devices", exported.Name)
+	assert.Equal(t, int(SpanKindServer), exported.Kind)
+	assert.Equal(t, "1704067200001000000", exported.StartTimeUnixNano)
+	assert.Equal(t, "1704067200002000000", exported.EndTimeUnixNano)
+	assert.Equal(t, []otlpAttribute{
+		{Key: "http.response.status_code", Value: map[string]any{"intValue": "500"}},
+		{Key: "http.route", Value: map[string]any{"stringValue": "/api/devices"}},
+	}, exported.Attributes)
+	assert.Equal(t, otlpStatus{Code: statusCodeError, Message: "internal server error"}, exported.Status)
+}
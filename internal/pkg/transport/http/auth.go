@@ -0,0 +1,197 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
+)
+
+// TokenVerifier verifies a bearer token and returns its claims.
+type TokenVerifier interface {
+	Verify(token string) (auth.Claims, error)
+}
+
+// APIKeyVerifier verifies an API key and returns its claims.
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (auth.Claims, error)
+}
+
+// APIKeyHeader is the header carrying the API key of machine clients.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyMiddleware authenticates the callers sending an API key and stores the key claims in
+// the request context, requests without key are left to AuthMiddleware. It must run after
+// HeaderMiddleware and before AuthMiddleware.
+func APIKeyMiddleware(verifier APIKeyVerifier) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			key := req.Header.Get(APIKeyHeader)
+			if key == "" {
+				next.ServeHTTP(respWriter, req)
+
+				return
+			}
+
+			claims, err := verifier.VerifyAPIKey(req.Context(), key)
+			if err != nil {
+				ErrorResponse(req.Context(), newAPIKeyError(err), respWriter)
+
+				return
+			}
+
+			reqContext, _ := dto.RequestFromContext(req.Context())
+			reqContext.Subject = claims.Subject
+			reqContext.Claims = &claims
+
+			next.ServeHTTP(respWriter, req.WithContext(dto.WithRequestContext(req.Context(), reqContext)))
+		})
+	}
+}
+
+// newAPIKeyError hides why a key was refused, lookup failures are not reported as invalid key.
+func newAPIKeyError(err error) error {
+	if !errors.Is(err, auth.ErrInvalidAPIKey) {
+		return err
+	}
+
+	appErr := exception.ErrUnauthorized
+	appErr.Cause = err
+	appErr.Localizable = lang.Localizable{MessageID: "errors.invalid_api_key", Message: "invalid api key"}
+	appErr.UICode = exception.InvalidAPIKey
+
+	return appErr
+}
+
+// AuthMiddleware authenticates the caller and stores its claims in the request context. Claims
+// already validated by an API Gateway v2 JWT or Lambda authorizer are trusted, otherwise the
+// bearer token of the Authorization header is verified. Callers authenticated by
+// APIKeyMiddleware are let through. It must run after HeaderMiddleware.
+func AuthMiddleware(verifier TokenVerifier) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			if reqContext, _ := dto.RequestFromContext(req.Context()); reqContext.Claims != nil {
+				next.ServeHTTP(respWriter, req)
+
+				return
+			}
+
+			claims, err := authenticate(req, verifier)
+			if err != nil {
+				respWriter.Header().Set("WWW-Authenticate", "Bearer")
+				ErrorResponse(req.Context(), err, respWriter)
+
+				return
+			}
+
+			reqContext, _ := dto.RequestFromContext(req.Context())
+			reqContext.Subject = claims.Subject
+			reqContext.Claims = &claims
+
+			next.ServeHTTP(respWriter, req.WithContext(dto.WithRequestContext(req.Context(), reqContext)))
+		})
+	}
+}
+
+func authenticate(req *http.Request, verifier TokenVerifier) (auth.Claims, error) {
+	if claims, ok := gatewayClaims(req.Context()); ok {
+		return claims, nil
+	}
+
+	token, ok := auth.BearerToken(req.Header.Get("Authorization"))
+	if !ok {
+		err := exception.ErrUnauthorized
+		err.UICode = exception.Unauthorized
+
+		return auth.Claims{}, err
+	}
+
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		return auth.Claims{}, newTokenError(err)
+	}
+
+	return claims, nil
+}
+
+// gatewayClaims returns the claims of the API Gateway v2 JWT authorizer, or the context of
+// the Lambda authorizer of the authorizer command. They are only present in the context of
+// requests converted from a lambda event.
+func gatewayClaims(ctx context.Context) (auth.Claims, bool) {
+	gatewayContext, ok := core.GetAPIGatewayV2ContextFromContext(ctx)
+	if !ok || gatewayContext.Authorizer == nil {
+		return auth.Claims{}, false
+	}
+
+	if jwt := gatewayContext.Authorizer.JWT; jwt != nil {
+		return auth.NewGatewayClaims(jwt.Claims, jwt.Scopes), true
+	}
+
+	if lambdaContext := gatewayContext.Authorizer.Lambda; lambdaContext != nil {
+		return auth.NewAuthorizerClaims(lambdaContext), true
+	}
+
+	return auth.Claims{}, false
+}
+
+func newTokenError(err error) exception.ApplicationError {
+	appErr := exception.ErrUnauthorized
+	appErr.Cause = err
+
+	if errors.Is(err, auth.ErrTokenExpired) {
+		appErr.Localizable = lang.Localizable{MessageID: "errors.token_expired", Message: "token expired"}
+		appErr.UICode = exception.TokenExpired
+
+		return appErr
+	}
+
+	appErr.Localizable = lang.Localizable{MessageID: "errors.invalid_token", Message: "invalid token"}
+	appErr.UICode = exception.InvalidToken
+
+	return appErr
+}
+
+// RequireScopes rejects the requests of callers missing one of the scopes, anonymous requests
+// are rejected with 401 and the others with 403. It must run after AuthMiddleware.
+func RequireScopes(scopes ...string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			reqContext, _ := dto.RequestFromContext(req.Context())
+
+			if reqContext.Claims == nil {
+				err := exception.ErrUnauthorized
+				err.UICode = exception.Unauthorized
+
+				respWriter.Header().Set("WWW-Authenticate", "Bearer")
+				ErrorResponse(req.Context(), err, respWriter)
+
+				return
+			}
+
+			if !reqContext.Claims.HasScopes(scopes...) {
+				err := exception.ErrForbidden
+				err.UICode = exception.InsufficientScope
+				err.Localizable = lang.Localizable{
+					MessageID:   "errors.insufficient_scope",
+					Message:     "insufficient scope",
+					MessageVars: map[string]interface{}{"scopes": strings.Join(scopes, ", ")},
+				}
+
+				respWriter.Header().Set("WWW-Authenticate",
+					fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(scopes, " ")))
+				ErrorResponse(req.Context(), err, respWriter)
+
+				return
+			}
+
+			next.ServeHTTP(respWriter, req)
+		})
+	}
+}
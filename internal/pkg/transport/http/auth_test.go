@@ -0,0 +1,238 @@
+//go:build unit
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubVerifier map[string]error
+
+func (v stubVerifier) Verify(token string) (auth.Claims, error) {
+	if err, ok := v[token]; ok && err != nil {
+		return auth.Claims{}, err
+	} else if !ok {
+		return auth.Claims{}, auth.ErrInvalidToken
+	}
+
+	return auth.Claims{Subject: "user-1", Scopes: []string{"devices:read"}}, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	verifier := stubVerifier{"valid": nil, "expired": auth.ErrTokenExpired}
+
+	serve := func(req *http.Request, scopes ...string) (*httptest.ResponseRecorder, dto.RequestContext) {
+		var reqContext dto.RequestContext
+
+		handler := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			reqContext, _ = dto.RequestFromContext(req.Context())
+		})
+
+		resp := httptest.NewRecorder()
+		HeaderMiddleware()(AuthMiddleware(verifier)(RequireScopes(scopes...)(handler))).ServeHTTP(resp, req)
+
+		return resp, reqContext
+	}
+
+	uiCode := func(resp *httptest.ResponseRecorder) string {
+		var body dto.ErrorResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+
+		return body.UICode
+	}
+
+	t.Run("valid_token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/devices", nil)
+		req.Header.Set("Authorization", "Bearer valid")
+
+		resp, reqContext := serve(req, "devices:read")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "user-1", reqContext.Subject)
+		assert.Equal(t, []string{"devices:read"}, reqContext.Claims.Scopes)
+	})
+
+	testCases := []struct {
+		name          string
+		authorization string
+		scopes        []string
+		status        int
+		uiCode        string
+		authenticate  string
+	}{
+		{
+			name:         "missing_token",
+			status:       http.StatusUnauthorized,
+			uiCode:       exception.Unauthorized,
+			authenticate: "Bearer",
+		},
+		{
+			name:          "basic_scheme",
+			authorization: "Basic dXNlcjpwYXNz",
+			status:        http.StatusUnauthorized,
+			uiCode:        exception.Unauthorized,
+			authenticate:  "Bearer",
+		},
+		{
+			name:          "invalid_token",
+			authorization: "Bearer forged",
+			status:        http.StatusUnauthorized,
+			uiCode:        exception.InvalidToken,
+			authenticate:  "Bearer",
+		},
+		{
+			name:          "expired_token",
+			authorization: "Bearer expired",
+			status:        http.StatusUnauthorized,
+			uiCode:        exception.TokenExpired,
+			authenticate:  "Bearer",
+		},
+		{
+			name:          "missing_scope",
+			authorization: "Bearer valid",
+			scopes:        []string{"devices:write"},
+			status:        http.StatusForbidden,
+			uiCode:        exception.InsufficientScope,
+			authenticate:  `Bearer error="insufficient_scope", scope="devices:write"`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/devices", nil)
+			req.Header.Set("Authorization", testCase.authorization)
+
+			resp, _ := serve(req, testCase.scopes...)
+			assert.Equal(t, testCase.status, resp.Code)
+			assert.Equal(t, testCase.uiCode, uiCode(resp))
+			assert.Equal(t, testCase.authenticate, resp.Header().Get("WWW-Authenticate"))
+		})
+	}
+
+	t.Run("gateway_claims", func(t *testing.T) {
+		req, err := (&core.RequestAccessorV2{}).EventToRequestWithContext(context.Background(),
+			events.APIGatewayV2HTTPRequest{
+				Version:  "2.0",
+				RawPath:  "/api/devices",
+				Headers:  map[string]string{"host": "example.com"},
+				RouteKey: "GET /api/devices",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodGet, Path: "/api/devices"},
+					Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+						JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+							Claims: map[string]string{"sub": "gateway-user", "scope": "devices:write"},
+						},
+					},
+				},
+			})
+		assert.NoError(t, err)
+
+		resp, reqContext := serve(req, "devices:write")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "gateway-user", reqContext.Subject)
+	})
+
+	t.Run("lambda_authorizer_context", func(t *testing.T) {
+		req, err := (&core.RequestAccessorV2{}).EventToRequestWithContext(context.Background(),
+			events.APIGatewayV2HTTPRequest{
+				Version:  "2.0",
+				RawPath:  "/api/devices",
+				Headers:  map[string]string{"host": "example.com"},
+				RouteKey: "GET /api/devices",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodGet, Path: "/api/devices"},
+					Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+						Lambda: auth.NewAuthorizerContext(auth.Claims{Subject: "apikey:1", Scopes: []string{"devices:read"}}),
+					},
+				},
+			})
+		assert.NoError(t, err)
+
+		resp, reqContext := serve(req, "devices:read")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "apikey:1", reqContext.Subject)
+
+		resp, _ = serve(req, "devices:write")
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+}
+
+type stubAPIKeyVerifier map[string]error
+
+func (v stubAPIKeyVerifier) VerifyAPIKey(_ context.Context, key string) (auth.Claims, error) {
+	if err, ok := v[key]; !ok {
+		return auth.Claims{}, auth.ErrInvalidAPIKey
+	} else if err != nil {
+		return auth.Claims{}, err
+	}
+
+	return auth.Claims{Subject: "apikey:1", Scopes: []string{"devices:write"}}, nil
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	apiKeyVerifier := stubAPIKeyVerifier{"valid": nil, "unavailable": errors.New("table unavailable")}
+
+	serve := func(req *http.Request) (*httptest.ResponseRecorder, dto.RequestContext) {
+		var reqContext dto.RequestContext
+
+		handler := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			reqContext, _ = dto.RequestFromContext(req.Context())
+		})
+
+		resp := httptest.NewRecorder()
+		HeaderMiddleware()(APIKeyMiddleware(apiKeyVerifier)(AuthMiddleware(stubVerifier{"valid": nil})(
+			RequireScopes("devices:write")(handler)))).ServeHTTP(resp, req)
+
+		return resp, reqContext
+	}
+
+	t.Run("valid_key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/devices", nil)
+		req.Header.Set("X-API-Key", "valid")
+
+		resp, reqContext := serve(req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "apikey:1", reqContext.Subject)
+	})
+
+	t.Run("invalid_key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/devices", nil)
+		req.Header.Set("X-API-Key", "forged")
+		req.Header.Set("Authorization", "Bearer valid")
+
+		resp, _ := serve(req)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+		var body dto.ErrorResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, exception.InvalidAPIKey, body.UICode)
+	})
+
+	t.Run("lookup_error", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/devices", nil)
+		req.Header.Set("X-API-Key", "unavailable")
+
+		resp, _ := serve(req)
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+
+	t.Run("bearer_token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/devices", nil)
+		req.Header.Set("Authorization", "Bearer valid")
+
+		// the token only grants devices:read
+		resp, _ := serve(req)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+}
@@ -1,43 +1,190 @@
 package http
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/go-chi/render"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
 )
 
+// DefaultMaxRequestBodySize is the size limit of the request bodies when DecoderOptions.MaxBodySize
+// is zero.
+const DefaultMaxRequestBodySize = 1 << 20
+
+const formContentType = "application/x-www-form-urlencoded"
+
+// bodyMethods are the methods whose requests carry the fields of the request in their body.
+var bodyMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+
 func init() { //nolint:gochecknoinits
 	render.Decode = Decoder
 }
 
+// DecoderOptions configures the decoding of the request bodies.
+type DecoderOptions struct {
+	// MaxBodySize is the size limit of a request body in bytes, larger bodies are refused with
+	// 413. Zero uses DefaultMaxRequestBodySize.
+	MaxBodySize int64
+	// Strict refuses the bodies with unknown fields or with data after the JSON document.
+	Strict bool
+}
+
+func (o DecoderOptions) maxBodySize() int64 {
+	if o.MaxBodySize <= 0 {
+		return DefaultMaxRequestBodySize
+	}
+
+	return o.MaxBodySize
+}
+
+// decoderOptions configures Decoder.
+var decoderOptions DecoderOptions
+
+// SetDecoderOptions configures the decoding of the request bodies.
+func SetDecoderOptions(options DecoderOptions) {
+	decoderOptions = options
+}
+
+// Decoder decodes a JSON or form request body into val, a form is decoded like the JSON object
+// of its fields, typed after the JSON fields of val. POST, PUT and PATCH requests must send a
+// body when val has JSON fields, other requests may omit it. Bodies without content type or of
+// another content type are refused with 415, and a body which is too large or cannot be decoded
+// with 413 and 400.
 func Decoder(req *http.Request, val interface{}) error {
-	if req.Body == nil {
+	var body *bufio.Reader
+
+	if req.Body != nil && req.Body != http.NoBody {
+		maxBodySize := decoderOptions.maxBodySize()
+		if req.ContentLength > maxBodySize {
+			return errRequestBodyTooLarge(maxBodySize)
+		}
+
+		body = bufio.NewReader(http.MaxBytesReader(nil, req.Body, maxBodySize))
+		if _, err := body.Peek(1); errors.Is(err, io.EOF) {
+			body = nil
+		}
+	}
+
+	if body == nil {
+		if slices.Contains(bodyMethods, req.Method) && hasJSONFields(reflect.TypeOf(val)) {
+			return errRequestBodyRequired()
+		}
+
 		return nil
 	}
 
-	var err error
+	contentType := req.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
 
-	contentTypeHeader := req.Header.Get("Content-Type")
+	switch {
+	case contentType == "":
+		err := errUnsupportedMediaType(contentType)
+		err.Localizable = lang.Localizable{MessageID: "errors.content_type_required", Message: "content type required"}
+
+		return err
+	case err != nil:
+		return errUnsupportedMediaType(contentType)
+	case mediaType == formContentType:
+		err = decodeForm(body, val)
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		err = decodeJSON(body, val)
+	default:
+		return errUnsupportedMediaType(mediaType)
+	}
+
+	if maxBytesErr := new(http.MaxBytesError); errors.As(err, &maxBytesErr) {
+		return errRequestBodyTooLarge(maxBytesErr.Limit)
+	}
+
+	if err != nil {
+		return errInvalidRequestBody(err)
+	}
+
+	return nil
+}
+
+// StrictUnmarshaler is implemented by the requests decoding their own JSON, to refuse the unknown
+// fields in strict mode.
+type StrictUnmarshaler interface {
+	UnmarshalStrictJSON(data []byte) error
+}
+
+// decodeJSON decodes a JSON document into val, in strict mode the unknown fields and the data
+// after the document are refused.
+func decodeJSON(body io.Reader, val interface{}) error {
+	decoder := json.NewDecoder(body)
+	if decoderOptions.Strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decodeValue(decoder, val); err != nil {
+		return err
+	}
+
+	if !decoderOptions.Strict {
+		// the rest of the body is read so that it is bounded by the size limit too
+		_, err := io.Copy(io.Discard, body)
+
+		return err //nolint:wrapcheck
+	}
+
+	_, err := decoder.Token()
 
 	switch {
-	case strings.Contains(contentTypeHeader, "application/x-www-form-urlencoded"):
-		err = render.DecodeForm(req.Body, val)
-	case strings.Contains(contentTypeHeader, "application/json"):
-		err = render.DecodeJSON(req.Body, val)
+	case errors.Is(err, io.EOF):
+		return nil
+	case errors.As(err, new(*http.MaxBytesError)):
+		return err //nolint:wrapcheck
 	default:
-		err = render.DecodeJSON(req.Body, val)
+		return errors.New("unexpected data after the JSON document") //nolint:err113
+	}
+}
+
+func decodeValue(decoder *json.Decoder, val interface{}) error {
+	unmarshaler, ok := val.(StrictUnmarshaler)
+	if !ok || !decoderOptions.Strict {
+		return decoder.Decode(val) //nolint:wrapcheck
+	}
+
+	var document json.RawMessage
+	if err := decoder.Decode(&document); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return unmarshaler.UnmarshalStrictJSON(document) //nolint:wrapcheck
+}
+
+// decodeForm decodes a form into val through the JSON object of its fields.
+func decodeForm(body io.Reader, val interface{}) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err //nolint:wrapcheck
 	}
 
-	if err != nil && errors.Is(err, io.EOF) {
-		err = nil // not all requests have body
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return fmt.Errorf("parse form: %w", err)
+	}
+
+	document, err := json.Marshal(formObject(values, val))
+	if err != nil {
+		return fmt.Errorf("convert form: %w", err)
 	}
 
-	return err
+	return decodeJSON(bytes.NewReader(document), val)
 }
 
 func DecodeRequest[T any, PT interface {
@@ -53,3 +200,51 @@ func DecodeRequest[T any, PT interface {
 
 	return binder, nil
 }
+
+func errInvalidRequestBody(cause error) exception.ApplicationError {
+	return exception.ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID:   "errors.invalid_request_body",
+			Message:     "invalid request body",
+			MessageVars: map[string]interface{}{"message": cause.Error()},
+		},
+		StatusCode: exception.CodeBadRequest,
+		UICode:     exception.InvalidRequestBody,
+		Cause:      cause,
+	}
+}
+
+func errRequestBodyRequired() exception.ApplicationError {
+	return exception.ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID: "errors.request_body_required",
+			Message:   "request body required",
+		},
+		StatusCode: exception.CodeBadRequest,
+		UICode:     exception.RequestBodyRequired,
+	}
+}
+
+func errRequestBodyTooLarge(limit int64) exception.ApplicationError {
+	return exception.ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID:   "errors.request_body_too_large",
+			Message:     fmt.Sprintf("request body exceeds %d bytes", limit),
+			MessageVars: map[string]interface{}{"limit": limit},
+		},
+		StatusCode: exception.CodeTooLarge,
+		UICode:     exception.RequestBodyTooLarge,
+	}
+}
+
+func errUnsupportedMediaType(contentType string) exception.ApplicationError {
+	return exception.ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID:   "errors.unsupported_media_type",
+			Message:     "unsupported content type",
+			MessageVars: map[string]interface{}{"contentType": contentType},
+		},
+		StatusCode: exception.CodeUnsupported,
+		UICode:     exception.UnsupportedMediaType,
+	}
+}
@@ -4,10 +4,16 @@ package http
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -55,7 +61,7 @@ func TestBindInvalidRequest(t *testing.T) {
 func TestBindEmptyRequestBody(t *testing.T) {
 	ctx := context.Background()
 
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, "/dummy/url?bar=123", http.NoBody)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "/dummy/url?bar=123", http.NoBody)
 	assert.Nil(t, err)
 
 	request.Header.Add("Content-Type", "application/json")
@@ -73,10 +79,288 @@ func TestBindEmptyRequestBody(t *testing.T) {
 func TestBindBodyNilRequest(t *testing.T) {
 	ctx := context.Background()
 
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, "/dummy/url?bar=123", nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "/dummy/url?bar=123", nil)
 	assert.Nil(t, err)
 
 	_, err = DecodeRequest[dummyRequest](ctx, request)
 	assert.Nil(t, err)
 	assert.Equal(t, nil, nil)
 }
+
+type bodylessRequest struct {
+	ID string `json:"-"`
+}
+
+func (b *bodylessRequest) Bind(_ *http.Request) error {
+	return nil
+}
+
+func TestBindRequiredRequestBody(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch} {
+		t.Run(method, func(t *testing.T) {
+			for _, body := range []io.Reader{nil, http.NoBody, strings.NewReader("")} {
+				request := httptest.NewRequest(method, "/dummy/url", body)
+				request.Header.Set("Content-Type", "application/json")
+
+				_, err := DecodeRequest[dummyRequest](context.Background(), request)
+
+				var appErr exception.ApplicationError
+				if assert.ErrorAs(t, err, &appErr) {
+					assert.Equal(t, http.StatusBadRequest, appErr.StatusCode)
+					assert.Equal(t, exception.RequestBodyRequired, appErr.UICode)
+				}
+			}
+
+			// requests without body fields, like the restore actions, need no body
+			_, err := DecodeRequest[bodylessRequest](context.Background(), httptest.NewRequest(method, "/dummy/url", nil))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+type formRequest struct {
+	Name    string      `json:"name"`
+	Limit   int32       `json:"limit"`
+	Ratio   float64     `json:"ratio"`
+	Hard    bool        `json:"hard"`
+	Note    *string     `json:"note"`
+	IDs     []string    `json:"ids"`
+	Owner   formOwner   `json:"owner"`
+	Devices []formOwner `json:"devices"`
+	Ignored string      `json:"-"`
+}
+
+type formOwner struct {
+	Email string `json:"email"`
+}
+
+func (f *formRequest) Bind(_ *http.Request) error {
+	return nil
+}
+
+func setDecoderOptions(t *testing.T, options DecoderOptions) {
+	t.Helper()
+
+	SetDecoderOptions(options)
+	t.Cleanup(func() { SetDecoderOptions(DecoderOptions{}) })
+}
+
+func TestDecoderErrors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		options        DecoderOptions
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedUICode string
+	}{
+		{
+			name:           "unsupported_content_type",
+			contentType:    "text/plain",
+			body:           `{"foo": "heho"}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedUICode: exception.UnsupportedMediaType,
+		},
+		{
+			name:           "missing_content_type",
+			body:           `{"foo": "heho"}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedUICode: exception.UnsupportedMediaType,
+		},
+		{
+			name:           "invalid_content_type",
+			contentType:    "application/",
+			body:           `{"foo": "heho"}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedUICode: exception.UnsupportedMediaType,
+		},
+		{
+			name:           "too_large",
+			options:        DecoderOptions{MaxBodySize: 8},
+			contentType:    "application/json",
+			body:           `{"foo": "heho"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedUICode: exception.RequestBodyTooLarge,
+		},
+		{
+			name:           "too_large_after_document",
+			options:        DecoderOptions{MaxBodySize: 20},
+			contentType:    "application/json",
+			body:           `{"foo": "heho"}` + strings.Repeat(" ", 20),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedUICode: exception.RequestBodyTooLarge,
+		},
+		{
+			name:           "malformed",
+			contentType:    "application/json",
+			body:           `{"foo": }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedUICode: exception.InvalidRequestBody,
+		},
+		{
+			name:           "strict_unknown_field",
+			options:        DecoderOptions{Strict: true},
+			contentType:    "application/json",
+			body:           `{"foo": "heho", "baz": 1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedUICode: exception.InvalidRequestBody,
+		},
+		{
+			name:           "strict_trailing_data",
+			options:        DecoderOptions{Strict: true},
+			contentType:    "application/merge-patch+json",
+			body:           `{"foo": "heho"} {}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedUICode: exception.InvalidRequestBody,
+		},
+		{
+			name:           "strict_unknown_form_field",
+			options:        DecoderOptions{Strict: true},
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "foo=heho&baz=1",
+			expectedStatus: http.StatusBadRequest,
+			expectedUICode: exception.InvalidRequestBody,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			setDecoderOptions(t, testCase.options)
+
+			request := httptest.NewRequest(http.MethodPost, "/dummy/url", strings.NewReader(testCase.body))
+			if testCase.contentType != "" {
+				request.Header.Set("Content-Type", testCase.contentType)
+			}
+
+			_, err := DecodeRequest[dummyRequest](context.Background(), request)
+
+			var appErr exception.ApplicationError
+			if assert.ErrorAs(t, err, &appErr) {
+				assert.Equal(t, testCase.expectedStatus, appErr.StatusCode)
+				assert.Equal(t, testCase.expectedUICode, appErr.UICode)
+			}
+		})
+	}
+}
+
+func TestDecoderLenient(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "unknown_field", contentType: "application/json", body: `{"foo": "heho", "baz": 1}`},
+		{name: "trailing_data", contentType: "application/json", body: `{"foo": "heho"} {}`},
+		{name: "json_suffix", contentType: "application/merge-patch+json; charset=utf-8", body: `{"foo": "heho"}`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/dummy/url", strings.NewReader(testCase.body))
+			request.Header.Set("Content-Type", testCase.contentType)
+
+			decoded, err := DecodeRequest[dummyRequest](context.Background(), request)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "heho", decoded.(*dummyRequest).Foo)
+			}
+		})
+	}
+}
+
+func TestDecoderEmptyBodyOfAnyContentType(t *testing.T) {
+	setDecoderOptions(t, DecoderOptions{Strict: true})
+
+	request := httptest.NewRequest(http.MethodGet, "/dummy/url", strings.NewReader(""))
+	request.Header.Set("Content-Type", "text/plain")
+
+	_, err := DecodeRequest[dummyRequest](context.Background(), request)
+	assert.NoError(t, err)
+}
+
+func TestDecoderForm(t *testing.T) {
+	setDecoderOptions(t, DecoderOptions{Strict: true})
+
+	form := url.Values{
+		"name":            {"sensor"},
+		"limit":           {"20"},
+		"ratio":           {"-0.5"},
+		"hard":            {"true"},
+		"note":            {"lab"},
+		"ids":             {"id1", "id2"},
+		"owner.email":     {"a@b.c"},
+		"devices.0.email": {"d@e.f"},
+		"devices.1.email": {"g@h.i"},
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/dummy/url", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	decoded, err := DecodeRequest[formRequest](context.Background(), request)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	note := "lab"
+	assert.Equal(t, &formRequest{
+		Name:    "sensor",
+		Limit:   20,
+		Ratio:   -0.5,
+		Hard:    true,
+		Note:    &note,
+		IDs:     []string{"id1", "id2"},
+		Owner:   formOwner{Email: "a@b.c"},
+		Devices: []formOwner{{Email: "d@e.f"}, {Email: "g@h.i"}},
+	}, decoded)
+}
+
+func TestDecoderStrictPatch(t *testing.T) {
+	testCases := []struct {
+		name   string
+		strict bool
+		body   string
+		err    bool
+	}{
+		{name: "strict_unknown_member", strict: true, body: `{"bogus": 1}`, err: true},
+		{name: "strict_known_member", strict: true, body: `{"name": "sensor"}`},
+		{name: "lenient_unknown_member", body: `{"bogus": 1}`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			setDecoderOptions(t, DecoderOptions{Strict: testCase.strict})
+
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("id", "device-1")
+
+			request := httptest.NewRequest(http.MethodPatch, "/api/devices/device-1", strings.NewReader(testCase.body))
+			request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, routeContext))
+			request.Header.Set("Content-Type", "application/merge-patch+json")
+
+			_, err := DecodeRequest[dto.PatchDeviceRequest](request.Context(), request)
+
+			if !testCase.err {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			var appErr exception.ApplicationError
+			if assert.ErrorAs(t, err, &appErr) {
+				assert.Equal(t, exception.InvalidRequestBody, appErr.UICode)
+				assert.Contains(t, appErr.Error(), `unknown field "bogus"`)
+			}
+		})
+	}
+}
+
+func TestDecoderFormTypeMismatch(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/dummy/url", strings.NewReader("limit=twenty"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	_, err := DecodeRequest[formRequest](context.Background(), request)
+
+	var appErr exception.ApplicationError
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, exception.InvalidRequestBody, appErr.UICode)
+	}
+}
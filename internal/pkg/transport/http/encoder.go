@@ -12,6 +12,11 @@ import (
 	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
 )
 
+// ETagger is implemented by responses carrying an entity tag, which is sent in the ETag header.
+type ETagger interface {
+	ETag() string
+}
+
 // ResponseWithBody is the common method to encode all response types to the
 // client. I chose to do it this way because, since we're using JSON, there's no
 // reason to provide anything more specific. It's certainly possible to
@@ -19,6 +24,10 @@ import (
 func ResponseWithBody(_ context.Context, w http.ResponseWriter, response interface{}) error {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
+	if tagged, ok := response.(ETagger); ok {
+		w.Header().Set("ETag", tagged.ETag())
+	}
+
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		return fmt.Errorf("encode response body: %w", err)
 	}
@@ -38,37 +47,63 @@ func CreatedResponse(_ context.Context, w http.ResponseWriter, _ interface{}) er
 	return nil
 }
 
+// CreatedResponseWithBody encodes the response like ResponseWithBody with the 201 status.
+func CreatedResponseWithBody(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if tagged, ok := response.(ETagger); ok {
+		w.Header().Set("ETag", tagged.ETag())
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("encode response body: %w", err)
+	}
+
+	return nil
+}
+
+// problemDetails makes every error response a RFC 7807 problem details document, otherwise
+// only the clients accepting application/problem+json receive one.
+var problemDetails bool
+
+// SetProblemDetails enables RFC 7807 problem details error responses for all the clients.
+func SetProblemDetails(enabled bool) {
+	problemDetails = enabled
+}
+
+// ErrorResponse encodes err as {error, uiCode} or as a problem details document when the client
+// accepts application/problem+json or problem details are enabled. The errors caused by an expired
+// request deadline are answered with 504.
 func ErrorResponse(ctx context.Context, err error, respWriter http.ResponseWriter) {
-	var (
-		appErr  exception.ApplicationError
-		uiErr   string
-		message string
-	)
+	err = timeoutError(err)
 
-	respWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
-	slog.Info("error", "error", err)
+	slog.InfoContext(ctx, "error", "error", err)
 
-	if errors.As(err, &appErr) {
-		respWriter.WriteHeader(appErr.StatusCode)
+	if errors.As(err, new(exception.ApplicationError)) {
+		slog.Default().DebugContext(ctx, "error", "cause", err.Error())
+	}
 
-		reqContext, _ := dto.RequestFromContext(ctx)
-		// in case of failure to get request context, default language will be used
-		message = appErr.Localize(reqContext.Language)
+	respWriter.Header().Add("Vary", "Accept")
 
-		uiErr = appErr.UICode
+	if reqContext, _ := dto.RequestFromContext(ctx); problemDetails || reqContext.AcceptProblem {
+		statusCode, problem := dto.NewProblemDetails(ctx, err)
 
-		slog.Default().Debug("error", "cause", err.Error())
-	} else {
-		respWriter.WriteHeader(http.StatusInternalServerError)
+		respWriter.Header().Set("Content-Type", dto.ProblemContentType)
+		respWriter.WriteHeader(statusCode)
 
-		uiErr = exception.InternalServerError
+		//nolint:errcheck,errchkjson
+		json.NewEncoder(respWriter).Encode(problem)
 
-		message = err.Error()
+		return
 	}
 
+	statusCode, resp := dto.NewErrorResponse(ctx, err)
+
+	respWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	respWriter.WriteHeader(statusCode)
+
 	//nolint:errcheck,errchkjson
-	json.NewEncoder(respWriter).Encode(dto.ErrorResponse{
-		Error:  message,
-		UICode: uiErr,
-	})
+	json.NewEncoder(respWriter).Encode(resp)
 }
@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
 	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
 	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
 	"github.com/stretchr/testify/assert"
@@ -36,6 +37,69 @@ func TestEncodeErrorCustomError(t *testing.T) {
 	assert.JSONEq(t, `{"error": "invalid request", "uiCode": "INVALID_REQUEST"}`, resp.Body.String())
 }
 
+func TestEncodeErrorProblemDetails(t *testing.T) {
+	appErr := exception.ApplicationError{
+		StatusCode:  http.StatusBadRequest,
+		Localizable: lang.Localizable{Message: "invalid request"},
+		UICode:      exception.InvalidRequest,
+		Details: []exception.FieldViolation{
+			{Field: "name", Rule: "required", Localizable: lang.Localizable{Message: "name is required"}},
+		},
+	}
+	problem := `{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "invalid request",
+		"instance": "/api/devices?limit=10",
+		"uiCode": "INVALID_REQUEST",
+		"errors": [{"field": "name", "rule": "required", "message": "name is required"}]
+	}`
+
+	newContext := func(accept string) context.Context {
+		req := httptest.NewRequest(http.MethodGet, "/api/devices?limit=10", nil)
+		req.Header.Set("Accept", accept)
+
+		req, err := dto.RequestWithContext(req)
+		assert.NoError(t, err)
+
+		return req.Context()
+	}
+
+	t.Run("accept_header", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		ErrorResponse(newContext("application/json;q=0.5, application/problem+json"), appErr, resp)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+		assert.JSONEq(t, problem, resp.Body.String())
+	})
+
+	t.Run("default_shape", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		ErrorResponse(newContext("application/json, application/problem+json;q=0"), appErr, resp)
+
+		assert.Equal(t, "application/json; charset=utf-8", resp.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+			"error": "invalid request",
+			"uiCode": "INVALID_REQUEST",
+			"details": [{"field": "name", "rule": "required", "message": "name is required"}]
+		}`, resp.Body.String())
+	})
+
+	t.Run("config_flag", func(t *testing.T) {
+		SetProblemDetails(true)
+
+		t.Cleanup(func() { SetProblemDetails(false) })
+
+		resp := httptest.NewRecorder()
+		ErrorResponse(newContext("application/json"), appErr, resp)
+
+		assert.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+		assert.JSONEq(t, problem, resp.Body.String())
+	})
+}
+
 func TestEncodeJSONResponse(t *testing.T) {
 	resp := httptest.NewRecorder()
 	err := ResponseWithBody(context.Background(), resp, map[string]string{"foo": "bar"})
@@ -61,3 +125,20 @@ func TestCreatedResponse(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusCreated, resp.Code)
 }
+
+type taggedResponse struct {
+	Foo string `json:"foo"`
+}
+
+func (taggedResponse) ETag() string {
+	return `"1"`
+}
+
+func TestEncodeJSONResponseWithETag(t *testing.T) {
+	resp := httptest.NewRecorder()
+	err := ResponseWithBody(context.Background(), resp, taggedResponse{Foo: "bar"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `"1"`, resp.Result().Header.Get("ETag"))
+	assert.JSONEq(t, `{"foo": "bar"}`, resp.Body.String())
+}
@@ -0,0 +1,208 @@
+package http
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// formObject converts a form into the JSON object of the fields of val, so that it is decoded
+// like a JSON body. The values are typed after the JSON fields of val: repeated keys fill the
+// slices, dotted keys the nested objects, e.g. owner.email, and indexed keys the slices of
+// objects, e.g. devices.0.name. The keys matching no field are kept as strings, so that the
+// strict mode refuses them.
+func formObject(values url.Values, val interface{}) map[string]interface{} {
+	used := make(map[string]bool, len(values))
+	fields := make(map[string]interface{})
+
+	if typ := reflect.TypeOf(val); typ != nil {
+		if object, ok := formValue(values, "", typ, used); ok {
+			if objectFields, ok := object.(map[string]interface{}); ok {
+				fields = objectFields
+			}
+		}
+	}
+
+	for key, value := range values {
+		if !used[key] && len(value) > 0 {
+			fields[key] = value[0]
+		}
+	}
+
+	return fields
+}
+
+// formValue returns the JSON value of type typ held by the form values at key, false when the
+// form has none.
+func formValue(values url.Values, key string, typ reflect.Type, used map[string]bool) (interface{}, bool) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case isFormScalar(typ):
+		value, ok := values[key]
+		if !ok || len(value) == 0 {
+			return nil, false
+		}
+
+		used[key] = true
+
+		return formScalar(value[0], typ), true
+	case typ.Kind() == reflect.Slice && isFormScalar(typ.Elem()):
+		value, ok := values[key]
+		if !ok {
+			return nil, false
+		}
+
+		used[key] = true
+
+		items := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			items = append(items, formScalar(item, typ.Elem()))
+		}
+
+		return items, true
+	case typ.Kind() == reflect.Slice:
+		var items []interface{}
+
+		for index := 0; ; index++ {
+			item, ok := formValue(values, formKey(key, strconv.Itoa(index)), typ.Elem(), used)
+			if !ok {
+				break
+			}
+
+			items = append(items, item)
+		}
+
+		return items, len(items) > 0
+	case typ.Kind() == reflect.Struct:
+		object := make(map[string]interface{})
+		formFields(values, key, typ, used, object)
+
+		return object, len(object) > 0 || key == ""
+	default:
+		return nil, false
+	}
+}
+
+// formFields adds the JSON fields of the struct typ held by the form values under key to object,
+// the fields of the embedded structs included.
+func formFields(values url.Values, key string, typ reflect.Type, used map[string]bool, object map[string]interface{}) {
+	for index := range typ.NumField() {
+		field := typ.Field(index)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if name == "" && field.Anonymous && fieldType.Kind() == reflect.Struct {
+			formFields(values, key, fieldType, used, object)
+
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if value, ok := formValue(values, formKey(key, name), field.Type, used); ok {
+			object[name] = value
+		}
+	}
+}
+
+// formScalar converts a form value to the JSON value of a field of type typ. A value which does
+// not fit typ is kept as a string, so that decoding it reports the mismatch.
+func formScalar(value string, typ reflect.Type) interface{} {
+	if implementsUnmarshaler(typ) {
+		return value
+	}
+
+	switch typ.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if value != "" && (value[0] == '-' || ('0' <= value[0] && value[0] <= '9')) && json.Valid([]byte(value)) {
+			return json.Number(value)
+		}
+	}
+
+	return value
+}
+
+func isFormScalar(typ reflect.Type) bool {
+	if implementsUnmarshaler(typ) {
+		return true
+	}
+
+	switch typ.Kind() { //nolint:exhaustive
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// implementsUnmarshaler reports whether typ decodes itself from a JSON string, like time.Time.
+func implementsUnmarshaler(typ reflect.Type) bool {
+	ptr := reflect.PointerTo(typ)
+
+	return ptr.Implements(textUnmarshalerType) ||
+		(typ.Kind() != reflect.Struct && ptr.Implements(jsonUnmarshalerType))
+}
+
+// hasJSONFields reports whether the struct typ, or the struct typ points to, has fields decoded
+// from a JSON body.
+func hasJSONFields(typ reflect.Type) bool {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return false
+	}
+
+	for index := range typ.NumField() {
+		field := typ.Field(index)
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func formKey(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "." + name
+}
@@ -0,0 +1,271 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/app/model"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
+)
+
+// IdempotencyStore locks the idempotency keys of the requests and stores their responses.
+type IdempotencyStore interface {
+	// Lock locks key for the request with fingerprint, when the key is already used the stored
+	// record is returned with false.
+	Lock(ctx context.Context, key string, fingerprint string) (model.IdempotencyRecord, bool, error)
+	// Complete stores the response of a locked record and releases its lock.
+	Complete(ctx context.Context, record model.IdempotencyRecord) error
+	// Unlock releases the lock of a record without response so that the key can be retried.
+	Unlock(ctx context.Context, record model.IdempotencyRecord) error
+}
+
+const (
+	// IdempotencyKeyHeader is the header carrying the idempotency key of mutating requests.
+	IdempotencyKeyHeader = "X-Transaction-Id"
+	// IdempotentReplayedHeader is set on the responses replayed from a previous request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyRetryAfter is the Retry-After, in seconds, sent while a request is in progress.
+	idempotencyRetryAfter = "1"
+)
+
+// idempotentMethods are the methods whose requests are deduplicated.
+var idempotentMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+type idempotencyResponseWriter struct {
+	http.ResponseWriter
+	body       bytes.Buffer
+	statusCode int
+}
+
+func (r *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+
+	r.body.Write(b)
+
+	return r.ResponseWriter.Write(b) //nolint:wrapcheck
+}
+
+func (r *idempotencyResponseWriter) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+	}
+
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// IdempotencyMiddleware runs the mutating requests sent with an X-Transaction-Id header at most
+// once per tenant. The response of the first request is stored and replayed to the retries
+// sending the same request, reusing the key for another request is refused with 422 and a
+// retry arriving while the first request runs gets 409. Server errors are not stored so that
+// the request can be retried. It must run after TenantMiddleware.
+func IdempotencyMiddleware(store IdempotencyStore, logger *slog.Logger) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			key := req.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !slices.Contains(idempotentMethods, req.Method) {
+				next.ServeHTTP(respWriter, req)
+
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				ErrorResponse(req.Context(), errInvalidIdempotencyKey(), respWriter)
+
+				return
+			}
+
+			fingerprint, err := requestFingerprint(req)
+			if err != nil {
+				ErrorResponse(req.Context(), err, respWriter)
+
+				return
+			}
+
+			record, locked, err := store.Lock(req.Context(), key, fingerprint)
+			if err != nil {
+				ErrorResponse(req.Context(), err, respWriter)
+
+				return
+			}
+
+			if !locked {
+				replayResponse(req.Context(), record, fingerprint, respWriter)
+
+				return
+			}
+
+			serveIdempotent(store, logger, record, next, respWriter, req)
+		})
+	}
+}
+
+// serveIdempotent serves a request holding the lock of record and stores its response. The
+// lock is released when the response is not stored, including when the handler panics.
+func serveIdempotent(
+	store IdempotencyStore,
+	logger *slog.Logger,
+	record model.IdempotencyRecord,
+	next http.Handler,
+	respWriter http.ResponseWriter,
+	req *http.Request,
+) {
+	// the outcome is stored even when the client went away
+	ctx := context.WithoutCancel(req.Context())
+	completed := false
+
+	defer func() {
+		if completed {
+			return
+		}
+
+		if err := store.Unlock(ctx, record); err != nil {
+			logger.WarnContext(ctx, "failed to unlock idempotency key",
+				slog.String("key", record.Key), slog.String("error", err.Error()))
+		}
+	}()
+
+	header := respWriter.Header().Clone()
+	recorder := &idempotencyResponseWriter{ResponseWriter: respWriter}
+
+	next.ServeHTTP(recorder, req)
+
+	if recorder.statusCode == 0 {
+		recorder.statusCode = http.StatusOK
+	}
+
+	if recorder.statusCode >= http.StatusInternalServerError {
+		return
+	}
+
+	record.StatusCode = recorder.statusCode
+	record.Header = addedHeaders(header, respWriter.Header())
+	record.Body = recorder.body.Bytes()
+
+	if err := store.Complete(ctx, record); err != nil {
+		logger.WarnContext(ctx, "failed to store idempotent response",
+			slog.String("key", record.Key), slog.String("error", err.Error()))
+
+		return
+	}
+
+	completed = true
+}
+
+// replayResponse answers a request whose key is already used by record.
+func replayResponse(
+	ctx context.Context,
+	record model.IdempotencyRecord,
+	fingerprint string,
+	respWriter http.ResponseWriter,
+) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		ErrorResponse(ctx, errIdempotencyKeyReused(), respWriter)
+	case record.Locked():
+		respWriter.Header().Set("Retry-After", idempotencyRetryAfter)
+		ErrorResponse(ctx, errIdempotencyInProgress(), respWriter)
+	default:
+		for name, values := range record.Header {
+			respWriter.Header()[name] = values
+		}
+
+		respWriter.Header().Set(IdempotentReplayedHeader, "true")
+		respWriter.WriteHeader(record.StatusCode)
+		_, _ = respWriter.Write(record.Body)
+	}
+}
+
+// requestFingerprint hashes what identifies a request: its method, URI, caller and body. The
+// body is buffered up to the size limit of the decoder, larger bodies are refused with 413.
+func requestFingerprint(req *http.Request) (string, error) {
+	var body []byte
+
+	if req.Body != nil {
+		var err error
+
+		maxBodySize := decoderOptions.maxBodySize()
+
+		body, err = io.ReadAll(http.MaxBytesReader(nil, req.Body, maxBodySize))
+		if maxBytesErr := new(http.MaxBytesError); errors.As(err, &maxBytesErr) {
+			return "", errRequestBodyTooLarge(maxBytesErr.Limit)
+		}
+
+		if err != nil {
+			return "", err //nolint:wrapcheck
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	reqContext, _ := dto.RequestFromContext(req.Context())
+
+	hash := sha256.New()
+	for _, part := range []string{req.Method, req.URL.RequestURI(), reqContext.Subject} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// addedHeaders returns the headers of after that are missing from before or have changed, which
+// are the headers set by the handler.
+func addedHeaders(before, after http.Header) map[string][]string {
+	added := make(map[string][]string)
+
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			added[name] = slices.Clone(values)
+		}
+	}
+
+	return added
+}
+
+func errInvalidIdempotencyKey() exception.ApplicationError {
+	return exception.ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID: "errors.invalid_idempotency_key",
+			Message:   "invalid idempotency key",
+		},
+		StatusCode: exception.CodeBadRequest,
+		UICode:     exception.InvalidIdempotencyKey,
+	}
+}
+
+func errIdempotencyKeyReused() exception.ApplicationError {
+	return exception.ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID: "errors.idempotency",
+			Message:   "idempotency key already used by another request",
+		},
+		StatusCode: exception.CodeUnprocessable,
+		UICode:     exception.IdempotencyKeyReused,
+	}
+}
+
+func errIdempotencyInProgress() exception.ApplicationError {
+	err := exception.ErrConflict
+	err.Localizable = lang.Localizable{
+		MessageID: "errors.idempotency_in_progress",
+		Message:   "idempotent request in progress",
+	}
+	err.UICode = exception.IdempotencyInProgress
+
+	return err
+}
@@ -0,0 +1,206 @@
+//go:build unit
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/app/model"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubIdempotencyStore keeps the records in memory, keys are not scoped to a tenant.
+type stubIdempotencyStore struct {
+	records  map[string]model.IdempotencyRecord
+	unlocked []string
+}
+
+func newStubIdempotencyStore() *stubIdempotencyStore {
+	return &stubIdempotencyStore{records: make(map[string]model.IdempotencyRecord)}
+}
+
+func (s *stubIdempotencyStore) Lock(
+	_ context.Context,
+	key string,
+	fingerprint string,
+) (model.IdempotencyRecord, bool, error) {
+	if record, ok := s.records[key]; ok {
+		return record, false, nil
+	}
+
+	record := model.IdempotencyRecord{Key: key, Fingerprint: fingerprint, LockID: "lock-" + key}
+	s.records[key] = record
+
+	return record, true, nil
+}
+
+func (s *stubIdempotencyStore) Complete(_ context.Context, record model.IdempotencyRecord) error {
+	record.LockID = ""
+	s.records[record.Key] = record
+
+	return nil
+}
+
+func (s *stubIdempotencyStore) Unlock(_ context.Context, record model.IdempotencyRecord) error {
+	delete(s.records, record.Key)
+	s.unlocked = append(s.unlocked, record.Key)
+
+	return nil
+}
+
+func serveIdempotency(
+	store IdempotencyStore,
+	handler http.Handler,
+	method string,
+	key string,
+	body string,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/devices", strings.NewReader(body))
+	req = req.WithContext(dto.WithRequestContext(req.Context(), dto.RequestContext{Subject: "user-1"}))
+
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	resp := httptest.NewRecorder()
+	IdempotencyMiddleware(store, slog.Default())(handler).ServeHTTP(resp, req)
+
+	return resp
+}
+
+func assertUICode(t *testing.T, resp *httptest.ResponseRecorder, uiCode string) {
+	t.Helper()
+
+	var body dto.ErrorResponse
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, uiCode, body.UICode)
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	t.Run("replays the stored response", func(t *testing.T) {
+		store := newStubIdempotencyStore()
+		calls := 0
+		handler := http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			calls++
+
+			body, _ := io.ReadAll(req.Body)
+			respWriter.Header().Set("ETag", `"1"`)
+			respWriter.WriteHeader(http.StatusCreated)
+			_, _ = respWriter.Write([]byte(`{"id":`))
+			_, _ = respWriter.Write(body)
+			_, _ = respWriter.Write([]byte(`}`))
+		})
+
+		first := serveIdempotency(store, handler, http.MethodPost, "tx-1", `"1"`)
+		replay := serveIdempotency(store, handler, http.MethodPost, "tx-1", `"1"`)
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusCreated, replay.Code)
+		assert.Equal(t, `{"id":"1"}`, first.Body.String())
+		assert.Equal(t, first.Body.String(), replay.Body.String())
+		assert.Equal(t, `"1"`, replay.Header().Get("ETag"))
+		assert.Equal(t, "true", replay.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, map[string][]string{"Etag": {`"1"`}}, store.records["tx-1"].Header)
+	})
+
+	t.Run("refuses a reused key", func(t *testing.T) {
+		store := newStubIdempotencyStore()
+		handler := http.HandlerFunc(func(respWriter http.ResponseWriter, _ *http.Request) {
+			respWriter.WriteHeader(http.StatusNoContent)
+		})
+
+		serveIdempotency(store, handler, http.MethodPost, "tx-1", `{"id":"1"}`)
+		resp := serveIdempotency(store, handler, http.MethodPost, "tx-1", `{"id":"2"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+		assertUICode(t, resp, exception.IdempotencyKeyReused)
+
+		resp = serveIdempotency(store, handler, http.MethodPut, "tx-1", `{"id":"1"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	})
+
+	t.Run("refuses a body over the size limit", func(t *testing.T) {
+		setDecoderOptions(t, DecoderOptions{MaxBodySize: 8})
+
+		store := newStubIdempotencyStore()
+		resp := serveIdempotency(store, http.NotFoundHandler(), http.MethodPost, "tx-1", `{"id":"123456789"}`)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+		assertUICode(t, resp, exception.RequestBodyTooLarge)
+		assert.Empty(t, store.records)
+	})
+
+	t.Run("refuses a retry of a running request", func(t *testing.T) {
+		store := newStubIdempotencyStore()
+
+		var inner *httptest.ResponseRecorder
+
+		handler := http.HandlerFunc(func(respWriter http.ResponseWriter, _ *http.Request) {
+			if inner == nil {
+				inner = serveIdempotency(store, http.NotFoundHandler(), http.MethodPost, "tx-1", "body")
+			}
+
+			respWriter.WriteHeader(http.StatusNoContent)
+		})
+
+		resp := serveIdempotency(store, handler, http.MethodPost, "tx-1", "body")
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		assert.Equal(t, http.StatusConflict, inner.Code)
+		assert.Equal(t, "1", inner.Header().Get("Retry-After"))
+		assertUICode(t, inner, exception.IdempotencyInProgress)
+	})
+
+	t.Run("unlocks the key on server errors", func(t *testing.T) {
+		store := newStubIdempotencyStore()
+		handler := http.HandlerFunc(func(respWriter http.ResponseWriter, _ *http.Request) {
+			respWriter.WriteHeader(http.StatusInternalServerError)
+		})
+
+		resp := serveIdempotency(store, handler, http.MethodDelete, "tx-1", "")
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+		assert.Equal(t, []string{"tx-1"}, store.unlocked)
+		assert.Empty(t, store.records)
+	})
+
+	t.Run("unlocks the key on panics", func(t *testing.T) {
+		store := newStubIdempotencyStore()
+		handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		})
+
+		assert.Panics(t, func() {
+			serveIdempotency(store, handler, http.MethodPatch, "tx-1", "")
+		})
+		assert.Equal(t, []string{"tx-1"}, store.unlocked)
+	})
+
+	t.Run("ignores safe requests and requests without key", func(t *testing.T) {
+		store := newStubIdempotencyStore()
+		handler := http.HandlerFunc(func(respWriter http.ResponseWriter, _ *http.Request) {
+			respWriter.WriteHeader(http.StatusOK)
+		})
+
+		assert.Equal(t, http.StatusOK, serveIdempotency(store, handler, http.MethodPost, "", "").Code)
+		assert.Equal(t, http.StatusOK, serveIdempotency(store, handler, http.MethodGet, "tx-1", "").Code)
+		assert.Empty(t, store.records)
+	})
+
+	t.Run("refuses a too long key", func(t *testing.T) {
+		store := newStubIdempotencyStore()
+		resp := serveIdempotency(store, http.NotFoundHandler(), http.MethodPost, strings.Repeat("k", 256), "")
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assertUICode(t, resp, exception.InvalidIdempotencyKey)
+	})
+}
@@ -0,0 +1,376 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxLogBodySize is the number of bytes of a body logged when LoggingOptions.MaxBodySize
+// is zero.
+const DefaultMaxLogBodySize = 4096
+
+const (
+	redactedValue   = "[REDACTED]"
+	truncatedMarker = "...[TRUNCATED]"
+)
+
+// defaultRedactedHeaders are the credential headers which are never logged in clear.
+var defaultRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-API-Key"}
+
+// LoggingOptions configures LoggingMiddleware.
+type LoggingOptions struct {
+	// RedactFields are the body fields and query parameters whose values are replaced by
+	// [REDACTED], as dot-separated JSON paths matching the end of the field path, array indices
+	// excluded: serial redacts every serial field, device.serial only the ones of a device object.
+	RedactFields []string
+	// RedactHeaders are the request headers whose values are replaced by [REDACTED], on top of
+	// Authorization, Cookie, Set-Cookie and X-API-Key.
+	RedactHeaders []string
+	// MaxBodySize is the number of bytes of a body logged, longer bodies are truncated. Zero uses
+	// DefaultMaxLogBodySize and a negative size logs no body.
+	MaxBodySize int
+	// SuccessSampleRate is the fraction of the successful requests logged, between 0 and 1, zero
+	// logs none of them and nil all of them. Failed requests are always logged.
+	SuccessSampleRate *float64
+}
+
+func (o LoggingOptions) maxBodySize() int {
+	if o.MaxBodySize == 0 {
+		return DefaultMaxLogBodySize
+	}
+
+	return max(o.MaxBodySize, 0)
+}
+
+// bodyCapture keeps the first bytes of a body and counts its size.
+type bodyCapture struct {
+	limit int
+	data  []byte
+	size  int
+}
+
+func (c *bodyCapture) write(b []byte) {
+	c.size += len(b)
+
+	if room := c.limit - len(c.data); room > 0 {
+		c.data = append(c.data, b[:min(room, len(b))]...)
+	}
+}
+
+func (c *bodyCapture) truncated() bool {
+	return c.size > len(c.data)
+}
+
+// captureReader captures the part of a body read through it.
+type captureReader struct {
+	io.Reader
+	capture *bodyCapture
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.capture.write(p[:n])
+
+	return n, err //nolint:wrapcheck
+}
+
+// captureRequestBody reads the logged beginning of the request body, so that it is logged even
+// when the handler does not read it, and captures the rest as the handler reads it, without
+// buffering it.
+func captureRequestBody(req *http.Request, capture *bodyCapture) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	// one more byte tells whether the body is truncated
+	prefix, _ := io.ReadAll(io.LimitReader(req.Body, int64(capture.limit)+1))
+	capture.write(prefix)
+
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(bytes.NewReader(prefix), &captureReader{Reader: req.Body, capture: capture}),
+		Closer: req.Body,
+	}
+}
+
+// redactor renders the bodies and the headers of the logged requests.
+type redactor struct {
+	fields  [][]string
+	headers map[string]bool
+}
+
+func newRedactor(options LoggingOptions) *redactor {
+	redactor := &redactor{headers: make(map[string]bool)}
+
+	for _, field := range options.RedactFields {
+		if field = strings.TrimSpace(field); field != "" {
+			redactor.fields = append(redactor.fields, strings.Split(field, "."))
+		}
+	}
+
+	for _, header := range slices.Concat(defaultRedactedHeaders, options.RedactHeaders) {
+		if header = strings.TrimSpace(header); header != "" {
+			redactor.headers[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+
+	return redactor
+}
+
+// header returns the request headers with the redacted values replaced.
+func (r *redactor) header(header http.Header) map[string]string {
+	values := make(map[string]string, len(header))
+
+	for name, value := range header {
+		if r.headers[http.CanonicalHeaderKey(name)] {
+			values[name] = redactedValue
+		} else {
+			values[name] = strings.Join(value, ", ")
+		}
+	}
+
+	return values
+}
+
+// url renders a request URL with the redacted query parameters replaced.
+func (r *redactor) url(reqURL *url.URL) string {
+	redacted := *reqURL
+	if redacted.RawQuery != "" {
+		redacted.RawQuery = r.form([]byte(redacted.RawQuery), false)
+	}
+
+	return redacted.String()
+}
+
+// body renders a captured body of contentType with the redacted fields replaced. JSON bodies are
+// compacted, and a truncated body ends with the truncation marker.
+func (r *redactor) body(capture *bodyCapture, contentType string) string {
+	if len(capture.data) == 0 {
+		return ""
+	}
+
+	var body string
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	trimmed := bytes.TrimSpace(capture.data)
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		body = r.form(capture.data, capture.truncated())
+	case len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '['):
+		body = r.json(capture.data)
+	default:
+		body = string(capture.data)
+	}
+
+	if capture.truncated() {
+		body += truncatedMarker
+	}
+
+	return body
+}
+
+// json compacts a JSON document and redacts its fields. Only the valid beginning of a truncated
+// or invalid document is rendered, so the value cut by the truncation is never logged.
+func (r *redactor) json(data []byte) string {
+	var (
+		out     bytes.Buffer
+		decoder = json.NewDecoder(bytes.NewReader(data))
+		stack   []*jsonFrame
+	)
+
+	decoder.UseNumber()
+
+	valueDone := func() {
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			top.count++
+			top.expectKey = top.object
+		}
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return out.String()
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			out.WriteRune(rune(delim))
+
+			stack = stack[:len(stack)-1]
+
+			valueDone()
+
+			continue
+		}
+
+		if top != nil && top.expectKey {
+			key, _ := token.(string)
+			if top.count > 0 {
+				out.WriteByte(',')
+			}
+
+			out.Write(marshalToken(key))
+			out.WriteByte(':')
+
+			top.key = key
+			top.expectKey = false
+
+			continue
+		}
+
+		if top != nil && !top.object && top.count > 0 {
+			out.WriteByte(',')
+		}
+
+		if r.redacted(stack) {
+			out.Write(marshalToken(redactedValue))
+
+			if _, ok := token.(json.Delim); ok && !skipJSONValue(decoder) {
+				return out.String()
+			}
+
+			valueDone()
+
+			continue
+		}
+
+		if delim, ok := token.(json.Delim); ok {
+			out.WriteRune(rune(delim))
+
+			stack = append(stack, &jsonFrame{object: delim == '{', expectKey: delim == '{'})
+
+			continue
+		}
+
+		out.Write(marshalToken(token))
+		valueDone()
+	}
+}
+
+// form redacts the fields of a form body, the last field of a truncated body is dropped.
+func (r *redactor) form(data []byte, truncated bool) string {
+	body := string(data)
+	if truncated {
+		if index := strings.LastIndexByte(body, '&'); index >= 0 {
+			body = body[:index]
+		} else {
+			return ""
+		}
+	}
+
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return ""
+	}
+
+	for key := range values {
+		if r.matches(strings.Split(key, ".")) {
+			values[key] = []string{redactedValue}
+		}
+	}
+
+	return values.Encode()
+}
+
+// redacted reports whether the value in the current position of the JSON document is redacted.
+func (r *redactor) redacted(stack []*jsonFrame) bool {
+	if len(r.fields) == 0 || len(stack) == 0 || !stack[len(stack)-1].object {
+		return false
+	}
+
+	path := make([]string, 0, len(stack))
+	for _, frame := range stack {
+		if frame.object {
+			path = append(path, frame.key)
+		}
+	}
+
+	return r.matches(path)
+}
+
+// matches reports whether a field path ends with one of the redacted paths.
+func (r *redactor) matches(path []string) bool {
+	for _, field := range r.fields {
+		if len(field) > len(path) {
+			continue
+		}
+
+		suffix := path[len(path)-len(field):]
+
+		matched := true
+		for i := range field {
+			if !strings.EqualFold(field[i], suffix[i]) {
+				matched = false
+
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonFrame is an object or an array being rendered.
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+	key       string
+	count     int
+}
+
+// skipJSONValue consumes the tokens of the object or array which was just opened, it returns
+// false when the document ends before.
+func skipJSONValue(decoder *json.Decoder) bool {
+	for depth := 1; depth > 0; {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+
+	return true
+}
+
+// marshalToken encodes a scalar JSON token without escaping HTML characters.
+func marshalToken(token json.Token) []byte {
+	if number, ok := token.(json.Number); ok {
+		return []byte(number)
+	}
+
+	var out bytes.Buffer
+
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(token); err != nil {
+		return []byte("null")
+	}
+
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n"))
+}
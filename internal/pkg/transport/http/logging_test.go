@@ -0,0 +1,205 @@
+//go:build unit
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactorBody(t *testing.T) {
+	redactor := newRedactor(LoggingOptions{RedactFields: []string{"serial", "owner.email", " "}})
+
+	testCases := []struct {
+		name        string
+		body        string
+		limit       int
+		contentType string
+		expected    string
+	}{
+		{
+			name:     "nested_fields",
+			body:     `{"name": "sensor", "serial": "SN-1", "owner": {"email": "a@b.c", "name": "ann"}, "email": "x"}`,
+			expected: `{"name":"sensor","serial":"[REDACTED]","owner":{"email":"[REDACTED]","name":"ann"},"email":"x"}`,
+		},
+		{
+			name:     "array_elements",
+			body:     `{"devices": [{"serial": "SN-1", "n": 1.50}, {"serial": {"raw": [1, 2]}, "ok": true}], "tags": [null]}`,
+			expected: `{"devices":[{"serial":"[REDACTED]","n":1.50},{"serial":"[REDACTED]","ok":true}],"tags":[null]}`,
+		},
+		{
+			name:     "top_level_array",
+			body:     `[{"Serial": "SN-1"}, "<b>"]`,
+			expected: `[{"Serial":"[REDACTED]"},"<b>"]`,
+		},
+		{
+			name:     "truncated_value",
+			body:     `{"name": "sensor", "serial": "SN-123456789"}`,
+			limit:    35,
+			expected: `{"name":"sensor","serial":` + truncatedMarker,
+		},
+		{
+			name:     "truncated_string",
+			body:     `{"name": "sensor", "description": "a long text"}`,
+			limit:    40,
+			expected: `{"name":"sensor","description":` + truncatedMarker,
+		},
+		{
+			name:     "truncated_redacted_object",
+			body:     `{"serial": {"value": "SN-1"}}`,
+			limit:    20,
+			expected: `{"serial":"[REDACTED]"` + truncatedMarker,
+		},
+		{
+			name:        "form",
+			body:        "name=sensor&serial=SN-1",
+			contentType: "application/x-www-form-urlencoded; charset=utf-8",
+			expected:    "name=sensor&serial=%5BREDACTED%5D",
+		},
+		{
+			name:        "truncated_form",
+			body:        "name=sensor&serial=SN-1",
+			limit:       16,
+			contentType: "application/x-www-form-urlencoded",
+			expected:    "name=sensor" + truncatedMarker,
+		},
+		{
+			name:     "text",
+			body:     "plain text body",
+			limit:    5,
+			expected: "plain" + truncatedMarker,
+		},
+		{
+			name:     "empty",
+			expected: "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			limit := testCase.limit
+			if limit == 0 {
+				limit = DefaultMaxLogBodySize
+			}
+
+			capture := &bodyCapture{limit: limit}
+			capture.write([]byte(testCase.body))
+
+			assert.Equal(t, testCase.expected, redactor.body(capture, testCase.contentType))
+		})
+	}
+}
+
+func TestRedactorHeader(t *testing.T) {
+	redactor := newRedactor(LoggingOptions{RedactHeaders: []string{"x-session"}})
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer token")
+	header.Set("X-Api-Key", "key")
+	header.Set("X-Session", "session")
+	header.Add("Accept", "application/json")
+	header.Add("Accept", "text/plain")
+
+	assert.Equal(t, map[string]string{
+		"Authorization": redactedValue,
+		"X-Api-Key":     redactedValue,
+		"X-Session":     redactedValue,
+		"Accept":        "application/json, text/plain",
+	}, redactor.header(header))
+}
+
+func TestLoggingMiddlewareBodies(t *testing.T) {
+	var (
+		out     = new(bytes.Buffer)
+		logger  = slog.New(slog.NewJSONHandler(out, nil))
+		reqBody = `{"name":"sensor","serial":"SN-1","description":"` + strings.Repeat("a", 64) + `"}`
+		read    string
+		handler = func(respWriter http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			read = string(body)
+
+			respWriter.Header().Set("Content-Type", "application/json")
+			respWriter.WriteHeader(http.StatusBadRequest)
+			respWriter.Write([]byte(`{"serial":"SN-1",`))
+			respWriter.Write([]byte(`"message":"invalid"}`))
+		}
+		req = httptest.NewRequest(http.MethodPost, "/api/devices?serial=SN-1&limit=20", strings.NewReader(reqBody))
+	)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+
+	LoggingMiddleware(logger, LoggingOptions{RedactFields: []string{"serial"}, MaxBodySize: 48})(
+		http.HandlerFunc(handler)).ServeHTTP(httptest.NewRecorder(), req)
+
+	// the handler reads the whole body
+	assert.Equal(t, reqBody, read)
+
+	var log struct {
+		Request      string            `json:"request"`
+		RequestSize  int               `json:"request_size"`
+		Response     string            `json:"response"`
+		ResponseSize int               `json:"response_size"`
+		Headers      map[string]string `json:"headers"`
+		URL          string            `json:"url"`
+	}
+
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &log))
+	assert.Equal(t, `{"name":"sensor","serial":"[REDACTED]","description":`+truncatedMarker, log.Request)
+	assert.Equal(t, len(reqBody), log.RequestSize)
+	assert.Equal(t, `{"serial":"[REDACTED]","message":"invalid"}`, log.Response)
+	assert.Equal(t, 37, log.ResponseSize)
+	assert.Equal(t, redactedValue, log.Headers["Authorization"])
+	assert.Equal(t, "/api/devices?limit=20&serial=%5BREDACTED%5D", log.URL)
+	assert.NotContains(t, out.String(), "SN-1")
+	assert.NotContains(t, out.String(), "secret")
+}
+
+func TestLoggingMiddlewareSampling(t *testing.T) {
+	var (
+		none = 0.0
+		tiny = 1e-12
+		all  = 1.0
+	)
+
+	testCases := []struct {
+		name          string
+		rate          *float64
+		loggedSuccess bool
+	}{
+		{name: "unset", rate: nil, loggedSuccess: true},
+		{name: "zero", rate: &none, loggedSuccess: false},
+		{name: "fraction", rate: &tiny, loggedSuccess: false},
+		{name: "one", rate: &all, loggedSuccess: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var (
+				out     = new(bytes.Buffer)
+				logger  = slog.New(slog.NewJSONHandler(out, nil))
+				status  int
+				handler = LoggingMiddleware(logger, LoggingOptions{SuccessSampleRate: testCase.rate})(
+					http.HandlerFunc(func(respWriter http.ResponseWriter, _ *http.Request) {
+						respWriter.WriteHeader(status)
+					}))
+			)
+
+			status = http.StatusOK
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/devices", nil))
+			assert.Equal(t, testCase.loggedSuccess, strings.Contains(out.String(), "successfully processing request"))
+
+			status = http.StatusNotFound
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/devices", nil))
+			assert.Contains(t, out.String(), "error processing request")
+		})
+	}
+}
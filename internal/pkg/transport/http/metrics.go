@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RequestMetrics records the served requests.
+type RequestMetrics interface {
+	PanicMetrics
+	RecordRequest(route string, method string, statusCode int, latency time.Duration)
+}
+
+// unmatchedRoute is the route of the requests matching no route, so that unknown paths do not
+// create new metric dimensions.
+const unmatchedRoute = "unmatched"
+
+type statusResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *statusResponseWriter) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+
+	return r.ResponseWriter.Write(b) //nolint:wrapcheck
+}
+
+func (r *statusResponseWriter) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+	}
+
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// MetricsMiddleware records the status and the latency of the requests by route pattern, e.g.
+// /api/devices/{id}. It must be used by the root router so that the full pattern is known.
+func MetricsMiddleware(metrics RequestMetrics) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			recorder := &statusResponseWriter{ResponseWriter: respWriter}
+
+			next.ServeHTTP(recorder, req)
+
+			if recorder.statusCode == 0 {
+				recorder.statusCode = http.StatusOK
+			}
+
+			metrics.RecordRequest(routePattern(req.Context()), req.Method, recorder.statusCode, time.Since(start))
+		})
+	}
+}
+
+// routePattern returns the pattern of the route matched by the request of ctx, e.g.
+// /api/devices/{id}, or unmatchedRoute.
+func routePattern(ctx context.Context) string {
+	if routeContext := chi.RouteContext(ctx); routeContext != nil && routeContext.RoutePattern() != "" {
+		return routeContext.RoutePattern()
+	}
+
+	return unmatchedRoute
+}
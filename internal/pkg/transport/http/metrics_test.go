@@ -0,0 +1,61 @@
+//go:build unit
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedRequest struct {
+	route      string
+	method     string
+	statusCode int
+}
+
+type stubRequestMetrics struct {
+	requests []recordedRequest
+	panics   []recordedRequest
+}
+
+func (s *stubRequestMetrics) RecordPanic(route string, method string) {
+	s.panics = append(s.panics, recordedRequest{route: route, method: method})
+}
+
+func (s *stubRequestMetrics) RecordRequest(route string, method string, statusCode int, _ time.Duration) {
+	s.requests = append(s.requests, recordedRequest{route, method, statusCode})
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	metrics := &stubRequestMetrics{}
+
+	router := chi.NewRouter()
+	router.Use(MetricsMiddleware(metrics))
+	router.Route("/api/devices", func(router chi.Router) {
+		router.Get("/{id}", func(respWriter http.ResponseWriter, _ *http.Request) {
+			_, _ = respWriter.Write([]byte("{}"))
+		})
+		router.Delete("/{id}", func(respWriter http.ResponseWriter, _ *http.Request) {
+			respWriter.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/devices/d1", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/devices/d2", nil),
+		httptest.NewRequest(http.MethodGet, "/unknown/path", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, []recordedRequest{
+		{route: "/api/devices/{id}", method: http.MethodGet, statusCode: http.StatusOK},
+		{route: "/api/devices/{id}", method: http.MethodDelete, statusCode: http.StatusNoContent},
+		{route: unmatchedRoute, method: http.MethodGet, statusCode: http.StatusNotFound},
+	}, metrics.requests)
+}
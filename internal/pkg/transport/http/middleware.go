@@ -1,30 +1,31 @@
 package http
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
-	"io"
+	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"runtime/debug"
 	"time"
 
 	"github.com/go-chi/cors"
 	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
 )
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
 type loggingResponseWriter struct {
 	http.ResponseWriter // original response writer
-	body                []byte
+	body                *bodyCapture
 	statusCode          int
 }
 
 func (r *loggingResponseWriter) Write(b []byte) (int, error) {
 	size, err := r.ResponseWriter.Write(b) // write response using original http.ResponseWriter
-	r.body = b                             // capture response body
+	r.body.write(b[:size])                 // capture response body, across writes
 
 	return size, err //nolint:wrapcheck
 }
@@ -34,42 +35,46 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.statusCode = statusCode                // capture status code
 }
 
-func LoggingMiddleware(logger *slog.Logger) MiddlewareFunc {
+// LoggingMiddleware logs every failed request and a sample of the successful ones, with their
+// headers and the beginning of their bodies, redacted as configured by options.
+func LoggingMiddleware(logger *slog.Logger, options LoggingOptions) MiddlewareFunc {
+	redactor := newRedactor(options)
+	maxBodySize := options.maxBodySize()
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
 			var (
 				start             = time.Now()
-				loggingRespWriter = loggingResponseWriter{ResponseWriter: respWriter}
-				reqBytes          []byte
+				reqBody           = &bodyCapture{limit: maxBodySize}
+				loggingRespWriter = loggingResponseWriter{ResponseWriter: respWriter, body: &bodyCapture{limit: maxBodySize}}
 			)
 
-			if req.Body != nil {
-				defer req.Body.Close()
-				reqBytes, _ = io.ReadAll(req.Body)
-				req.Body = io.NopCloser(bytes.NewReader(reqBytes))
-			}
+			captureRequestBody(req, reqBody)
 
 			next.ServeHTTP(&loggingRespWriter, req)
 
-			var (
-				respBytes  = loggingRespWriter.body
-				statusCode = loggingRespWriter.statusCode
-			)
-
+			statusCode := loggingRespWriter.statusCode
 			if statusCode == 0 {
 				// use default status code
 				statusCode = http.StatusOK
 			}
 
+			if statusCode < http.StatusBadRequest && !sampled(options.SuccessSampleRate) {
+				return
+			}
+
 			attrs := []any{
 				slog.String("type", "inbound"),
 				slog.String("transport", "http"),
 				slog.Duration("duration_ns", time.Duration(time.Since(start).Nanoseconds())),
-				slog.String("url", req.URL.String()),
+				slog.String("url", redactor.url(req.URL)),
 				slog.String("method", req.Method),
 				slog.Int("status_code", statusCode),
-				slog.String("request", string(compactJSON(reqBytes))),
-				slog.String("response", string(compactJSON(respBytes))),
+				slog.Any("headers", redactor.header(req.Header)),
+				slog.String("request", redactor.body(reqBody, req.Header.Get("Content-Type"))),
+				slog.Int("request_size", reqBody.size),
+				slog.String("response", redactor.body(loggingRespWriter.body, respWriter.Header().Get("Content-Type"))),
+				slog.Int("response_size", loggingRespWriter.body.size),
 			}
 
 			if statusCode >= http.StatusBadRequest {
@@ -81,51 +86,112 @@ func LoggingMiddleware(logger *slog.Logger) MiddlewareFunc {
 	}
 }
 
-func compactJSON(jsonBytes []byte) []byte {
-	if len(jsonBytes) == 0 {
-		return jsonBytes
-	}
-
-	buffer := new(bytes.Buffer)
-
-	err := json.Compact(buffer, jsonBytes)
-	if err != nil {
-		return jsonBytes
+// sampled reports whether a successful request is logged, rate is between 0 and 1 and nil logs
+// every request.
+func sampled(rate *float64) bool {
+	if rate == nil {
+		return true
 	}
 
-	return buffer.Bytes()
+	return *rate >= 1 || (*rate > 0 && rand.Float64() < *rate) //nolint:gosec
 }
 
-func Recoverer(logger *slog.Logger) MiddlewareFunc {
+// Recoverer recovers the panics of the handlers and responds with a localized
+// INTERNAL_SERVER_ERROR, unless the response has already started. The panics are logged,
+// recorded by the metrics and a sanitized snapshot of the request is stored when configured.
+func Recoverer(logger *slog.Logger, options RecovererOptions) MiddlewareFunc {
+	redactor := newRedactor(options.Sanitize)
+	maxBodySize := options.Sanitize.maxBodySize()
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			var reqBody *bodyCapture
+			if options.Snapshots != nil {
+				reqBody = &bodyCapture{limit: maxBodySize}
+				captureRequestBody(req, reqBody)
+			}
+
+			recorder := &statusResponseWriter{ResponseWriter: respWriter}
+
 			defer func() {
-				if rvr := recover(); rvr != nil {
-					if err, _ := rvr.(error); errors.Is(err, http.ErrAbortHandler) {
-						// we don't recover http.ErrAbortHandler so the response
-						// to the client is aborted, this should not be logged
-						panic(rvr)
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+
+				if err, _ := rvr.(error); errors.Is(err, http.ErrAbortHandler) {
+					// we don't recover http.ErrAbortHandler so the response
+					// to the client is aborted, this should not be logged
+					panic(rvr)
+				}
+
+				ctx := req.Context()
+				stack := debug.Stack()
+
+				logger.ErrorContext(ctx, "panic occurred", slog.Any("message", rvr), slog.String("stack_trace", string(stack)))
+
+				if options.Metrics != nil {
+					options.Metrics.RecordPanic(routePattern(ctx), req.Method)
+				}
+
+				if options.Snapshots != nil {
+					snapshot := newPanicSnapshot(req, redactor, reqBody, rvr, stack)
+					if err := options.Snapshots.WriteSnapshot(ctx, snapshot); err != nil {
+						logger.WarnContext(ctx, "failed to write panic snapshot", slog.String("error", err.Error()))
 					}
+				}
 
-					logger.Error("panic occurred", slog.Any("message", rvr), slog.String("stack_trace", string(debug.Stack())))
-					respWriter.WriteHeader(http.StatusInternalServerError)
+				if recorder.statusCode != 0 {
+					// the status has been sent, the client gets a truncated response
+					return
 				}
+
+				appErr := exception.ErrInternal
+				appErr.UICode = exception.InternalServerError
+				appErr.Cause = fmt.Errorf("panic: %v", rvr) //nolint:err113
+
+				ErrorResponse(localizedContext(req), appErr, respWriter)
 			}()
 
-			next.ServeHTTP(respWriter, req)
+			next.ServeHTTP(recorder, req)
 		})
 	}
 }
 
+// localizedContext returns the context of req carrying the request context, which negotiates the
+// language of the error responses written before HeaderMiddleware runs.
+func localizedContext(req *http.Request) context.Context {
+	if _, ok := dto.RequestFromContext(req.Context()); ok {
+		return req.Context()
+	}
+
+	newReq, err := dto.RequestWithContext(req)
+	if err != nil {
+		return req.Context()
+	}
+
+	return newReq.Context()
+}
+
 // CORSMiddleware set CORS related headers.
 func CORSMiddleware(allowedOrigins []string) func(next http.Handler) http.Handler {
 	return cors.Handler(cors.Options{
 		AllowedOrigins: allowedOrigins, // allow swagger
 		AllowedMethods: []string{"GET", "POST", "PATCH", "PUT", "OPTIONS", "DELETE"},
-		AllowedHeaders: []string{"Authorization", "Origin", "Content-Type", "X-Timestamp", "X-Transaction-Id"},
+		AllowedHeaders: []string{
+			"Authorization", "Origin", "Content-Type", "Accept-Language",
+			"X-Timestamp", "X-Transaction-Id", "If-Match", "X-API-Key", "X-Tenant-Id", "X-Request-Id",
+			"Traceparent", "X-Amzn-Trace-Id",
+		},
+		ExposedHeaders: []string{
+			"ETag", "Content-Language", "WWW-Authenticate", "Retry-After", "Idempotent-Replayed",
+			"X-Request-Id", "X-Aws-Request-Id", "X-Apigw-Request-Id", "Traceparent", "X-Amzn-Trace-Id",
+		},
 	})
 }
 
+// HeaderMiddleware stores the request context built from the request headers and sets
+// Content-Language to the negotiated language.
 func HeaderMiddleware() func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
@@ -136,6 +202,11 @@ func HeaderMiddleware() func(next http.Handler) http.Handler {
 				return
 			}
 
+			if reqContext, ok := dto.RequestFromContext(newReq.Context()); ok {
+				respWriter.Header().Set("Content-Language", reqContext.Language)
+				respWriter.Header().Add("Vary", "Accept-Language")
+			}
+
 			next.ServeHTTP(respWriter, newReq)
 		})
 	}
@@ -6,12 +6,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -70,7 +74,7 @@ func TestLoggingMiddleware(t *testing.T) {
 				respRecorder = httptest.NewRecorder()
 			)
 
-			handlerWithLogging := LoggingMiddleware(logger)(http.HandlerFunc(handler))
+			handlerWithLogging := LoggingMiddleware(logger, LoggingOptions{})(http.HandlerFunc(handler))
 			handlerWithLogging.ServeHTTP(respRecorder, req)
 
 			var log structuredLog
@@ -110,7 +114,7 @@ func TestLoggingMiddlewareMinifyJSON(t *testing.T) {
 		respRecorder = httptest.NewRecorder()
 	)
 
-	handlerWithLogging := LoggingMiddleware(logger)(http.HandlerFunc(handler))
+	handlerWithLogging := LoggingMiddleware(logger, LoggingOptions{})(http.HandlerFunc(handler))
 	handlerWithLogging.ServeHTTP(respRecorder, req)
 
 	var log structuredLog
@@ -134,14 +138,122 @@ func TestPanicRecovererMiddleware(t *testing.T) {
 	)
 
 	assert.NotPanics(t, func() {
-		Recoverer(logger)(handler).ServeHTTP(respRecorder, req)
+		Recoverer(logger, RecovererOptions{})(handler).ServeHTTP(respRecorder, req)
 
 		var log structuredLog
-		err := json.Unmarshal(out.Bytes(), &log)
+		err := json.Unmarshal(bytes.SplitN(out.Bytes(), []byte("\n"), 2)[0], &log)
 		assert.Nil(t, err)
 
 		assert.NotEmpty(t, log.StackTrace)
 	})
+
+	assert.Equal(t, http.StatusInternalServerError, respRecorder.Code)
+	assert.Equal(t, "application/json; charset=utf-8", respRecorder.Header().Get("Content-Type"))
+	assert.Contains(t, respRecorder.Body.String(), `"uiCode":"INTERNAL_SERVER_ERROR"`)
+}
+
+type stubSnapshotSink struct {
+	snapshots []PanicSnapshot
+}
+
+//nolint:gocritic
+func (s *stubSnapshotSink) WriteSnapshot(_ context.Context, snapshot PanicSnapshot) error {
+	s.snapshots = append(s.snapshots, snapshot)
+
+	return nil
+}
+
+func TestPanicRecovererReporting(t *testing.T) {
+	var (
+		metrics  = &stubRequestMetrics{}
+		sink     = &stubSnapshotSink{}
+		logger   = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		recoverr = Recoverer(logger, RecovererOptions{
+			Metrics:   metrics,
+			Snapshots: sink,
+			Sanitize:  LoggingOptions{RedactFields: []string{"serial"}},
+		})
+		router = chi.NewRouter()
+	)
+
+	router.Use(RequestIDMiddleware())
+	router.Route("/api", func(router chi.Router) {
+		router.Use(recoverr)
+		router.Post("/devices/{id}", func(_ http.ResponseWriter, _ *http.Request) {
+			panic("nil map")
+		})
+		router.Get("/devices/{id}", func(respWriter http.ResponseWriter, _ *http.Request) {
+			respWriter.WriteHeader(http.StatusOK)
+			panic("after the status")
+		})
+	})
+
+	t.Run("localized_error", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/devices/d1?token=abc&serial=SN-2", strings.NewReader(`{"serial":"SN-1","name":"a"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Request-Id", "req-1")
+
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+		var body dto.ErrorResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, "INTERNAL_SERVER_ERROR", body.UICode)
+		assert.Equal(t, "req-1", body.RequestID)
+		assert.NotContains(t, body.Error, "nil map")
+
+		assert.Equal(t, []recordedRequest{{route: "/api/devices/{id}", method: http.MethodPost}}, metrics.panics)
+
+		if !assert.Len(t, sink.snapshots, 1) {
+			return
+		}
+
+		snapshot := sink.snapshots[0]
+		assert.Equal(t, "req-1", snapshot.RequestID)
+		assert.Equal(t, http.MethodPost, snapshot.Method)
+		assert.Equal(t, "/api/devices/d1?serial=%5BREDACTED%5D&token=abc", snapshot.URL)
+		assert.Equal(t, "/api/devices/{id}", snapshot.Route)
+		assert.Equal(t, redactedValue, snapshot.Headers["Authorization"])
+		assert.Equal(t, `{"serial":"[REDACTED]","name":"a"}`, snapshot.Body)
+		assert.Equal(t, "nil map", snapshot.Panic)
+		assert.NotEmpty(t, snapshot.Stack)
+	})
+
+	t.Run("response_started", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/devices/d1", nil))
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Body.String())
+		assert.Len(t, metrics.panics, 2)
+	})
+}
+
+func TestHeaderMiddleware(t *testing.T) {
+	lang.SetSupportedLanguages("en,es,id")
+
+	t.Cleanup(func() { lang.SetSupportedLanguages("en") })
+
+	var (
+		language string
+		handler  = http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			reqContext, _ := dto.RequestFromContext(req.Context())
+			language = reqContext.Language
+		})
+		respRecorder = httptest.NewRecorder()
+		req, _       = http.NewRequestWithContext(context.Background(), http.MethodGet, "http://example.com/api/v1/foo", nil)
+	)
+
+	req.Header.Set("Accept-Language", "es-MX, en;q=0.5")
+
+	HeaderMiddleware()(handler).ServeHTTP(respRecorder, req)
+
+	assert.Equal(t, "es", language)
+	assert.Equal(t, "es", respRecorder.Header().Get("Content-Language"))
+	assert.Equal(t, "Accept-Language", respRecorder.Header().Get("Vary"))
 }
 
 func unescapeUnquote(s string) string {
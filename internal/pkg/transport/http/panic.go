@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ijalalfrz/go-serverless/internal/pkg/requestid"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tracing"
+)
+
+// PanicMetrics records the requests whose handler panicked.
+type PanicMetrics interface {
+	RecordPanic(route string, method string)
+}
+
+// PanicSnapshot is the sanitized state of a request whose handler panicked, kept for the
+// post-mortem debugging.
+type PanicSnapshot struct {
+	Time      time.Time         `json:"time"`
+	RequestID string            `json:"requestId,omitempty"`
+	TraceID   string            `json:"traceId,omitempty"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Route     string            `json:"route"`
+	Headers   map[string]string `json:"headers"`
+	Body      string            `json:"body,omitempty"`
+	BodySize  int               `json:"bodySize"`
+	Panic     string            `json:"panic"`
+	Stack     string            `json:"stack"`
+}
+
+// SnapshotSink stores the snapshots of the requests whose handler panicked.
+type SnapshotSink interface {
+	WriteSnapshot(ctx context.Context, snapshot PanicSnapshot) error
+}
+
+// RecovererOptions configures Recoverer.
+type RecovererOptions struct {
+	// Metrics records the panics, nil disables it.
+	Metrics PanicMetrics
+	// Snapshots stores a snapshot of the panicking requests, nil disables it.
+	Snapshots SnapshotSink
+	// Sanitize redacts the headers and the body of the snapshots and bounds the body size, like
+	// in the request logs.
+	Sanitize LoggingOptions
+}
+
+// LogSnapshotSink logs the snapshots.
+type LogSnapshotSink struct {
+	logger *slog.Logger
+}
+
+// NewLogSnapshotSink creates a sink logging the snapshots to logger.
+func NewLogSnapshotSink(logger *slog.Logger) *LogSnapshotSink {
+	return &LogSnapshotSink{logger: logger}
+}
+
+//nolint:gocritic // the snapshot is passed by value by the SnapshotSink interface
+func (s *LogSnapshotSink) WriteSnapshot(ctx context.Context, snapshot PanicSnapshot) error {
+	s.logger.ErrorContext(ctx, "panic snapshot", slog.Any("snapshot", snapshot))
+
+	return nil
+}
+
+// FileSnapshotSink appends the snapshots to a file as JSON lines.
+type FileSnapshotSink struct {
+	mu  sync.Mutex
+	out io.WriteCloser
+}
+
+// NewFileSnapshotSink creates a sink appending the snapshots to the file at path.
+func NewFileSnapshotSink(path string) (*FileSnapshotSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) //nolint:mnd
+	if err != nil {
+		return nil, fmt.Errorf("failed to open panic snapshot file: %w", err)
+	}
+
+	return &FileSnapshotSink{out: file}, nil
+}
+
+//nolint:gocritic // the snapshot is passed by value by the SnapshotSink interface
+func (s *FileSnapshotSink) WriteSnapshot(_ context.Context, snapshot PanicSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode panic snapshot: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.out.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write panic snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the snapshot file.
+func (s *FileSnapshotSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.out.Close() //nolint:wrapcheck
+}
+
+// newPanicSnapshot snapshots req with its headers, query and body redacted.
+func newPanicSnapshot(
+	req *http.Request,
+	redactor *redactor,
+	body *bodyCapture,
+	rvr any,
+	stack []byte,
+) PanicSnapshot {
+	snapshot := PanicSnapshot{
+		Time:     time.Now().UTC(),
+		Method:   req.Method,
+		URL:      redactor.url(req.URL),
+		Route:    routePattern(req.Context()),
+		Headers:  redactor.header(req.Header),
+		Body:     redactor.body(body, req.Header.Get("Content-Type")),
+		BodySize: body.size,
+		Panic:    fmt.Sprint(rvr),
+		Stack:    string(stack),
+	}
+
+	if ids, ok := requestid.FromContext(req.Context()); ok {
+		snapshot.RequestID = ids.RequestID
+	}
+
+	if span := tracing.SpanFromContext(req.Context()); span != nil {
+		snapshot.TraceID = span.SpanContext().TraceID.String()
+	}
+
+	return snapshot
+}
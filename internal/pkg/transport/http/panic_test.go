@@ -0,0 +1,40 @@
+//go:build unit
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSnapshotSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panics.jsonl")
+
+	sink, err := NewFileSnapshotSink(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, sink.WriteSnapshot(context.Background(), PanicSnapshot{RequestID: "req-1", Panic: "boom"}))
+	assert.NoError(t, sink.WriteSnapshot(context.Background(), PanicSnapshot{RequestID: "req-2", Panic: "boom"}))
+	assert.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	if !assert.Len(t, lines, 2) {
+		return
+	}
+
+	var snapshot PanicSnapshot
+	assert.NoError(t, json.Unmarshal(lines[1], &snapshot))
+	assert.Equal(t, "req-2", snapshot.RequestID)
+	assert.Equal(t, "boom", snapshot.Panic)
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/requestid"
+)
+
+// RequestIDMiddleware identifies the request and stores its IDs in the context read by the
+// loggers and the error responses, the IDs are echoed in the response headers. The request ID
+// is taken from a valid X-Request-Id header, then from the API Gateway request ID, and is
+// generated otherwise. It must run first so that every log line carries the IDs.
+func RequestIDMiddleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			ids := requestIDs(req)
+
+			respWriter.Header().Set(requestid.Header, ids.RequestID)
+
+			if ids.AWSRequestID != "" {
+				respWriter.Header().Set(requestid.AWSHeader, ids.AWSRequestID)
+			}
+
+			if ids.GatewayRequestID != "" {
+				respWriter.Header().Set(requestid.GatewayHeader, ids.GatewayRequestID)
+			}
+
+			next.ServeHTTP(respWriter, req.WithContext(requestid.NewContext(req.Context(), ids)))
+		})
+	}
+}
+
+func requestIDs(req *http.Request) requestid.IDs {
+	ids := requestid.IDs{GatewayRequestID: gatewayRequestID(req.Context())}
+
+	if lambdaContext, ok := lambdacontext.FromContext(req.Context()); ok {
+		ids.AWSRequestID = lambdaContext.AwsRequestID
+	}
+
+	switch header := req.Header.Get(requestid.Header); {
+	case requestid.Valid(header):
+		ids.RequestID = header
+	case ids.GatewayRequestID != "":
+		ids.RequestID = ids.GatewayRequestID
+	default:
+		ids.RequestID = requestid.New()
+	}
+
+	return ids
+}
+
+// gatewayRequestID returns the requestContext.requestId of the API Gateway event the request
+// was converted from, it is empty for other event sources.
+func gatewayRequestID(ctx context.Context) string {
+	if gatewayContext, ok := core.GetAPIGatewayV2ContextFromContext(ctx); ok {
+		return gatewayContext.RequestID
+	}
+
+	if gatewayContext, ok := core.GetAPIGatewayContextFromContext(ctx); ok {
+		return gatewayContext.RequestID
+	}
+
+	return ""
+}
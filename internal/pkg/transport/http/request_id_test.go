@@ -0,0 +1,88 @@
+//go:build unit
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveRequestID(req *http.Request) (*httptest.ResponseRecorder, requestid.IDs) {
+	var ids requestid.IDs
+
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		ids, _ = requestid.FromContext(req.Context())
+	})
+
+	resp := httptest.NewRecorder()
+	RequestIDMiddleware()(handler).ServeHTTP(resp, req)
+
+	return resp, ids
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	t.Run("client_request_id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/devices", nil)
+		req.Header.Set(requestid.Header, "req-1")
+
+		resp, ids := serveRequestID(req)
+		assert.Equal(t, requestid.IDs{RequestID: "req-1"}, ids)
+		assert.Equal(t, "req-1", resp.Header().Get(requestid.Header))
+		assert.Empty(t, resp.Header().Get(requestid.AWSHeader))
+	})
+
+	t.Run("generated_request_id", func(t *testing.T) {
+		for _, header := range []string{"", "not a valid id"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/devices", nil)
+			req.Header.Set(requestid.Header, header)
+
+			resp, ids := serveRequestID(req)
+			assert.True(t, requestid.Valid(ids.RequestID))
+			assert.NotEqual(t, header, ids.RequestID)
+			assert.Equal(t, ids.RequestID, resp.Header().Get(requestid.Header))
+		}
+	})
+
+	t.Run("lambda_event", func(t *testing.T) {
+		ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "aws-1"})
+
+		req, err := (&core.RequestAccessorV2{}).EventToRequestWithContext(ctx, events.APIGatewayV2HTTPRequest{
+			Version:  "2.0",
+			RawPath:  "/api/devices",
+			Headers:  map[string]string{"host": "example.com"},
+			RouteKey: "GET /api/devices",
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				RequestID: "apigw-1",
+				HTTP:      events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodGet, Path: "/api/devices"},
+			},
+		})
+		assert.NoError(t, err)
+
+		resp, ids := serveRequestID(req)
+		assert.Equal(t, requestid.IDs{RequestID: "apigw-1", AWSRequestID: "aws-1", GatewayRequestID: "apigw-1"}, ids)
+		assert.Equal(t, "apigw-1", resp.Header().Get(requestid.Header))
+		assert.Equal(t, "aws-1", resp.Header().Get(requestid.AWSHeader))
+		assert.Equal(t, "apigw-1", resp.Header().Get(requestid.GatewayHeader))
+	})
+
+	t.Run("api_gateway_v1_event", func(t *testing.T) {
+		req, err := (&core.RequestAccessor{}).EventToRequestWithContext(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod:     http.MethodGet,
+			Path:           "/api/devices",
+			Headers:        map[string]string{"X-Request-Id": "req-1"},
+			RequestContext: events.APIGatewayProxyRequestContext{RequestID: "apigw-1"},
+		})
+		assert.NoError(t, err)
+
+		_, ids := serveRequestID(req)
+		assert.Equal(t, requestid.IDs{RequestID: "req-1", GatewayRequestID: "apigw-1"}, ids)
+	})
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
+)
+
+// TenantMiddleware resolves the tenant of the request and stores it in the request context and
+// in the context read by the repositories. The tenant claim of the caller wins, an X-Tenant-Id
+// header naming another tenant is refused. Authenticated callers without tenant claim must be
+// granted tenant.CrossTenantScope to select the tenant with the header, like every caller when
+// authentication is disabled. Requests without tenant are rejected. It must run after
+// AuthMiddleware.
+func TenantMiddleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			reqContext, _ := dto.RequestFromContext(req.Context())
+
+			tenantID, err := resolveTenant(req, reqContext)
+			if err != nil {
+				ErrorResponse(req.Context(), err, respWriter)
+
+				return
+			}
+
+			reqContext.Tenant = tenantID
+			ctx := tenant.NewContext(dto.WithRequestContext(req.Context(), reqContext), tenantID)
+
+			next.ServeHTTP(respWriter, req.WithContext(ctx))
+		})
+	}
+}
+
+func resolveTenant(req *http.Request, reqContext dto.RequestContext) (string, error) {
+	header := req.Header.Get(tenant.Header)
+
+	tenantID := header
+	if reqContext.Claims != nil && reqContext.Claims.Tenant != "" {
+		tenantID = reqContext.Claims.Tenant
+
+		if header != "" && header != tenantID {
+			err := exception.ErrForbidden
+			err.Localizable = lang.Localizable{MessageID: "errors.tenant_mismatch", Message: "tenant mismatch"}
+			err.UICode = exception.TenantMismatch
+
+			return "", err
+		}
+	}
+
+	claims := reqContext.Claims
+	if claims != nil && claims.Tenant == "" && !claims.HasScopes(tenant.CrossTenantScope) {
+		err := exception.ErrForbidden
+		err.Localizable = lang.Localizable{MessageID: "errors.tenant_not_granted", Message: "tenant not granted"}
+		err.UICode = exception.TenantNotGranted
+
+		return "", err
+	}
+
+	if tenantID == "" {
+		return "", tenant.ErrTenantRequired
+	}
+
+	if err := tenant.Validate(tenantID); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+
+	return tenantID, nil
+}
@@ -0,0 +1,94 @@
+//go:build unit
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ijalalfrz/go-serverless/internal/app/dto"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	testCases := []struct {
+		name   string
+		claims *auth.Claims
+		header string
+		status int
+		uiCode string
+		tenant string
+	}{
+		{name: "header", header: "acme", status: http.StatusOK, tenant: "acme"},
+		{name: "claim", claims: &auth.Claims{Tenant: "acme"}, status: http.StatusOK, tenant: "acme"},
+		{
+			name:   "claim_and_matching_header",
+			claims: &auth.Claims{Tenant: "acme"},
+			header: "acme",
+			status: http.StatusOK,
+			tenant: "acme",
+		},
+		{
+			name:   "claim_without_tenant",
+			claims: &auth.Claims{},
+			header: "globex",
+			status: http.StatusForbidden,
+			uiCode: exception.TenantNotGranted,
+		},
+		{
+			name:   "cross_tenant_scope",
+			claims: &auth.Claims{Scopes: []string{tenant.CrossTenantScope}},
+			header: "globex",
+			status: http.StatusOK,
+			tenant: "globex",
+		},
+		{
+			name:   "claim_and_other_header",
+			claims: &auth.Claims{Tenant: "acme"},
+			header: "globex",
+			status: http.StatusForbidden,
+			uiCode: exception.TenantMismatch,
+		},
+		{name: "missing", status: http.StatusBadRequest, uiCode: exception.TenantRequired},
+		{name: "invalid", header: "acme#DEVICE#1", status: http.StatusBadRequest, uiCode: exception.InvalidTenant},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var (
+				reqContext dto.RequestContext
+				tenantID   string
+			)
+
+			handler := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				reqContext, _ = dto.RequestFromContext(req.Context())
+				tenantID, _ = tenant.FromContext(req.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/devices", nil)
+			req = req.WithContext(dto.WithRequestContext(req.Context(), dto.RequestContext{Claims: testCase.claims}))
+
+			if testCase.header != "" {
+				req.Header.Set(tenant.Header, testCase.header)
+			}
+
+			resp := httptest.NewRecorder()
+			TenantMiddleware()(handler).ServeHTTP(resp, req)
+
+			assert.Equal(t, testCase.status, resp.Code)
+			assert.Equal(t, testCase.tenant, reqContext.Tenant)
+			assert.Equal(t, testCase.tenant, tenantID)
+
+			if testCase.uiCode != "" {
+				var body dto.ErrorResponse
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+				assert.Equal(t, testCase.uiCode, body.UICode)
+			}
+		})
+	}
+}
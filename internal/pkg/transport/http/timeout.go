@@ -0,0 +1,227 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/exception"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/lang"
+)
+
+// DefaultDeadlineMargin is the time kept before the deadline of the request context to answer
+// when TimeoutMiddleware is given no margin.
+const DefaultDeadlineMargin = 500 * time.Millisecond
+
+// TimeoutMiddleware bounds the requests by timeout and by the deadline of the request context,
+// the Lambda invocation deadline, minus margin, so that the timed out requests are answered
+// before Lambda kills the invocation. The handler runs on its own goroutine with a context
+// canceled on the deadline, which aborts its DynamoDB calls, and its response is buffered: when
+// the deadline passes before the handler returns, the request gets 504 and the response of the
+// handler is discarded. A zero timeout only applies the context
+// deadline and a zero margin uses DefaultDeadlineMargin.
+func TimeoutMiddleware(timeout time.Duration, margin time.Duration) MiddlewareFunc {
+	if margin <= 0 {
+		margin = DefaultDeadlineMargin
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			deadline, ok := requestDeadline(req.Context(), timeout, margin)
+			if !ok {
+				next.ServeHTTP(respWriter, req)
+
+				return
+			}
+
+			ctx, cancel := context.WithDeadline(req.Context(), deadline)
+			defer cancel()
+
+			// the router recycles its route context once the request returns, the handler which
+			// may outlive it routes on a copy
+			handlerCtx := ctx
+			routeContext := chi.RouteContext(ctx)
+			handlerRoute := copyRouteContext(routeContext)
+
+			if handlerRoute != nil {
+				handlerCtx = context.WithValue(ctx, chi.RouteCtxKey, handlerRoute)
+			}
+
+			buffer := &timeoutResponseWriter{header: respWriter.Header().Clone()}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+
+			go func() {
+				defer func() {
+					if rvr := recover(); rvr != nil {
+						panicked <- rvr
+
+						return
+					}
+
+					close(done)
+				}()
+
+				next.ServeHTTP(buffer, req.WithContext(handlerCtx))
+			}()
+
+			select {
+			case <-done:
+			case rvr := <-panicked:
+				panic(rvr)
+			case <-ctx.Done():
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					buffer.timeout()
+					ErrorResponse(localizedContext(req), errRequestTimeout(ctx.Err()), respWriter)
+
+					return
+				}
+
+				// the request is canceled, the handler aborts on its own
+				select {
+				case <-done:
+				case rvr := <-panicked:
+					panic(rvr)
+				}
+			}
+
+			if routeContext != nil {
+				routeContext.URLParams = handlerRoute.URLParams
+				routeContext.RoutePatterns = handlerRoute.RoutePatterns
+			}
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				ErrorResponse(localizedContext(req), errRequestTimeout(ctx.Err()), respWriter)
+
+				return
+			}
+
+			buffer.flush(respWriter)
+		})
+	}
+}
+
+// timeoutResponseWriter buffers the response of a handler run by TimeoutMiddleware, the writes
+// after the timeout fail with http.ErrHandlerTimeout.
+type timeoutResponseWriter struct {
+	mu         sync.Mutex
+	header     http.Header
+	body       bytes.Buffer
+	statusCode int
+	timedOut   bool
+}
+
+func (w *timeoutResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutResponseWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	return w.body.Write(b) //nolint:wrapcheck
+}
+
+func (w *timeoutResponseWriter) WriteHeader(statusCode int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.timedOut && w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *timeoutResponseWriter) timeout() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.timedOut = true
+}
+
+// flush writes the buffered response, once the handler has returned.
+func (w *timeoutResponseWriter) flush(respWriter http.ResponseWriter) {
+	header := respWriter.Header()
+	clear(header)
+	maps.Copy(header, w.header)
+
+	if w.statusCode != 0 {
+		respWriter.WriteHeader(w.statusCode)
+	}
+
+	if w.body.Len() > 0 {
+		_, _ = respWriter.Write(w.body.Bytes())
+	}
+}
+
+// copyRouteContext copies the route context of the router, nil when there is none.
+func copyRouteContext(routeContext *chi.Context) *chi.Context {
+	if routeContext == nil {
+		return nil
+	}
+
+	return &chi.Context{
+		Routes:      routeContext.Routes,
+		RoutePath:   routeContext.RoutePath,
+		RouteMethod: routeContext.RouteMethod,
+		URLParams: chi.RouteParams{
+			Keys:   slices.Clone(routeContext.URLParams.Keys),
+			Values: slices.Clone(routeContext.URLParams.Values),
+		},
+		RoutePatterns: slices.Clone(routeContext.RoutePatterns),
+	}
+}
+
+// requestDeadline returns the earliest of the timeout and of the context deadline minus margin,
+// false when there is neither.
+func requestDeadline(ctx context.Context, timeout time.Duration, margin time.Duration) (time.Time, bool) {
+	var deadline time.Time
+
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	if ctxDeadline, ok := ctx.Deadline(); ok {
+		ctxDeadline = ctxDeadline.Add(-margin)
+		if deadline.IsZero() || ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+	}
+
+	return deadline, !deadline.IsZero()
+}
+
+// timeoutError turns the errors caused by an expired deadline, like the DynamoDB calls aborted
+// by TimeoutMiddleware, into the timeout error. Application errors are kept.
+func timeoutError(err error) error {
+	if errors.As(err, new(exception.ApplicationError)) || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	return errRequestTimeout(err)
+}
+
+func errRequestTimeout(cause error) exception.ApplicationError {
+	return exception.ApplicationError{
+		Localizable: lang.Localizable{
+			MessageID: "errors.request_timeout",
+			Message:   "request timed out",
+		},
+		StatusCode: exception.CodeTimeout,
+		UICode:     exception.RequestTimeout,
+		Cause:      cause,
+	}
+}
@@ -0,0 +1,200 @@
+//go:build unit
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestDeadline(t *testing.T) {
+	lambdaCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lambdaDeadline, _ := lambdaCtx.Deadline()
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		timeout  time.Duration
+		expected time.Duration
+		ok       bool
+	}{
+		{
+			name:     "timeout",
+			ctx:      context.Background(),
+			timeout:  time.Second,
+			expected: time.Second,
+			ok:       true,
+		},
+		{
+			name:     "timeout_before_lambda_deadline",
+			ctx:      lambdaCtx,
+			timeout:  time.Second,
+			expected: time.Second,
+			ok:       true,
+		},
+		{
+			name:     "lambda_deadline_before_timeout",
+			ctx:      lambdaCtx,
+			timeout:  time.Minute,
+			expected: time.Until(lambdaDeadline) - 2*time.Second,
+			ok:       true,
+		},
+		{
+			name:     "lambda_deadline_only",
+			ctx:      lambdaCtx,
+			expected: time.Until(lambdaDeadline) - 2*time.Second,
+			ok:       true,
+		},
+		{
+			name: "none",
+			ctx:  context.Background(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			deadline, ok := requestDeadline(testCase.ctx, testCase.timeout, 2*time.Second)
+
+			assert.Equal(t, testCase.ok, ok)
+
+			if testCase.ok {
+				assert.WithinDuration(t, time.Now().Add(testCase.expected), deadline, 100*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "completed",
+			handler: func(respWriter http.ResponseWriter, _ *http.Request) {
+				respWriter.WriteHeader(http.StatusNoContent)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "call_aborted_by_deadline",
+			handler: func(respWriter http.ResponseWriter, req *http.Request) {
+				<-req.Context().Done()
+
+				ErrorResponse(req.Context(), fmt.Errorf("failed to get device: %w", req.Context().Err()), respWriter)
+			},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   `"uiCode":"REQUEST_TIMEOUT"`,
+		},
+		{
+			name: "unanswered",
+			handler: func(_ http.ResponseWriter, req *http.Request) {
+				<-req.Context().Done()
+			},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   `"uiCode":"REQUEST_TIMEOUT"`,
+		},
+		{
+			name: "answered_after_deadline",
+			handler: func(respWriter http.ResponseWriter, req *http.Request) {
+				<-req.Context().Done()
+
+				respWriter.WriteHeader(http.StatusCreated)
+			},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   `"uiCode":"REQUEST_TIMEOUT"`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var (
+				respRecorder = httptest.NewRecorder()
+				req          = httptest.NewRequest(http.MethodGet, "/api/devices", nil)
+			)
+
+			TimeoutMiddleware(10*time.Millisecond, 0)(testCase.handler).ServeHTTP(respRecorder, req)
+
+			assert.Equal(t, testCase.expectedStatus, respRecorder.Code)
+			assert.Contains(t, respRecorder.Body.String(), testCase.expectedBody)
+		})
+	}
+}
+
+func TestTimeoutMiddlewareHandlerIgnoringContext(t *testing.T) {
+	var (
+		respRecorder = httptest.NewRecorder()
+		req          = httptest.NewRequest(http.MethodGet, "/api/devices", nil)
+		release      = make(chan struct{})
+		written      = make(chan error, 1)
+	)
+
+	TimeoutMiddleware(10*time.Millisecond, 0)(http.HandlerFunc(func(respWriter http.ResponseWriter, _ *http.Request) {
+		// a call which does not abort on the deadline
+		<-release
+
+		_, err := respWriter.Write([]byte(`{"id":"1"}`))
+		written <- err
+	})).ServeHTTP(respRecorder, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, respRecorder.Code)
+	assert.Contains(t, respRecorder.Body.String(), `"uiCode":"REQUEST_TIMEOUT"`)
+
+	close(release)
+	assert.ErrorIs(t, <-written, http.ErrHandlerTimeout)
+	assert.NotContains(t, respRecorder.Body.String(), `"id"`)
+}
+
+func TestTimeoutMiddlewareRouteContext(t *testing.T) {
+	var (
+		router       = chi.NewRouter()
+		respRecorder = httptest.NewRecorder()
+		pattern      string
+	)
+
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(respWriter, req)
+
+			pattern = chi.RouteContext(req.Context()).RoutePattern()
+		})
+	})
+	router.Use(TimeoutMiddleware(time.Second, 0))
+	router.Get("/api/devices/{id}", func(respWriter http.ResponseWriter, req *http.Request) {
+		_, _ = respWriter.Write([]byte(chi.URLParam(req, "id")))
+	})
+
+	router.ServeHTTP(respRecorder, httptest.NewRequest(http.MethodGet, "/api/devices/device-1", nil))
+
+	assert.Equal(t, http.StatusOK, respRecorder.Code)
+	assert.Equal(t, "device-1", respRecorder.Body.String())
+	assert.Equal(t, "/api/devices/{id}", pattern)
+}
+
+func TestTimeoutMiddlewareLambdaDeadline(t *testing.T) {
+	var (
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		req         = httptest.NewRequest(http.MethodGet, "/api/devices", nil).WithContext(ctx)
+		deadline    time.Time
+	)
+
+	defer cancel()
+
+	TimeoutMiddleware(0, 0)(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		deadline, _ = req.Context().Deadline()
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	lambdaDeadline, _ := ctx.Deadline()
+	assert.Equal(t, lambdaDeadline.Add(-DefaultDeadlineMargin), deadline)
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/requestid"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tracing"
+)
+
+// lambdaTraceIDKey is the context key of the X-Ray trace header of a Lambda invocation, set by
+// the aws-lambda-go runtime.
+const lambdaTraceIDKey = "x-amzn-trace-id"
+
+// TracingMiddleware starts a server span for every request, child of the trace propagated in the
+// traceparent header, else in the X-Amzn-Trace-Id header, else by the Lambda invocation, and
+// echoes the span in both response headers. It must be used by the root router, after
+// RequestIDMiddleware, so that the span is named after the full route pattern.
+func TracingMiddleware(tracer *tracing.Tracer) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			if remote, ok := remoteSpanContext(req); ok {
+				ctx = tracing.ContextWithRemoteSpanContext(ctx, remote)
+			}
+
+			ctx, span := tracer.Start(ctx, req.Method, tracing.SpanKindServer)
+			defer span.End()
+
+			span.SetAttribute("http.request.method", req.Method)
+			span.SetAttribute("url.path", req.URL.Path)
+
+			if ids, ok := requestid.FromContext(ctx); ok {
+				span.SetAttribute("request_id", ids.RequestID)
+			}
+
+			respWriter.Header().Set(tracing.TraceparentHeader, tracing.FormatTraceparent(span.SpanContext()))
+			respWriter.Header().Set(tracing.XRayHeader, tracing.FormatXRay(span.SpanContext()))
+
+			recorder := &statusResponseWriter{ResponseWriter: respWriter}
+
+			next.ServeHTTP(recorder, req.WithContext(ctx))
+
+			if recorder.statusCode == 0 {
+				recorder.statusCode = http.StatusOK
+			}
+
+			if routeContext := chi.RouteContext(ctx); routeContext != nil && routeContext.RoutePattern() != "" {
+				span.SetName(req.Method + " " + routeContext.RoutePattern())
+				span.SetAttribute("http.route", routeContext.RoutePattern())
+			}
+
+			span.SetAttribute("http.response.status_code", recorder.statusCode)
+
+			if recorder.statusCode >= http.StatusInternalServerError {
+				span.RecordError(errors.New(http.StatusText(recorder.statusCode))) //nolint:err113
+			}
+		})
+	}
+}
+
+// remoteSpanContext returns the span context propagated to the request, the invalid headers are
+// ignored.
+func remoteSpanContext(req *http.Request) (tracing.SpanContext, bool) {
+	if header := req.Header.Get(tracing.TraceparentHeader); header != "" {
+		if sc, err := tracing.ParseTraceparent(header); err == nil {
+			return sc, true
+		}
+	}
+
+	if header := req.Header.Get(tracing.XRayHeader); header != "" {
+		if sc, err := tracing.ParseXRay(header); err == nil {
+			return sc, true
+		}
+	}
+
+	return lambdaSpanContext(req.Context())
+}
+
+// lambdaSpanContext returns the X-Ray trace of the Lambda invocation serving the request.
+func lambdaSpanContext(ctx context.Context) (tracing.SpanContext, bool) {
+	header, ok := ctx.Value(lambdaTraceIDKey).(string)
+	if !ok || header == "" {
+		return tracing.SpanContext{}, false
+	}
+
+	sc, err := tracing.ParseXRay(header)
+
+	return sc, err == nil
+}
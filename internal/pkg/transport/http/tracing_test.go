@@ -0,0 +1,125 @@
+//go:build unit
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubExporter struct {
+	spans []*tracing.Span
+}
+
+func (s *stubExporter) Export(_ context.Context, span *tracing.Span) {
+	s.spans = append(s.spans, span)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := &stubExporter{}
+
+	var handlerSpan *tracing.Span
+
+	router := chi.NewRouter()
+	router.Use(RequestIDMiddleware(), TracingMiddleware(tracing.NewTracer("test", exporter)))
+	router.Route("/api/devices", func(router chi.Router) {
+		router.Get("/{id}", func(respWriter http.ResponseWriter, req *http.Request) {
+			handlerSpan = tracing.SpanFromContext(req.Context())
+
+			respWriter.WriteHeader(http.StatusInternalServerError)
+		})
+	})
+
+	t.Run("traceparent", func(t *testing.T) {
+		exporter.spans = nil
+
+		req := httptest.NewRequest(http.MethodGet, "/api/devices/d1", nil)
+		req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		req.Header.Set("X-Amzn-Trace-Id", "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8")
+		req.Header.Set("X-Request-Id", "req-1")
+
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if !assert.Len(t, exporter.spans, 1) {
+			return
+		}
+
+		span := exporter.spans[0]
+		assert.Same(t, span, handlerSpan)
+		assert.Equal(t, "GET /api/devices/{id}", span.Name())
+		assert.Equal(t, tracing.SpanKindServer, span.Kind())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID.String())
+		assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanID().String())
+		assert.Equal(t, map[string]any{
+			"http.request.method":       http.MethodGet,
+			"url.path":                  "/api/devices/d1",
+			"http.route":                "/api/devices/{id}",
+			"http.response.status_code": http.StatusInternalServerError,
+			"request_id":                "req-1",
+		}, span.Attributes())
+		assert.Error(t, span.Err())
+		assert.Equal(t, tracing.FormatTraceparent(span.SpanContext()), resp.Header().Get("Traceparent"))
+		assert.Equal(t, tracing.FormatXRay(span.SpanContext()), resp.Header().Get("X-Amzn-Trace-Id"))
+	})
+
+	t.Run("x_ray", func(t *testing.T) {
+		exporter.spans = nil
+
+		req := httptest.NewRequest(http.MethodGet, "/api/devices/d1", nil)
+		req.Header.Set("Traceparent", "invalid")
+		req.Header.Set("X-Amzn-Trace-Id", "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8")
+
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if assert.Len(t, exporter.spans, 1) {
+			assert.Equal(t, "5759e988bd862e3fe1be46a994272793", exporter.spans[0].SpanContext().TraceID.String())
+			assert.Equal(t, "53995c3f42cd8ad8", exporter.spans[0].ParentSpanID().String())
+		}
+	})
+
+	t.Run("lambda_invocation", func(t *testing.T) {
+		exporter.spans = nil
+
+		//nolint:staticcheck // the key set by the aws-lambda-go runtime
+		ctx := context.WithValue(context.Background(), lambdaTraceIDKey,
+			"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/devices/d1", nil))
+
+		if assert.Len(t, exporter.spans, 1) {
+			assert.Equal(t, "5759e988bd862e3fe1be46a994272793", exporter.spans[0].SpanContext().TraceID.String())
+		}
+	})
+
+	t.Run("not_sampled", func(t *testing.T) {
+		exporter.spans = nil
+
+		req := httptest.NewRequest(http.MethodGet, "/api/devices/d1", nil)
+		req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Empty(t, exporter.spans)
+		assert.Contains(t, resp.Header().Get("Traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736")
+	})
+
+	t.Run("unmatched", func(t *testing.T) {
+		exporter.spans = nil
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+		if assert.Len(t, exporter.spans, 1) {
+			assert.Equal(t, http.MethodGet, exporter.spans[0].Name())
+			assert.NotContains(t, exporter.spans[0].Attributes(), "http.route")
+			assert.NoError(t, exporter.spans[0].Err())
+		}
+	})
+}
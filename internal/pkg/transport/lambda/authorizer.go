@@ -0,0 +1,154 @@
+package lambda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
+)
+
+// AuthorizerResponse is the response format of the Lambda authorizer.
+type AuthorizerResponse string
+
+const (
+	// AuthorizerResponseSimple answers with the isAuthorized flag of the simple response format.
+	AuthorizerResponseSimple AuthorizerResponse = "simple"
+	// AuthorizerResponseIAM answers with an IAM policy allowing or denying the API stage.
+	AuthorizerResponseIAM AuthorizerResponse = "iam"
+)
+
+// apiKeyHeader is the X-API-Key header, API Gateway v2 lower cases the header names.
+const apiKeyHeader = "x-api-key"
+
+var (
+	ErrUnknownAuthorizerResponse = errors.New("unknown authorizer response")
+	errMissingCredentials        = errors.New("missing credentials")
+)
+
+// TokenVerifier verifies a bearer token and returns its claims.
+type TokenVerifier interface {
+	Verify(token string) (auth.Claims, error)
+}
+
+// APIKeyVerifier verifies an API key and returns its claims.
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (auth.Claims, error)
+}
+
+// Authorizer is an API Gateway v2 Lambda authorizer accepting the bearer tokens and API keys
+// accepted by the API. The claims are returned as authorizer context, see auth.NewAuthorizerContext,
+// the API still checks the scopes of every route.
+type Authorizer struct {
+	tokens   TokenVerifier
+	apiKeys  APIKeyVerifier
+	response AuthorizerResponse
+}
+
+// NewAuthorizer creates an authorizer answering in the given response format, an empty format
+// is the simple one.
+func NewAuthorizer(tokens TokenVerifier, apiKeys APIKeyVerifier, response AuthorizerResponse) (*Authorizer, error) {
+	switch response {
+	case "":
+		response = AuthorizerResponseSimple
+	case AuthorizerResponseSimple, AuthorizerResponseIAM:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAuthorizerResponse, response)
+	}
+
+	return &Authorizer{tokens: tokens, apiKeys: apiKeys, response: response}, nil
+}
+
+// Invoke authorizes one request of the 2.0 payload format, it is meant to be passed to
+// lambda.Start. Refused credentials are denied, other failures are returned so that the
+// gateway answers with a server error rather than a denial.
+func (a *Authorizer) Invoke(ctx context.Context, event events.APIGatewayV2CustomAuthorizerV2Request) (interface{}, error) {
+	claims, err := a.authenticate(ctx, event.Headers)
+	if err != nil && !isRefused(err) {
+		return nil, err
+	}
+
+	authorized := err == nil
+	if !authorized {
+		slog.InfoContext(ctx, "request denied", slog.String("route", event.RouteKey), slog.String("reason", err.Error()))
+	}
+
+	var authorizerContext map[string]interface{}
+	if authorized {
+		authorizerContext = auth.NewAuthorizerContext(claims)
+	}
+
+	if a.response == AuthorizerResponseSimple {
+		return events.APIGatewayV2CustomAuthorizerSimpleResponse{
+			IsAuthorized: authorized,
+			Context:      authorizerContext,
+		}, nil
+	}
+
+	principalID, effect := "anonymous", "Deny"
+	if authorized {
+		principalID, effect = claims.Subject, "Allow"
+	}
+
+	return events.APIGatewayV2CustomAuthorizerIAMPolicyResponse{
+		PrincipalID: principalID,
+		PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+			Version: "2012-10-17",
+			Statement: []events.IAMPolicyStatement{
+				{
+					Action:   []string{"execute-api:Invoke"},
+					Effect:   effect,
+					Resource: []string{stageResource(event.RouteArn)},
+				},
+			},
+		},
+		Context: authorizerContext,
+	}, nil
+}
+
+// authenticate verifies the API key, or the bearer token when no key is sent, like the
+// APIKeyMiddleware and AuthMiddleware of the HTTP transport.
+func (a *Authorizer) authenticate(ctx context.Context, headers map[string]string) (auth.Claims, error) {
+	if key := headers[apiKeyHeader]; key != "" {
+		claims, err := a.apiKeys.VerifyAPIKey(ctx, key)
+		if err != nil {
+			return auth.Claims{}, fmt.Errorf("verify api key: %w", err)
+		}
+
+		return claims, nil
+	}
+
+	token, ok := auth.BearerToken(headers["authorization"])
+	if !ok {
+		return auth.Claims{}, errMissingCredentials
+	}
+
+	claims, err := a.tokens.Verify(token)
+	if err != nil {
+		return auth.Claims{}, fmt.Errorf("verify token: %w", err)
+	}
+
+	return claims, nil
+}
+
+func isRefused(err error) bool {
+	return errors.Is(err, errMissingCredentials) ||
+		errors.Is(err, auth.ErrInvalidToken) ||
+		errors.Is(err, auth.ErrTokenExpired) ||
+		errors.Is(err, auth.ErrInvalidAPIKey)
+}
+
+// stageResource widens the route ARN to every route of the stage, so that a policy cached by
+// the gateway for the caller credentials is valid for the other routes as well.
+func stageResource(routeArn string) string {
+	// arn:aws:execute-api:region:account:api-id/stage/METHOD/path
+	parts := strings.SplitN(routeArn, "/", 3) //nolint:gomnd
+	if len(parts) < 2 {                       //nolint:gomnd
+		return routeArn
+	}
+
+	return parts[0] + "/" + parts[1] + "/*"
+}
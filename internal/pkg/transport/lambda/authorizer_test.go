@@ -0,0 +1,116 @@
+//go:build unit
+
+package lambda
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ijalalfrz/go-serverless/internal/pkg/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTableUnavailable = errors.New("table unavailable")
+
+type stubVerifier struct{}
+
+func (stubVerifier) Verify(token string) (auth.Claims, error) {
+	if token != "valid" {
+		return auth.Claims{}, auth.ErrInvalidToken
+	}
+
+	return auth.Claims{Subject: "user-1", Scopes: []string{"devices:read"}}, nil
+}
+
+func (stubVerifier) VerifyAPIKey(_ context.Context, key string) (auth.Claims, error) {
+	switch key {
+	case "valid":
+		return auth.Claims{Subject: "apikey:1", Scopes: []string{"devices:write"}}, nil
+	case "unavailable":
+		return auth.Claims{}, errTableUnavailable
+	default:
+		return auth.Claims{}, auth.ErrInvalidAPIKey
+	}
+}
+
+func authorizerRequest(headers map[string]string) events.APIGatewayV2CustomAuthorizerV2Request {
+	return events.APIGatewayV2CustomAuthorizerV2Request{
+		Version:  "2.0",
+		Type:     "REQUEST",
+		RouteArn: "arn:aws:execute-api:ap-southeast-1:123456789012:abcdef123/prod/GET/api/devices",
+		RouteKey: "GET /api/devices",
+		Headers:  headers,
+	}
+}
+
+func TestAuthorizer_Simple(t *testing.T) {
+	authorizer, err := NewAuthorizer(stubVerifier{}, stubVerifier{}, "")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		headers    map[string]string
+		authorized bool
+		subject    string
+	}{
+		{name: "bearer_token", headers: map[string]string{"authorization": "Bearer valid"}, authorized: true, subject: "user-1"},
+		{name: "api_key", headers: map[string]string{"x-api-key": "valid"}, authorized: true, subject: "apikey:1"},
+		{name: "invalid_token", headers: map[string]string{"authorization": "Bearer forged"}},
+		{name: "invalid_api_key", headers: map[string]string{"x-api-key": "forged", "authorization": "Bearer valid"}},
+		{name: "missing_credentials", headers: map[string]string{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resp, err := authorizer.Invoke(context.Background(), authorizerRequest(testCase.headers))
+			assert.NoError(t, err)
+
+			simple, ok := resp.(events.APIGatewayV2CustomAuthorizerSimpleResponse)
+			assert.True(t, ok)
+			assert.Equal(t, testCase.authorized, simple.IsAuthorized)
+
+			if testCase.authorized {
+				assert.Equal(t, testCase.subject, simple.Context["sub"])
+			} else {
+				assert.Nil(t, simple.Context)
+			}
+		})
+	}
+
+	t.Run("lookup_error", func(t *testing.T) {
+		_, err := authorizer.Invoke(context.Background(), authorizerRequest(map[string]string{"x-api-key": "unavailable"}))
+		assert.ErrorIs(t, err, errTableUnavailable)
+	})
+}
+
+func TestAuthorizer_IAM(t *testing.T) {
+	authorizer, err := NewAuthorizer(stubVerifier{}, stubVerifier{}, AuthorizerResponseIAM)
+	assert.NoError(t, err)
+
+	resp, err := authorizer.Invoke(context.Background(), authorizerRequest(map[string]string{"authorization": "Bearer valid"}))
+	assert.NoError(t, err)
+
+	policy, ok := resp.(events.APIGatewayV2CustomAuthorizerIAMPolicyResponse)
+	assert.True(t, ok)
+	assert.Equal(t, "user-1", policy.PrincipalID)
+	assert.Equal(t, "devices:read", policy.Context["scope"])
+	assert.Equal(t, []events.IAMPolicyStatement{{
+		Action:   []string{"execute-api:Invoke"},
+		Effect:   "Allow",
+		Resource: []string{"arn:aws:execute-api:ap-southeast-1:123456789012:abcdef123/prod/*"},
+	}}, policy.PolicyDocument.Statement)
+
+	resp, err = authorizer.Invoke(context.Background(), authorizerRequest(map[string]string{}))
+	assert.NoError(t, err)
+
+	policy, ok = resp.(events.APIGatewayV2CustomAuthorizerIAMPolicyResponse)
+	assert.True(t, ok)
+	assert.Equal(t, "Deny", policy.PolicyDocument.Statement[0].Effect)
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	_, err := NewAuthorizer(stubVerifier{}, stubVerifier{}, "token")
+	assert.ErrorIs(t, err, ErrUnknownAuthorizerResponse)
+}
@@ -0,0 +1,230 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+// EventSource is the service invoking the function with HTTP requests.
+type EventSource string
+
+const (
+	// EventSourceAuto detects the event source of every invocation from its payload.
+	EventSourceAuto         EventSource = "auto"
+	EventSourceAPIGatewayV1 EventSource = "apigateway_v1"
+	EventSourceAPIGatewayV2 EventSource = "apigateway_v2"
+	EventSourceALB          EventSource = "alb"
+	EventSourceFunctionURL  EventSource = "function_url"
+)
+
+var ErrUnknownEventSource = errors.New("unknown event source")
+
+// Handler adapts an http.Handler to the events of API Gateway REST (v1) and HTTP (v2) APIs,
+// Application Load Balancers and Lambda Function URLs.
+type Handler struct {
+	handler http.Handler
+	source  EventSource
+	v1      core.RequestAccessor
+	v2      core.RequestAccessorV2
+	alb     core.RequestAccessorALB
+}
+
+// NewHandler creates a handler for the given event source, an empty source is detected
+// like EventSourceAuto.
+func NewHandler(handler http.Handler, source EventSource) (*Handler, error) {
+	switch source {
+	case "":
+		source = EventSourceAuto
+	case EventSourceAuto, EventSourceAPIGatewayV1, EventSourceAPIGatewayV2, EventSourceALB, EventSourceFunctionURL:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventSource, source)
+	}
+
+	return &Handler{handler: handler, source: source}, nil
+}
+
+// Invoke serves one invocation, the response has the shape expected by the event source.
+// It is meant to be passed to lambda.Start.
+func (h *Handler) Invoke(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	source := h.source
+	if source == EventSourceAuto {
+		var err error
+
+		source, err = DetectEventSource(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch source {
+	case EventSourceAPIGatewayV1:
+		return h.invokeAPIGatewayV1(ctx, payload)
+	case EventSourceALB:
+		return h.invokeALB(ctx, payload)
+	case EventSourceFunctionURL:
+		resp, err := h.invokeAPIGatewayV2(ctx, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		// the function URL payload format is the one of HTTP APIs
+		return events.LambdaFunctionURLResponse{
+			StatusCode:      resp.StatusCode,
+			Headers:         resp.Headers,
+			Body:            resp.Body,
+			IsBase64Encoded: resp.IsBase64Encoded,
+			Cookies:         resp.Cookies,
+		}, nil
+	default:
+		return h.invokeAPIGatewayV2(ctx, payload)
+	}
+}
+
+// DetectEventSource tells the event source from the shape of an invocation payload.
+func DetectEventSource(payload []byte) (EventSource, error) {
+	var envelope struct {
+		Version        string `json:"version"`
+		HTTPMethod     string `json:"httpMethod"`
+		RequestContext struct {
+			ELB        json.RawMessage `json:"elb"`
+			DomainName string          `json:"domainName"`
+		} `json:"requestContext"`
+	}
+
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnknownEventSource, err)
+	}
+
+	switch {
+	case envelope.RequestContext.ELB != nil:
+		return EventSourceALB, nil
+	case envelope.Version == "2.0" && strings.Contains(envelope.RequestContext.DomainName, ".lambda-url."):
+		return EventSourceFunctionURL, nil
+	case envelope.Version == "2.0":
+		return EventSourceAPIGatewayV2, nil
+	case envelope.HTTPMethod != "":
+		return EventSourceAPIGatewayV1, nil
+	default:
+		return "", ErrUnknownEventSource
+	}
+}
+
+func (h *Handler) invokeAPIGatewayV1(ctx context.Context, payload json.RawMessage) (events.APIGatewayProxyResponse, error) {
+	var event events.APIGatewayProxyRequest
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return events.APIGatewayProxyResponse{}, fmt.Errorf("unmarshal api gateway v1 event: %w", err)
+	}
+
+	req, err := h.v1.EventToRequestWithContext(ctx, event)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, fmt.Errorf("convert api gateway v1 event: %w", err)
+	}
+
+	writer := core.NewProxyResponseWriter()
+	h.handler.ServeHTTP(writer, req)
+
+	resp, err := writer.GetProxyResponse()
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, fmt.Errorf("api gateway v1 response: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (h *Handler) invokeAPIGatewayV2(ctx context.Context, payload json.RawMessage) (events.APIGatewayV2HTTPResponse, error) {
+	var event events.APIGatewayV2HTTPRequest
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return events.APIGatewayV2HTTPResponse{}, fmt.Errorf("unmarshal api gateway v2 event: %w", err)
+	}
+
+	req, err := h.v2.EventToRequestWithContext(ctx, event)
+	if err != nil {
+		return events.APIGatewayV2HTTPResponse{}, fmt.Errorf("convert api gateway v2 event: %w", err)
+	}
+
+	writer := core.NewProxyResponseWriterV2()
+	h.handler.ServeHTTP(writer, req)
+
+	resp, err := writer.GetProxyResponse()
+	if err != nil {
+		return events.APIGatewayV2HTTPResponse{}, fmt.Errorf("api gateway v2 response: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (h *Handler) invokeALB(ctx context.Context, payload json.RawMessage) (events.ALBTargetGroupResponse, error) {
+	var event events.ALBTargetGroupRequest
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return events.ALBTargetGroupResponse{}, fmt.Errorf("unmarshal alb event: %w", err)
+	}
+
+	// the load balancer passes query parameters as sent by the client, the accessor encodes them again
+	event.QueryStringParameters = unescapeQuery(event.QueryStringParameters)
+	event.MultiValueQueryStringParameters = unescapeMultiValueQuery(event.MultiValueQueryStringParameters)
+
+	req, err := h.alb.EventToRequestWithContext(ctx, event)
+	if err != nil {
+		return events.ALBTargetGroupResponse{}, fmt.Errorf("convert alb event: %w", err)
+	}
+
+	writer := core.NewProxyResponseWriterALB()
+	h.handler.ServeHTTP(writer, req)
+
+	resp, err := writer.GetProxyResponse()
+	if err != nil {
+		return events.ALBTargetGroupResponse{}, fmt.Errorf("alb response: %w", err)
+	}
+
+	resp.StatusDescription = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+
+	// target groups without multi value headers only accept single value headers
+	if event.MultiValueHeaders == nil {
+		resp.Headers = make(map[string]string, len(resp.MultiValueHeaders))
+		for key, values := range resp.MultiValueHeaders {
+			resp.Headers[key] = strings.Join(values, ",")
+		}
+
+		resp.MultiValueHeaders = nil
+	}
+
+	return resp, nil
+}
+
+func unescapeQuery(params map[string]string) map[string]string {
+	unescaped := make(map[string]string, len(params))
+
+	for key, value := range params {
+		unescaped[unescape(key)] = unescape(value)
+	}
+
+	return unescaped
+}
+
+func unescapeMultiValueQuery(params map[string][]string) map[string][]string {
+	unescaped := make(map[string][]string, len(params))
+
+	for key, values := range params {
+		for _, value := range values {
+			unescaped[unescape(key)] = append(unescaped[unescape(key)], unescape(value))
+		}
+	}
+
+	return unescaped
+}
+
+func unescape(value string) string {
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		return unescaped
+	}
+
+	return value
+}
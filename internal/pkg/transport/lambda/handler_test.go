@@ -0,0 +1,265 @@
+//go:build unit
+
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type echo struct {
+	Method string              `json:"method"`
+	Path   string              `json:"path"`
+	Query  map[string][]string `json:"query"`
+	Multi  []string            `json:"multi"`
+	Body   string              `json:"body"`
+}
+
+func echoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		w.Header().Add("X-Reply", "a")
+		w.Header().Add("X-Reply", "b")
+		w.WriteHeader(http.StatusCreated)
+
+		//nolint:errcheck,errchkjson
+		json.NewEncoder(w).Encode(echo{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.Query(),
+			Multi:  req.Header.Values("X-Multi"),
+			Body:   string(body),
+		})
+	})
+}
+
+func decodeEcho(t *testing.T, body string) echo {
+	t.Helper()
+
+	var got echo
+	assert.NoError(t, json.Unmarshal([]byte(body), &got))
+
+	return got
+}
+
+var encodedBody = base64.StdEncoding.EncodeToString([]byte(`{"name":"sensor"}`))
+
+func TestHandler_APIGatewayV1(t *testing.T) {
+	payload := `{
+		"resource": "/{proxy+}",
+		"path": "/api/devices",
+		"httpMethod": "POST",
+		"multiValueHeaders": {"X-Multi": ["one", "two"], "Content-Type": ["application/json"]},
+		"multiValueQueryStringParameters": {"tag": ["a", "b"]},
+		"requestContext": {"requestId": "req-1", "domainName": "api.example.com"},
+		"body": "` + encodedBody + `",
+		"isBase64Encoded": true
+	}`
+
+	for _, source := range []EventSource{EventSourceAuto, EventSourceAPIGatewayV1} {
+		handler, err := NewHandler(echoHandler(), source)
+		assert.NoError(t, err)
+
+		out, err := handler.Invoke(context.Background(), json.RawMessage(payload))
+		assert.NoError(t, err)
+
+		resp, ok := out.(events.APIGatewayProxyResponse)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, []string{"a", "b"}, resp.MultiValueHeaders["X-Reply"])
+
+		got := decodeEcho(t, resp.Body)
+		assert.Equal(t, echo{
+			Method: http.MethodPost,
+			Path:   "/api/devices",
+			Query:  map[string][]string{"tag": {"a", "b"}},
+			Multi:  []string{"one", "two"},
+			Body:   `{"name":"sensor"}`,
+		}, got)
+	}
+}
+
+func TestHandler_APIGatewayV2(t *testing.T) {
+	payload := `{
+		"version": "2.0",
+		"routeKey": "$default",
+		"rawPath": "/api/devices",
+		"rawQueryString": "tag=a&tag=b",
+		"headers": {"x-multi": "one,two"},
+		"requestContext": {
+			"requestId": "req-1",
+			"domainName": "abc.execute-api.ap-southeast-1.amazonaws.com",
+			"http": {"method": "POST", "path": "/api/devices"}
+		},
+		"body": "` + encodedBody + `",
+		"isBase64Encoded": true
+	}`
+
+	handler, err := NewHandler(echoHandler(), EventSourceAuto)
+	assert.NoError(t, err)
+
+	out, err := handler.Invoke(context.Background(), json.RawMessage(payload))
+	assert.NoError(t, err)
+
+	resp, ok := out.(events.APIGatewayV2HTTPResponse)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "a,b", resp.Headers["X-Reply"])
+
+	got := decodeEcho(t, resp.Body)
+	assert.Equal(t, []string{"one", "two"}, got.Multi)
+	assert.Equal(t, map[string][]string{"tag": {"a", "b"}}, got.Query)
+	assert.Equal(t, `{"name":"sensor"}`, got.Body)
+}
+
+func TestHandler_ALB(t *testing.T) {
+	t.Run("multi_value_headers", func(t *testing.T) {
+		payload := `{
+			"requestContext": {"elb": {"targetGroupArn": "arn:aws:elasticloadbalancing:tg"}},
+			"httpMethod": "POST",
+			"path": "/api/devices",
+			"multiValueQueryStringParameters": {"serial": ["SN%2001"], "tag": ["a", "b"]},
+			"multiValueHeaders": {"host": ["lb.example.com"], "x-multi": ["one", "two"]},
+			"body": "` + encodedBody + `",
+			"isBase64Encoded": true
+		}`
+
+		handler, err := NewHandler(echoHandler(), EventSourceAuto)
+		assert.NoError(t, err)
+
+		out, err := handler.Invoke(context.Background(), json.RawMessage(payload))
+		assert.NoError(t, err)
+
+		resp, ok := out.(events.ALBTargetGroupResponse)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "201 Created", resp.StatusDescription)
+		assert.Equal(t, []string{"a", "b"}, resp.MultiValueHeaders["X-Reply"])
+		assert.Nil(t, resp.Headers)
+
+		got := decodeEcho(t, resp.Body)
+		assert.Equal(t, echo{
+			Method: http.MethodPost,
+			Path:   "/api/devices",
+			Query:  map[string][]string{"serial": {"SN 01"}, "tag": {"a", "b"}},
+			Multi:  []string{"one", "two"},
+			Body:   `{"name":"sensor"}`,
+		}, got)
+	})
+
+	t.Run("single_value_headers", func(t *testing.T) {
+		payload := `{
+			"requestContext": {"elb": {"targetGroupArn": "arn:aws:elasticloadbalancing:tg"}},
+			"httpMethod": "GET",
+			"path": "/api/devices",
+			"queryStringParameters": {"serial": "SN%2001"},
+			"headers": {"host": "lb.example.com", "x-multi": "one"},
+			"body": "",
+			"isBase64Encoded": false
+		}`
+
+		handler, err := NewHandler(echoHandler(), EventSourceALB)
+		assert.NoError(t, err)
+
+		out, err := handler.Invoke(context.Background(), json.RawMessage(payload))
+		assert.NoError(t, err)
+
+		resp, ok := out.(events.ALBTargetGroupResponse)
+		assert.True(t, ok)
+		assert.Equal(t, "a,b", resp.Headers["X-Reply"])
+		assert.Nil(t, resp.MultiValueHeaders)
+
+		got := decodeEcho(t, resp.Body)
+		assert.Equal(t, map[string][]string{"serial": {"SN 01"}}, got.Query)
+		assert.Equal(t, []string{"one"}, got.Multi)
+	})
+}
+
+func TestHandler_FunctionURL(t *testing.T) {
+	payload := `{
+		"version": "2.0",
+		"rawPath": "/api/devices",
+		"rawQueryString": "",
+		"headers": {"x-multi": "one,two"},
+		"requestContext": {
+			"requestId": "req-1",
+			"domainName": "abc.lambda-url.ap-southeast-1.on.aws",
+			"http": {"method": "PUT", "path": "/api/devices"}
+		},
+		"body": "` + encodedBody + `",
+		"isBase64Encoded": true
+	}`
+
+	handler, err := NewHandler(echoHandler(), EventSourceAuto)
+	assert.NoError(t, err)
+
+	out, err := handler.Invoke(context.Background(), json.RawMessage(payload))
+	assert.NoError(t, err)
+
+	resp, ok := out.(events.LambdaFunctionURLResponse)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "a,b", resp.Headers["X-Reply"])
+
+	got := decodeEcho(t, resp.Body)
+	assert.Equal(t, http.MethodPut, got.Method)
+	assert.Equal(t, []string{"one", "two"}, got.Multi)
+	assert.Equal(t, `{"name":"sensor"}`, got.Body)
+}
+
+func TestDetectEventSource(t *testing.T) {
+	testCases := map[string]struct {
+		payload string
+		want    EventSource
+		wantErr bool
+	}{
+		"api_gateway_v1": {payload: `{"httpMethod": "GET", "requestContext": {"stage": "dev"}}`, want: EventSourceAPIGatewayV1},
+		"api_gateway_v1_with_version": {
+			payload: `{"version": "1.0", "httpMethod": "GET", "requestContext": {}}`,
+			want:    EventSourceAPIGatewayV1,
+		},
+		"api_gateway_v2": {
+			payload: `{"version": "2.0", "requestContext": {"domainName": "abc.execute-api.ap-southeast-1.amazonaws.com"}}`,
+			want:    EventSourceAPIGatewayV2,
+		},
+		"function_url": {
+			payload: `{"version": "2.0", "requestContext": {"domainName": "abc.lambda-url.ap-southeast-1.on.aws"}}`,
+			want:    EventSourceFunctionURL,
+		},
+		"alb":       {payload: `{"httpMethod": "GET", "requestContext": {"elb": {}}}`, want: EventSourceALB},
+		"unknown":   {payload: `{"Records": []}`, wantErr: true},
+		"not_json":  {payload: `[`, wantErr: true},
+		"not_event": {payload: `"text"`, wantErr: true},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := DetectEventSource([]byte(testCase.payload))
+			if testCase.wantErr {
+				assert.ErrorIs(t, err, ErrUnknownEventSource)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.want, got)
+		})
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	handler, err := NewHandler(echoHandler(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, EventSourceAuto, handler.source)
+
+	_, err = NewHandler(echoHandler(), "sqs")
+	assert.ErrorIs(t, err, ErrUnknownEventSource)
+}
@@ -39,8 +39,31 @@ module "lambda" {
     LOG_LEVEL                   = "debug"
     DYNAMODB_REGION             = var.aws_region
     PROFILING_ENABLED           = "false"
+    HTTP_TIMEOUT                = "25s"
+    HTTP_MAX_BODY_SIZE          = "1048576"
+    HTTP_STRICT_DECODING        = "true"
+    LAMBDA_DEADLINE_MARGIN      = "1s"
     LOCALES_BASE_PATH           = "./resources/locales"
     LOCALES_SUPPORTED_LANGUAGES = "en,id"
+
+    DEVICE_SOFT_DELETE_RETENTION = "720h"
+
+    IDEMPOTENCY_TTL          = "24h"
+    IDEMPOTENCY_LOCK_TIMEOUT = "30s"
+
+    METRICS_ENABLED   = "true"
+    METRICS_NAMESPACE = "GoServerless/${var.environment}"
+
+    TRACING_ENABLED      = "true"
+    TRACING_EXPORTER     = "log"
+    TRACING_SERVICE_NAME = "go-serverless-${var.environment}"
+
+    LOG_REDACT_FIELDS       = "serial"
+    LOG_REDACT_HEADERS      = "Authorization,X-API-Key"
+    LOG_MAX_BODY_SIZE       = "4096"
+    LOG_SUCCESS_SAMPLE_RATE = "1"
+
+    PANIC_SNAPSHOT_SINK = "log"
   }
 
   dynamodb_table_arn = module.dynamodb.table_arn
@@ -10,6 +10,27 @@ resource "aws_dynamodb_table" "table" {
     type = "S"
   }
 
+  attribute {
+    name = "deviceModel"
+    type = "S"
+  }
+
+  # devices by device model, only device items carry deviceModel so the index is sparse
+  global_secondary_index {
+    name            = "deviceModel-index"
+    hash_key        = "deviceModel"
+    range_key       = "PK"
+    projection_type = "ALL"
+    read_capacity   = var.read_capacity
+    write_capacity  = var.write_capacity
+  }
+
+  # soft-deleted devices are purged once expiresAt (unix seconds) is reached
+  ttl {
+    attribute_name = "expiresAt"
+    enabled        = true
+  }
+
   tags = merge(
     var.tags,
     {
@@ -62,9 +62,11 @@ resource "aws_iam_role_policy" "dynamodb_policy" {
           "dynamodb:UpdateItem",
           "dynamodb:DeleteItem",
           "dynamodb:Query",
+          "dynamodb:BatchGetItem",
+          "dynamodb:BatchWriteItem",
           "dynamodb:Scan"
         ]
-        Resource = [var.dynamodb_table_arn]
+        Resource = [var.dynamodb_table_arn, "${var.dynamodb_table_arn}/index/*"]
       }
     ]
   })